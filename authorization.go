@@ -0,0 +1,324 @@
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuthorizationRequest represents an OAuth 2.0 / OIDC authorization request
+//
+// RFC 6749 COMPLIANCE:
+// - Section 4.1.1: Authorization Request (response_type, client_id, redirect_uri, scope, state)
+//
+// RFC 7636 COMPLIANCE:
+// - Section 4.3: code_challenge and code_challenge_method (PKCE)
+//
+// OIDC Core 1.0 COMPLIANCE:
+// - Section 3.1.2.1: nonce, prompt, login_hint, max_age
+//
+// RFC 9101 COMPLIANCE:
+// - Section 5: with a request object key, parameters are sent as a signed request object
+//
+// Create with NewAuthorizationRequest and chain the With* methods for optional fields
+type AuthorizationRequest struct {
+	RedirectURI string   // Callback URL registered for the client
+	State       string   // Opaque CSRF value echoed back in the callback
+	Scopes      []string // Requested scopes (falls back to Discovery.Scopes if empty)
+
+	// PKCE (RFC 7636)
+	CodeChallenge       string // Challenge derived from the code verifier
+	CodeChallengeMethod string // "S256" (plain is not supported by MCP)
+
+	// OIDC parameters
+	Nonce     string // Binds the ID token to this request
+	Prompt    string // none, login, consent, select_account
+	LoginHint string // Hint about the user identifier
+	MaxAge    *int   // Max seconds since last authentication (nil = not sent)
+
+	// Additional OAuth parameters
+	ResponseMode         string                // query, fragment, form_post
+	Resource             string                // RFC 8707 resource indicator (falls back to Discovery.ResourceURL)
+	AuthorizationDetails []AuthorizationDetail // RFC 9396 Rich Authorization Requests
+	ScopeSeparator       string                // Delimiter for the scope parameter (default: space)
+
+	// JWT-Secured Authorization Request (RFC 9101)
+	RequestObjectKey   *ecdsa.PrivateKey // P-256 key signing the request object (nil = plain query parameters)
+	RequestObjectKeyID string            // kid header of the request object (optional)
+}
+
+// NewAuthorizationRequest creates an authorization request with the required parameters
+func NewAuthorizationRequest(redirectURI, state string, scopes []string) *AuthorizationRequest {
+	return &AuthorizationRequest{
+		RedirectURI: redirectURI,
+		State:       state,
+		Scopes:      scopes,
+	}
+}
+
+// WithPKCE sets the S256 PKCE code challenge
+func (r *AuthorizationRequest) WithPKCE(codeChallenge string) *AuthorizationRequest {
+	r.CodeChallenge = codeChallenge
+	r.CodeChallengeMethod = "S256"
+	return r
+}
+
+// WithNonce sets the OIDC nonce parameter
+func (r *AuthorizationRequest) WithNonce(nonce string) *AuthorizationRequest {
+	r.Nonce = nonce
+	return r
+}
+
+// WithPrompt sets the OIDC prompt parameter (e.g. "login", "consent")
+func (r *AuthorizationRequest) WithPrompt(prompt string) *AuthorizationRequest {
+	r.Prompt = prompt
+	return r
+}
+
+// WithLoginHint sets the OIDC login_hint parameter
+func (r *AuthorizationRequest) WithLoginHint(hint string) *AuthorizationRequest {
+	r.LoginHint = hint
+	return r
+}
+
+// WithMaxAge sets the OIDC max_age parameter
+//
+// OIDC Core 1.0 Section 3.1.2.1:
+// - Specifies the allowable elapsed time in seconds since the user last authenticated
+// - If the session is older, the authorization server MUST re-authenticate the user
+// - max_age=0 forces immediate re-authentication (equivalent to prompt=login)
+//
+// seconds must be >= 0; negative values cause Build to fail
+func (r *AuthorizationRequest) WithMaxAge(seconds int) *AuthorizationRequest {
+	r.MaxAge = &seconds
+	return r
+}
+
+// WithResponseMode sets the response_mode parameter (e.g. "form_post")
+func (r *AuthorizationRequest) WithResponseMode(mode string) *AuthorizationRequest {
+	r.ResponseMode = mode
+	return r
+}
+
+// WithResource overrides the RFC 8707 resource indicator taken from Discovery.ResourceURL
+func (r *AuthorizationRequest) WithResource(resource string) *AuthorizationRequest {
+	r.Resource = resource
+	return r
+}
+
+// WithAuthorizationDetails sets the RFC 9396 authorization_details parameter
+// Check Discovery.SupportsRAR before relying on fine-grained authorization
+func (r *AuthorizationRequest) WithAuthorizationDetails(details []AuthorizationDetail) *AuthorizationRequest {
+	r.AuthorizationDetails = details
+	return r
+}
+
+// WithScopeSeparator sets the delimiter used to join the scope parameter (default: space)
+//
+// INTEROP WORKAROUND: RFC 6749 Section 3.3 requires space-delimited scopes; only use this for
+// non-compliant servers that expect e.g. commas. The separator is URL-encoded like any other
+// character, so "+" is sent as %2B; servers that expect a literal "+" between scopes already
+// get one with the default separator, since a space is encoded as "+" in the query string.
+func (r *AuthorizationRequest) WithScopeSeparator(separator string) *AuthorizationRequest {
+	r.ScopeSeparator = separator
+	return r
+}
+
+// WithRequestObject sends the parameters as an ES256-signed request object (RFC 9101)
+//
+// The public key must be registered with the authorization server, e.g. through the client's
+// jwks or jwks_uri metadata; keyID is sent as the kid header when non-empty.
+// Required when Discovery.RequireRequestObject is set.
+func (r *AuthorizationRequest) WithRequestObject(key *ecdsa.PrivateKey, keyID string) *AuthorizationRequest {
+	r.RequestObjectKey = key
+	r.RequestObjectKeyID = keyID
+	return r
+}
+
+// Build returns the authorization endpoint URL the user should be redirected to
+//
+// MCP SPEC COMPLIANCE:
+// - Includes the RFC 8707 resource parameter so the issued token is audience-bound
+//
+// RFC 9101 COMPLIANCE:
+// - Section 5: with a request object key only client_id and request are sent in the query
+// - require_signed_request_object without a key returns ErrRequestObjectRequired
+func (r *AuthorizationRequest) Build(d *Discovery, creds *ClientCredentials) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("discovery is required")
+	}
+	if d.AuthorizationEndpoint == "" {
+		return "", fmt.Errorf("no authorization endpoint in discovery")
+	}
+	if creds == nil || creds.ClientID == "" {
+		return "", fmt.Errorf("client_id is required")
+	}
+	if r.MaxAge != nil && *r.MaxAge < 0 {
+		return "", fmt.Errorf("max_age must be >= 0, got %d", *r.MaxAge)
+	}
+	if d.RequireRequestObject && r.RequestObjectKey == nil {
+		return "", fmt.Errorf("%w: configure a signing key with WithRequestObject", ErrRequestObjectRequired)
+	}
+
+	authURL, err := url.Parse(d.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", creds.ClientID)
+	setIfNotEmpty(query, "redirect_uri", r.RedirectURI)
+	setIfNotEmpty(query, "state", r.State)
+
+	scopes := r.Scopes
+	if len(scopes) == 0 {
+		scopes = d.Scopes
+	}
+	separator := r.ScopeSeparator
+	if separator == "" {
+		separator = defaultScopeSeparator
+	}
+	setIfNotEmpty(query, "scope", strings.Join(scopes, separator))
+
+	if r.CodeChallenge != "" {
+		query.Set("code_challenge", r.CodeChallenge)
+		query.Set("code_challenge_method", r.CodeChallengeMethod)
+	}
+
+	resource := r.Resource
+	if resource == "" {
+		resource = d.ResourceURL
+	}
+	if resource != "" {
+		canonical, err := CanonicalizeResource(resource)
+		if err != nil {
+			return "", fmt.Errorf("invalid resource indicator: %w", err)
+		}
+		query.Set("resource", canonical)
+	}
+
+	setIfNotEmpty(query, "nonce", r.Nonce)
+	setIfNotEmpty(query, "prompt", r.Prompt)
+	setIfNotEmpty(query, "login_hint", r.LoginHint)
+	setIfNotEmpty(query, "response_mode", r.ResponseMode)
+	if r.MaxAge != nil {
+		query.Set("max_age", strconv.Itoa(*r.MaxAge))
+	}
+
+	// RFC 9396 Section 3: authorization_details is a JSON array in the query string
+	if len(r.AuthorizationDetails) > 0 {
+		details, err := json.Marshal(r.AuthorizationDetails)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal authorization_details: %w", err)
+		}
+		query.Set("authorization_details", string(details))
+	}
+
+	// Preserve any query parameters already present on the endpoint (RFC 6749 Section 3.1)
+	endpointQuery := authURL.Query()
+	if r.RequestObjectKey != nil {
+		requestObject, err := buildRequestObject(d, creds.ClientID, query, r.RequestObjectKey, r.RequestObjectKeyID, time.Now())
+		if err != nil {
+			return "", fmt.Errorf("failed to build request object: %w", err)
+		}
+		// RFC 9101 Section 5: client_id is repeated outside the request object
+		query = url.Values{}
+		query.Set("client_id", creds.ClientID)
+		query.Set("request", requestObject)
+	}
+	for key, values := range query {
+		endpointQuery[key] = values
+	}
+
+	authURL.RawQuery = endpointQuery.Encode()
+	return authURL.String(), nil
+}
+
+// MarshalJSON serializes the detail as a flat JSON object with "type" and the type-specific fields
+func (a AuthorizationDetail) MarshalJSON() ([]byte, error) {
+	if a.Type == "" {
+		return nil, fmt.Errorf("authorization detail type is required")
+	}
+
+	object := make(map[string]any, len(a.Fields)+1)
+	for key, value := range a.Fields {
+		object[key] = value
+	}
+	object["type"] = a.Type
+
+	return json.Marshal(object)
+}
+
+// setIfNotEmpty sets a query parameter only when the value is non-empty
+func setIfNotEmpty(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// AuthorizationOption customizes the authorization request built by BuildAuthorizationURL
+type AuthorizationOption func(*AuthorizationRequest)
+
+// WithMaxAge sets the max_age parameter on the authorization request
+// See AuthorizationRequest.WithMaxAge
+func WithMaxAge(seconds int) AuthorizationOption {
+	return func(r *AuthorizationRequest) {
+		r.WithMaxAge(seconds)
+	}
+}
+
+// WithRequestObjectKey signs the authorization request as an RFC 9101 request object
+// See AuthorizationRequest.WithRequestObject
+func WithRequestObjectKey(key *ecdsa.PrivateKey, keyID string) AuthorizationOption {
+	return func(r *AuthorizationRequest) {
+		r.WithRequestObject(key, keyID)
+	}
+}
+
+// WithScopes requests the given scopes instead of Discovery.Scopes
+func WithScopes(scopes ...string) AuthorizationOption {
+	return func(r *AuthorizationRequest) {
+		r.Scopes = scopes
+	}
+}
+
+// WithScopeSeparator sets the scope delimiter on the authorization request
+// See AuthorizationRequest.WithScopeSeparator
+func WithScopeSeparator(separator string) AuthorizationOption {
+	return func(r *AuthorizationRequest) {
+		r.WithScopeSeparator(separator)
+	}
+}
+
+// BuildAuthorizationURL builds the URL the user is redirected to for authorization
+// using the scopes and resource from discovery (convenience wrapper around AuthorizationRequest.Build)
+//
+// codeChallenge: The S256 PKCE challenge derived from the code verifier
+//
+// When Discovery.Scopes is non-empty, scopes requested with WithScopes must be a subset of it
+// (see ValidateScopes)
+func BuildAuthorizationURL(discovery *Discovery, clientID, redirectURI, state, codeChallenge string, opts ...AuthorizationOption) (string, error) {
+	if discovery == nil {
+		return "", fmt.Errorf("discovery is required")
+	}
+	req := NewAuthorizationRequest(redirectURI, state, discovery.Scopes)
+	if codeChallenge != "" {
+		req.WithPKCE(codeChallenge)
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	if len(discovery.Scopes) > 0 {
+		if err := ValidateScopes(req.Scopes, discovery.Scopes); err != nil {
+			return "", err
+		}
+	}
+
+	return req.Build(discovery, &ClientCredentials{ClientID: clientID})
+}
@@ -0,0 +1,402 @@
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestBuildAuthorizationURL verifies the standard authorization request parameters
+func TestBuildAuthorizationURL(t *testing.T) {
+	discovery := &Discovery{
+		AuthorizationEndpoint: "https://auth.example.com/authorize",
+		ResourceURL:           "https://api.example.com",
+		Scopes:                []string{"read", "write"},
+	}
+
+	authURL, err := BuildAuthorizationURL(discovery, "client-123", DefaultRedirectURI, "state-abc", "challenge-xyz")
+	if err != nil {
+		t.Fatalf("BuildAuthorizationURL failed: %v", err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Invalid URL returned: %v", err)
+	}
+	query := parsed.Query()
+
+	expected := map[string]string{
+		"response_type":         "code",
+		"client_id":             "client-123",
+		"redirect_uri":          DefaultRedirectURI,
+		"state":                 "state-abc",
+		"scope":                 "read write",
+		"code_challenge":        "challenge-xyz",
+		"code_challenge_method": "S256",
+		"resource":              "https://api.example.com",
+	}
+	for key, want := range expected {
+		if got := query.Get(key); got != want {
+			t.Errorf("Parameter %s: expected %q, got %q", key, want, got)
+		}
+	}
+	if query.Has("max_age") {
+		t.Error("Expected max_age to be omitted when not set")
+	}
+}
+
+// TestBuildAuthorizationURL_MaxAge verifies max_age handling and validation
+func TestBuildAuthorizationURL_MaxAge(t *testing.T) {
+	discovery := &Discovery{
+		AuthorizationEndpoint: "https://auth.example.com/authorize",
+	}
+
+	tests := []struct {
+		name        string
+		maxAge      int
+		expectValue string
+		expectError bool
+	}{
+		{
+			name:        "zero forces re-authentication",
+			maxAge:      0,
+			expectValue: "0",
+		},
+		{
+			name:        "positive value",
+			maxAge:      3600,
+			expectValue: "3600",
+		},
+		{
+			name:        "negative value rejected",
+			maxAge:      -1,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authURL, err := BuildAuthorizationURL(discovery, "client-123", "", "state", "", WithMaxAge(tt.maxAge))
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("Expected error for max_age=%d", tt.maxAge)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildAuthorizationURL failed: %v", err)
+			}
+
+			parsed, _ := url.Parse(authURL)
+			if got := parsed.Query().Get("max_age"); got != tt.expectValue {
+				t.Errorf("Expected max_age=%s, got %q", tt.expectValue, got)
+			}
+		})
+	}
+}
+
+// TestAuthorizationRequest_Build verifies optional parameters set via method chaining
+func TestAuthorizationRequest_Build(t *testing.T) {
+	discovery := &Discovery{
+		AuthorizationEndpoint: "https://auth.example.com/authorize?tenant=acme",
+		ResourceURL:           "https://api.example.com",
+		Scopes:                []string{"read"},
+	}
+	creds := &ClientCredentials{ClientID: "client-123"}
+
+	req := NewAuthorizationRequest(DefaultRedirectURI, "state-abc", []string{"read", "admin"}).
+		WithPKCE("challenge-xyz").
+		WithNonce("nonce-1").
+		WithPrompt("consent").
+		WithLoginHint("user@example.com").
+		WithResource("https://api.example.com/mcp")
+
+	authURL, err := req.Build(discovery, creds)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	parsed, _ := url.Parse(authURL)
+	query := parsed.Query()
+
+	expected := map[string]string{
+		"tenant":                "acme",
+		"client_id":             "client-123",
+		"scope":                 "read admin",
+		"code_challenge_method": "S256",
+		"nonce":                 "nonce-1",
+		"prompt":                "consent",
+		"login_hint":            "user@example.com",
+		"resource":              "https://api.example.com/mcp",
+	}
+	for key, want := range expected {
+		if got := query.Get(key); got != want {
+			t.Errorf("Parameter %s: expected %q, got %q", key, want, got)
+		}
+	}
+}
+
+// TestAuthorizationRequest_MissingClientID verifies Build fails without client credentials
+func TestAuthorizationRequest_MissingClientID(t *testing.T) {
+	discovery := &Discovery{AuthorizationEndpoint: "https://auth.example.com/authorize"}
+
+	if _, err := NewAuthorizationRequest("", "state", nil).Build(discovery, nil); err == nil {
+		t.Error("Expected error when credentials are missing")
+	}
+}
+
+// TestAuthorizationRequest_NilDiscovery verifies a nil discovery is an error rather than a panic
+func TestAuthorizationRequest_NilDiscovery(t *testing.T) {
+	if _, err := NewAuthorizationRequest("", "state", nil).Build(nil, &ClientCredentials{ClientID: "client-123"}); err == nil {
+		t.Error("Expected error from Build without discovery")
+	}
+	if _, err := BuildAuthorizationURL(nil, "client-123", DefaultRedirectURI, "state", "challenge"); err == nil {
+		t.Error("Expected error from BuildAuthorizationURL without discovery")
+	}
+	if _, err := StartAuthorization(NewMemoryStateStore(0), nil, &ClientCredentials{ClientID: "client-123"}, DefaultRedirectURI); err == nil {
+		t.Error("Expected error from StartAuthorization without discovery")
+	}
+}
+
+// TestAuthorizationRequest_AuthorizationDetails verifies RFC 9396 serialization
+func TestAuthorizationRequest_AuthorizationDetails(t *testing.T) {
+	discovery := &Discovery{AuthorizationEndpoint: "https://auth.example.com/authorize"}
+	creds := &ClientCredentials{ClientID: "client-123"}
+
+	details := []AuthorizationDetail{
+		{
+			Type: "payment_initiation",
+			Fields: map[string]any{
+				"actions":          []string{"initiate"},
+				"instructedAmount": map[string]any{"currency": "EUR", "amount": "123.50"},
+			},
+		},
+	}
+
+	authURL, err := NewAuthorizationRequest("", "state", nil).WithAuthorizationDetails(details).Build(discovery, creds)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	parsed, _ := url.Parse(authURL)
+	var decoded []map[string]any
+	if err := json.Unmarshal([]byte(parsed.Query().Get("authorization_details")), &decoded); err != nil {
+		t.Fatalf("authorization_details is not a JSON array: %v", err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("Expected 1 authorization detail, got %d", len(decoded))
+	}
+	if decoded[0]["type"] != "payment_initiation" {
+		t.Errorf("Expected type=payment_initiation, got %v", decoded[0]["type"])
+	}
+	if _, ok := decoded[0]["instructedAmount"]; !ok {
+		t.Error("Expected type-specific fields to be flattened into the object")
+	}
+
+	// A detail without a type is invalid
+	_, err = NewAuthorizationRequest("", "state", nil).
+		WithAuthorizationDetails([]AuthorizationDetail{{Fields: map[string]any{"actions": "read"}}}).
+		Build(discovery, creds)
+	if err == nil {
+		t.Error("Expected error for authorization detail without type")
+	}
+}
+
+// TestBuildAuthorizationURL_ScopeSeparator verifies the default space separator and the comma workaround
+func TestBuildAuthorizationURL_ScopeSeparator(t *testing.T) {
+	discovery := &Discovery{
+		AuthorizationEndpoint: "https://auth.example.com/authorize",
+		Scopes:                []string{"read", "write"},
+	}
+
+	tests := []struct {
+		name          string
+		opts          []AuthorizationOption
+		expectedScope string
+		expectedRaw   string
+	}{
+		{
+			name:          "space (default)",
+			expectedScope: "read write",
+			expectedRaw:   "scope=read+write",
+		},
+		{
+			name:          "comma",
+			opts:          []AuthorizationOption{WithScopeSeparator(",")},
+			expectedScope: "read,write",
+			expectedRaw:   "scope=read%2Cwrite",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authURL, err := BuildAuthorizationURL(discovery, "client-123", DefaultRedirectURI, "state", "", tt.opts...)
+			if err != nil {
+				t.Fatalf("BuildAuthorizationURL failed: %v", err)
+			}
+			parsed, err := url.Parse(authURL)
+			if err != nil {
+				t.Fatalf("Invalid URL returned: %v", err)
+			}
+
+			if got := parsed.Query().Get("scope"); got != tt.expectedScope {
+				t.Errorf("Expected scope %q, got %q", tt.expectedScope, got)
+			}
+			if !strings.Contains(parsed.RawQuery, tt.expectedRaw) {
+				t.Errorf("Expected raw query to contain %q, got %q", tt.expectedRaw, parsed.RawQuery)
+			}
+		})
+	}
+}
+
+// TestBuildAuthorizationURL_ValidatesScopes verifies requested scopes must be advertised in Discovery.Scopes
+func TestBuildAuthorizationURL_ValidatesScopes(t *testing.T) {
+	discovery := &Discovery{
+		AuthorizationEndpoint: "https://auth.example.com/authorize",
+		Scopes:                []string{"read", "write"},
+	}
+
+	authURL, err := BuildAuthorizationURL(discovery, "client-123", DefaultRedirectURI, "state", "", WithScopes("read"))
+	if err != nil {
+		t.Fatalf("BuildAuthorizationURL failed: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Invalid URL returned: %v", err)
+	}
+	if got := parsed.Query().Get("scope"); got != "read" {
+		t.Errorf("Expected scope %q, got %q", "read", got)
+	}
+
+	_, err = BuildAuthorizationURL(discovery, "client-123", DefaultRedirectURI, "state", "", WithScopes("read", "admin"))
+	if !errors.Is(err, ErrUnsupportedScope) {
+		t.Fatalf("Expected ErrUnsupportedScope, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "admin") {
+		t.Errorf("Expected error to list the unsupported scope, got %q", err.Error())
+	}
+
+	// Without advertised scopes there is nothing to validate against
+	discovery.Scopes = nil
+	if _, err := BuildAuthorizationURL(discovery, "client-123", DefaultRedirectURI, "state", "", WithScopes("admin")); err != nil {
+		t.Errorf("Expected no validation without Discovery.Scopes, got %v", err)
+	}
+}
+
+// TestAuthorizationRequest_CanonicalResource verifies the resource parameter is canonicalized
+func TestAuthorizationRequest_CanonicalResource(t *testing.T) {
+	discovery := &Discovery{
+		AuthorizationEndpoint: "https://auth.example.com/authorize",
+		ResourceURL:           "HTTPS://API.Example.com:443/mcp#frag",
+	}
+
+	authURL, err := NewAuthorizationRequest(DefaultRedirectURI, "state", nil).Build(discovery, &ClientCredentials{ClientID: "client-123"})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Invalid URL returned: %v", err)
+	}
+	if got := parsed.Query().Get("resource"); got != "https://api.example.com/mcp" {
+		t.Errorf("Expected canonical resource, got %q", got)
+	}
+}
+
+// TestAuthorizationRequest_RequestObjectRequired verifies that a server requiring
+// signed request objects (RFC 9101) is rejected without a signing key
+func TestAuthorizationRequest_RequestObjectRequired(t *testing.T) {
+	discovery := &Discovery{
+		AuthorizationEndpoint: "https://auth.example.com/authorize",
+		RequireRequestObject:  true,
+	}
+
+	_, err := BuildAuthorizationURL(discovery, "client-123", DefaultRedirectURI, "state-abc", "challenge-xyz")
+	if !errors.Is(err, ErrRequestObjectRequired) {
+		t.Fatalf("Expected ErrRequestObjectRequired, got %v", err)
+	}
+}
+
+// TestAuthorizationRequest_RequestObject verifies that with a key the parameters are sent
+// as a signed request object and only client_id and request remain in the query
+func TestAuthorizationRequest_RequestObject(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	discovery := &Discovery{
+		AuthorizationEndpoint:    "https://auth.example.com/authorize?tenant=acme",
+		Issuer:                   "https://auth.example.com",
+		ResourceURL:              "https://api.example.com",
+		RequireRequestObject:     true,
+		RequestObjectSigningAlgs: []string{"RS256", "ES256"},
+	}
+
+	authURL, err := BuildAuthorizationURL(discovery, "client-123", DefaultRedirectURI, "state-abc", "challenge-xyz",
+		WithRequestObjectKey(key, "key-1"), WithMaxAge(60))
+	if err != nil {
+		t.Fatalf("BuildAuthorizationURL failed: %v", err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Invalid URL: %v", err)
+	}
+	query := parsed.Query()
+	if len(query) != 3 || query.Get("client_id") != "client-123" || query.Get("tenant") != "acme" {
+		t.Errorf("Expected only tenant, client_id and request in the query, got %v", query)
+	}
+
+	header, claims := decodeES256JWT(t, &key.PublicKey, query.Get("request"))
+	if header["typ"] != "oauth-authz-req+jwt" || header["alg"] != "ES256" || header["kid"] != "key-1" {
+		t.Errorf("Unexpected header: %v", header)
+	}
+	expected := map[string]any{
+		"iss":                   "client-123",
+		"aud":                   "https://auth.example.com",
+		"client_id":             "client-123",
+		"response_type":         "code",
+		"redirect_uri":          DefaultRedirectURI,
+		"state":                 "state-abc",
+		"code_challenge":        "challenge-xyz",
+		"code_challenge_method": "S256",
+		"resource":              "https://api.example.com",
+		"max_age":               float64(60),
+	}
+	for name, want := range expected {
+		if claims[name] != want {
+			t.Errorf("Expected claim %s=%v, got %v", name, want, claims[name])
+		}
+	}
+	iat, _ := claims["iat"].(float64)
+	exp, _ := claims["exp"].(float64)
+	if exp-iat != requestObjectLifetime.Seconds() {
+		t.Errorf("Expected exp %v after iat, got iat=%v exp=%v", requestObjectLifetime, iat, exp)
+	}
+	if claims["jti"] == "" || claims["jti"] == nil {
+		t.Error("Expected jti claim")
+	}
+}
+
+// TestAuthorizationRequest_RequestObjectUnsupportedAlg verifies that ES256 request objects
+// are not sent to a server advertising other signing algorithms only
+func TestAuthorizationRequest_RequestObjectUnsupportedAlg(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	discovery := &Discovery{
+		AuthorizationEndpoint:    "https://auth.example.com/authorize",
+		RequestObjectSigningAlgs: []string{"RS256"},
+	}
+
+	req := NewAuthorizationRequest(DefaultRedirectURI, "state-abc", nil).WithRequestObject(key, "")
+	if _, err := req.Build(discovery, &ClientCredentials{ClientID: "client-123"}); err == nil {
+		t.Fatal("Expected error for unsupported request object signing algorithm")
+	}
+}
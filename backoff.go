@@ -0,0 +1,95 @@
+package oauth
+
+import "time"
+
+// withRetryAfter raises delay to the Retry-After carried by lastError, if that is longer
+func withRetryAfter(delay time.Duration, lastError error) time.Duration {
+	if retryAfter, ok := retryAfterFromError(lastError, time.Now()); ok {
+		return max(delay, retryAfter)
+	}
+	return delay
+}
+
+// BackoffStrategy decides how long to wait before retrying a failed operation
+//
+// attempt is the number of attempts made so far (1 after the first failure) and lastError
+// is the error that attempt returned. NextDelay returns the delay before the next attempt
+// and false when no further attempt should be made.
+//
+// The built-in strategies never retry sooner than the server asked for: when lastError
+// carries a Retry-After (TokenError.RetryAfter, or the response of a MetadataFetchError or
+// DCRError) it is a floor on the delay.
+type BackoffStrategy interface {
+	NextDelay(attempt int, lastError error) (time.Duration, bool)
+}
+
+// BackoffFunc adapts an ordinary function to a BackoffStrategy (e.g. for a linear backoff)
+type BackoffFunc func(attempt int, lastError error) (time.Duration, bool)
+
+// NextDelay calls f(attempt, lastError)
+func (f BackoffFunc) NextDelay(attempt int, lastError error) (time.Duration, bool) {
+	return f(attempt, lastError)
+}
+
+// noRetry never retries
+type noRetry struct{}
+
+func (noRetry) NextDelay(int, error) (time.Duration, bool) { return 0, false }
+
+// NoRetry returns a BackoffStrategy that gives up after the first failure
+func NoRetry() BackoffStrategy {
+	return noRetry{}
+}
+
+// constantBackoff waits the same delay between attempts
+type constantBackoff struct {
+	delay       time.Duration
+	maxAttempts int
+}
+
+func (b constantBackoff) NextDelay(attempt int, lastError error) (time.Duration, bool) {
+	if !attemptsRemain(attempt, b.maxAttempts) {
+		return 0, false
+	}
+	return withRetryAfter(b.delay, lastError), true
+}
+
+// ConstantBackoff returns a BackoffStrategy that waits d between attempts
+// maxAttempts limits the total number of attempts, including the first (<= 0 = unlimited)
+func ConstantBackoff(d time.Duration, maxAttempts int) BackoffStrategy {
+	return constantBackoff{delay: d, maxAttempts: maxAttempts}
+}
+
+// exponentialBackoff doubles the delay after every attempt up to a cap
+type exponentialBackoff struct {
+	base        time.Duration
+	maxDelay    time.Duration
+	maxAttempts int
+}
+
+func (b exponentialBackoff) NextDelay(attempt int, lastError error) (time.Duration, bool) {
+	if !attemptsRemain(attempt, b.maxAttempts) {
+		return 0, false
+	}
+	delay := b.base
+	for i := 1; i < attempt && delay < b.maxDelay; i++ {
+		delay *= 2
+	}
+	return withRetryAfter(min(delay, b.maxDelay), lastError), true
+}
+
+// ExponentialBackoff returns a BackoffStrategy that waits base after the first failure and
+// doubles the delay after each further failure, never exceeding maxDelay (unless Retry-After asks for longer)
+// maxAttempts limits the total number of attempts, including the first (<= 0 = unlimited).
+// There is no jitter, so delays are deterministic.
+func ExponentialBackoff(base, maxDelay time.Duration, maxAttempts int) BackoffStrategy {
+	if maxDelay < base {
+		maxDelay = base
+	}
+	return exponentialBackoff{base: base, maxDelay: maxDelay, maxAttempts: maxAttempts}
+}
+
+// attemptsRemain reports whether another attempt is allowed after attempt attempts
+func attemptsRemain(attempt, maxAttempts int) bool {
+	return maxAttempts <= 0 || attempt < maxAttempts
+}
@@ -0,0 +1,107 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestBackoffStrategies verifies the delays and retry decisions of the built-in strategies
+func TestBackoffStrategies(t *testing.T) {
+	type step struct {
+		delay time.Duration
+		retry bool
+	}
+
+	tests := []struct {
+		name     string
+		strategy BackoffStrategy
+		expected []step // Result of NextDelay for attempt 1, 2, ...
+	}{
+		{
+			name:     "no retry",
+			strategy: NoRetry(),
+			expected: []step{{0, false}},
+		},
+		{
+			name:     "constant",
+			strategy: ConstantBackoff(2*time.Second, 3),
+			expected: []step{{2 * time.Second, true}, {2 * time.Second, true}, {0, false}},
+		},
+		{
+			name:     "constant unlimited",
+			strategy: ConstantBackoff(time.Second, 0),
+			expected: []step{{time.Second, true}, {time.Second, true}, {time.Second, true}, {time.Second, true}},
+		},
+		{
+			name:     "exponential capped",
+			strategy: ExponentialBackoff(time.Second, 5*time.Second, 0),
+			expected: []step{{time.Second, true}, {2 * time.Second, true}, {4 * time.Second, true}, {5 * time.Second, true}, {5 * time.Second, true}},
+		},
+		{
+			name:     "exponential max attempts",
+			strategy: ExponentialBackoff(100*time.Millisecond, time.Minute, 3),
+			expected: []step{{100 * time.Millisecond, true}, {200 * time.Millisecond, true}, {0, false}},
+		},
+		{
+			name: "custom linear",
+			strategy: BackoffFunc(func(attempt int, _ error) (time.Duration, bool) {
+				return time.Duration(attempt) * time.Second, attempt < 3
+			}),
+			expected: []step{{time.Second, true}, {2 * time.Second, true}, {3 * time.Second, false}},
+		},
+	}
+
+	lastErr := errors.New("temporary failure")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.expected {
+				delay, retry := tt.strategy.NextDelay(i+1, lastErr)
+				if delay != want.delay || retry != want.retry {
+					t.Errorf("Attempt %d: expected (%s, %v), got (%s, %v)", i+1, want.delay, want.retry, delay, retry)
+				}
+			}
+		})
+	}
+}
+
+// TestExponentialBackoff_LargeAttempt verifies the delay saturates at the cap without overflowing
+func TestExponentialBackoff_LargeAttempt(t *testing.T) {
+	delay, retry := ExponentialBackoff(time.Second, time.Hour, 0).NextDelay(1000, nil)
+	if delay != time.Hour || !retry {
+		t.Errorf("Expected (1h, true), got (%s, %v)", delay, retry)
+	}
+}
+
+// TestBackoff_RetryAfterFloor verifies the built-in strategies never retry sooner than a
+// Retry-After carried by the last error
+func TestBackoff_RetryAfterFloor(t *testing.T) {
+	metadataErr := &MetadataFetchError{
+		URL:              "https://auth.example.com/.well-known/oauth-authorization-server",
+		StatusCode:       http.StatusServiceUnavailable,
+		capturedResponse: capturedResponse{resp: &http.Response{Header: http.Header{"Retry-After": {"7"}}}},
+	}
+
+	tests := []struct {
+		name     string
+		strategy BackoffStrategy
+		err      error
+		expected time.Duration
+	}{
+		{"constant below Retry-After", ConstantBackoff(time.Second, 0), &TokenError{Code: "slow_down", RetryAfter: 5 * time.Second}, 5 * time.Second},
+		{"constant above Retry-After", ConstantBackoff(10*time.Second, 0), &TokenError{Code: "slow_down", RetryAfter: 5 * time.Second}, 10 * time.Second},
+		{"exponential beyond its cap", ExponentialBackoff(time.Second, 2*time.Second, 0), &TokenError{Code: "slow_down", RetryAfter: 30 * time.Second}, 30 * time.Second},
+		{"metadata response header", ExponentialBackoff(time.Second, time.Minute, 0), metadataErr, 7 * time.Second},
+		{"no Retry-After", ConstantBackoff(time.Second, 0), errors.New("network down"), time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retry := tt.strategy.NextDelay(1, tt.err)
+			if delay != tt.expected || !retry {
+				t.Errorf("Expected (%s, true), got (%s, %v)", tt.expected, delay, retry)
+			}
+		})
+	}
+}
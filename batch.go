@@ -0,0 +1,78 @@
+package oauth
+
+import (
+	"context"
+	"sync"
+)
+
+// DiscoverAll runs DiscoverOAuthRequirements for every MCP server URL using at most
+// concurrency workers, and returns the results and errors keyed by URL
+//
+// Every URL appears in exactly one of the two maps (duplicates are discovered once).
+// A concurrency below 1 is treated as 1. The same options are applied to every discovery;
+// combine with WithHostLimiter to also bound requests per host.
+//
+// CANCELLATION: when ctx is canceled, in-flight discoveries are aborted (they share ctx) and
+// pending URLs are not started; DiscoverAll returns as soon as the in-flight requests
+// have unwound, with ctx.Err() (e.g. context.Canceled) as the error of every URL that
+// did not complete.
+func DiscoverAll(ctx context.Context, mcpURLs []string, concurrency int, opts ...DiscoveryOption) (map[string]*Discovery, map[string]error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make(map[string]*Discovery)
+	errs := make(map[string]error)
+	var mu sync.Mutex
+
+	urls := make(chan string)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(mcpURLs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for mcpURL := range urls {
+				var discovery *Discovery
+				err := ctx.Err()
+				if err == nil {
+					discovery, err = DiscoverOAuthRequirements(ctx, mcpURL, opts...)
+				}
+
+				mu.Lock()
+				if err != nil {
+					errs[mcpURL] = err
+				} else {
+					results[mcpURL] = discovery
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	seen := make(map[string]bool)
+	var pending []string
+	for _, mcpURL := range mcpURLs {
+		if seen[mcpURL] {
+			continue
+		}
+		seen[mcpURL] = true
+		if pending != nil {
+			pending = append(pending, mcpURL)
+			continue
+		}
+		select {
+		case urls <- mcpURL:
+		case <-ctx.Done():
+			// Stop handing out work; the remaining URLs fail with the context error
+			pending = []string{mcpURL}
+		}
+	}
+	close(urls)
+	wg.Wait()
+
+	for _, mcpURL := range pending {
+		errs[mcpURL] = ctx.Err()
+	}
+
+	return results, errs
+}
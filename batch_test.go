@@ -0,0 +1,121 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDiscoverAll verifies results and errors are keyed by URL and concurrency is bounded
+func TestDiscoverAll(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	release := make(chan struct{})
+
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	// Let probes through one at a time so the pool has a chance to saturate
+	go func() {
+		for range 4 { // One probe per distinct reachable URL
+			release <- struct{}{}
+		}
+	}()
+
+	servers := []string{
+		server.URL + "/mcp",
+		server.URL + "/mcp?tenant=a",
+		server.URL + "/mcp?tenant=b",
+		server.URL + "/mcp?tenant=c",
+	}
+	unreachable := "http://127.0.0.1:1/mcp"
+
+	// The duplicate URL is discovered once
+	results, errs := DiscoverAll(context.Background(), append(servers, servers[0], unreachable), 2)
+
+	if len(results) != len(servers) {
+		t.Errorf("Expected %d results, got %d: %v", len(servers), len(results), errs)
+	}
+	for _, serverURL := range servers {
+		if results[serverURL] == nil || !results[serverURL].RequiresOAuth {
+			t.Errorf("Expected discovery result for %s", serverURL)
+		}
+	}
+	if len(errs) != 1 || errs[unreachable] == nil {
+		t.Errorf("Expected a single error for %s, got %v", unreachable, errs)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 concurrent discoveries, got %d", maxInFlight)
+	}
+}
+
+// TestDiscoverAll_Cancel verifies a cancellation mid-batch aborts in-flight discoveries,
+// starts no pending ones and reports context.Canceled for every unfinished URL
+func TestDiscoverAll_Cancel(t *testing.T) {
+	fast := []string{
+		newMockOAuthServer(t, func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusUnauthorized) }).URL + "/mcp",
+		newMockOAuthServer(t, func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusUnauthorized) }).URL + "/mcp",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var started atomic.Int32
+	var slow []string
+	for range 4 {
+		server := newMockOAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+			started.Add(1)
+			_, _ = io.Copy(io.Discard, r.Body) // The server notices the disconnect only after the body is read
+			cancel()
+			<-r.Context().Done() // Block until the client gives up
+		})
+		slow = append(slow, server.URL+"/mcp")
+	}
+
+	done := make(chan struct{})
+	var results map[string]*Discovery
+	var errs map[string]error
+	go func() {
+		results, errs = DiscoverAll(ctx, append(append([]string{}, fast...), slow...), 2)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("DiscoverAll did not return after cancellation")
+	}
+
+	for _, serverURL := range fast {
+		if results[serverURL] == nil {
+			t.Errorf("Expected %s to complete before the cancellation, got error %v", serverURL, errs[serverURL])
+		}
+	}
+	for _, serverURL := range slow {
+		if !errors.Is(errs[serverURL], context.Canceled) {
+			t.Errorf("Expected context.Canceled for %s, got %v", serverURL, errs[serverURL])
+		}
+	}
+	if n := started.Load(); n > 2 {
+		t.Errorf("Expected at most 2 slow discoveries to start, got %d", n)
+	}
+}
@@ -0,0 +1,151 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrIssuerMismatch is returned when the RFC 9207 iss parameter in an authorization
+// response does not match the expected authorization server issuer (possible mix-up attack)
+var ErrIssuerMismatch = errors.New("authorization response issuer mismatch")
+
+// ErrIssuerMissing is returned when an authorization response lacks the RFC 9207 iss parameter
+// although the authorization server advertises authorization_response_iss_parameter_supported
+var ErrIssuerMissing = errors.New("authorization response missing iss parameter")
+
+// CallbackOption configures ParseAuthorizationCallback
+type CallbackOption func(*callbackConfig)
+
+// callbackConfig holds options for authorization callback parsing
+type callbackConfig struct {
+	expectedIssuer string // Issuer the iss parameter must match (empty = not checked)
+	requireIssuer  bool   // Reject responses without an iss parameter
+	err            error  // Invalid option, returned by ParseAuthorizationCallback
+}
+
+// WithExpectedIssuer validates the RFC 9207 iss parameter against the given issuer
+//
+// RFC 9207 Section 2.4: Clients MUST compare iss with the issuer of the authorization
+// server the request was sent to. Use Discovery.Issuer as the expected value.
+// This is security-critical for deployments that talk to multiple authorization servers.
+func WithExpectedIssuer(issuer string) CallbackOption {
+	return func(c *callbackConfig) {
+		c.expectedIssuer = issuer
+	}
+}
+
+// WithDiscoveryIssuer validates the RFC 9207 iss parameter against Discovery.Issuer
+//
+// RFC 9207 Section 2.4: When the authorization server advertises
+// authorization_response_iss_parameter_supported (Discovery.SupportsIssParameter), clients
+// MUST reject authorization responses without iss. Otherwise iss is only checked if present.
+// A nil discovery makes ParseAuthorizationCallback fail rather than skip the check.
+func WithDiscoveryIssuer(discovery *Discovery) CallbackOption {
+	return func(c *callbackConfig) {
+		if discovery == nil {
+			c.err = fmt.Errorf("WithDiscoveryIssuer: discovery is required")
+			return
+		}
+		c.expectedIssuer = discovery.Issuer
+		c.requireIssuer = discovery.SupportsIssParameter
+	}
+}
+
+// OAuthCallbackError represents an error returned in the authorization response
+//
+// RFC 6749 COMPLIANCE:
+// - Section 4.1.2.1: Error Response (error, error_description, error_uri, state)
+type OAuthCallbackError struct {
+	Code        string // Error code (e.g. "access_denied")
+	Description string // Human-readable error description
+	URI         string // URI of a page with error information
+	State       string // State value echoed back by the authorization server
+}
+
+func (e *OAuthCallbackError) Error() string {
+	msg := fmt.Sprintf("authorization failed: %s", e.Code)
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	if e.URI != "" {
+		msg += " (see " + e.URI + ")"
+	}
+	return msg
+}
+
+// ParseAuthorizationCallback parses the redirect URL the authorization server sent the user back to
+//
+// RFC 6749 COMPLIANCE:
+// - Section 4.1.2: Extracts code and state from a successful response
+// - Section 4.1.2.1: Returns *OAuthCallbackError when the error parameter is present
+//
+// RFC 9207 COMPLIANCE:
+// - Extracts the iss parameter, from error responses as well
+// - Issuer checks run before an error response is reported
+// - With WithExpectedIssuer, returns ErrIssuerMismatch if iss differs from the expected issuer
+// - With WithDiscoveryIssuer, also returns ErrIssuerMissing if the server advertises iss support but omitted it
+func ParseAuthorizationCallback(callbackURL string, opts ...CallbackOption) (*AuthorizationCallbackResult, error) {
+	var config callbackConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.err != nil {
+		return nil, config.err
+	}
+
+	parsed, err := url.Parse(callbackURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid callback URL: %w", err)
+	}
+
+	query := parsed.Query()
+	result := &AuthorizationCallbackResult{
+		Code:   query.Get("code"),
+		State:  query.Get("state"),
+		Issuer: query.Get("iss"),
+	}
+
+	// RFC 9207 Section 2: error responses carry iss too, and are checked first so a
+	// mixed-up authorization server cannot inject its error into this flow
+	if config.requireIssuer && result.Issuer == "" {
+		return nil, ErrIssuerMissing
+	}
+	if config.expectedIssuer != "" {
+		if err := result.ValidateIssuer(config.expectedIssuer); err != nil {
+			return nil, err
+		}
+	}
+
+	if errCode := query.Get("error"); errCode != "" {
+		return nil, &OAuthCallbackError{
+			Code:        errCode,
+			Description: query.Get("error_description"),
+			URI:         query.Get("error_uri"),
+			State:       result.State,
+		}
+	}
+
+	if result.Code == "" {
+		return nil, fmt.Errorf("authorization callback missing code parameter")
+	}
+
+	return result, nil
+}
+
+// ValidateIssuer checks the RFC 9207 iss parameter against the expected issuer
+//
+// RFC 9207 Section 2.4:
+// - Issuer identifiers are compared using simple string comparison
+// - A missing iss parameter is accepted (servers that don't support RFC 9207 omit it)
+//
+// Returns an error wrapping ErrIssuerMismatch if the issuers differ
+func (r *AuthorizationCallbackResult) ValidateIssuer(expectedIssuer string) error {
+	if r.Issuer == "" {
+		return nil
+	}
+	if r.Issuer != expectedIssuer {
+		return fmt.Errorf("%w: got %q, expected %q", ErrIssuerMismatch, r.Issuer, expectedIssuer)
+	}
+	return nil
+}
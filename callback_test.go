@@ -0,0 +1,225 @@
+package oauth
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestParseAuthorizationCallback_Success verifies extraction of code, state and iss
+func TestParseAuthorizationCallback_Success(t *testing.T) {
+	result, err := ParseAuthorizationCallback("https://mcp.docker.com/oauth/callback?code=abc123&state=xyz&iss=https%3A%2F%2Fauth.example.com")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if result.Code != "abc123" {
+		t.Errorf("Expected code=abc123, got %s", result.Code)
+	}
+	if result.State != "xyz" {
+		t.Errorf("Expected state=xyz, got %s", result.State)
+	}
+	if result.Issuer != "https://auth.example.com" {
+		t.Errorf("Expected iss=https://auth.example.com, got %s", result.Issuer)
+	}
+}
+
+// TestParseAuthorizationCallback_Error verifies that the error parameter produces an OAuthCallbackError
+func TestParseAuthorizationCallback_Error(t *testing.T) {
+	_, err := ParseAuthorizationCallback("http://localhost:5000/callback?error=access_denied&error_description=User+denied&error_uri=https%3A%2F%2Fdocs.example.com&state=xyz")
+	if err == nil {
+		t.Fatal("Expected error for error response")
+	}
+
+	var callbackErr *OAuthCallbackError
+	if !errors.As(err, &callbackErr) {
+		t.Fatalf("Expected *OAuthCallbackError, got %T", err)
+	}
+	if callbackErr.Code != "access_denied" {
+		t.Errorf("Expected Code=access_denied, got %s", callbackErr.Code)
+	}
+	if callbackErr.Description != "User denied" {
+		t.Errorf("Expected Description='User denied', got %s", callbackErr.Description)
+	}
+	if callbackErr.URI != "https://docs.example.com" {
+		t.Errorf("Expected URI=https://docs.example.com, got %s", callbackErr.URI)
+	}
+	if callbackErr.State != "xyz" {
+		t.Errorf("Expected State=xyz, got %s", callbackErr.State)
+	}
+}
+
+// TestParseAuthorizationCallback_MissingCode verifies error handling when code is absent
+func TestParseAuthorizationCallback_MissingCode(t *testing.T) {
+	if _, err := ParseAuthorizationCallback("http://localhost:5000/callback?state=xyz"); err == nil {
+		t.Error("Expected error when code is missing")
+	}
+}
+
+// TestAuthorizationCallbackResult_ValidateIssuer verifies RFC 9207 issuer comparison
+func TestAuthorizationCallbackResult_ValidateIssuer(t *testing.T) {
+	tests := []struct {
+		name        string
+		issuer      string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "matching issuer",
+			issuer:   "https://auth.example.com",
+			expected: "https://auth.example.com",
+		},
+		{
+			name:        "mismatched issuer",
+			issuer:      "https://evil.example.com",
+			expected:    "https://auth.example.com",
+			expectError: true,
+		},
+		{
+			name:     "iss not sent",
+			issuer:   "",
+			expected: "https://auth.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &AuthorizationCallbackResult{Code: "abc", Issuer: tt.issuer}
+			err := result.ValidateIssuer(tt.expected)
+			if tt.expectError && err == nil {
+				t.Error("Expected issuer mismatch error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestParseAuthorizationCallback_ExpectedIssuer verifies RFC 9207 mix-up protection
+func TestParseAuthorizationCallback_ExpectedIssuer(t *testing.T) {
+	const expectedIssuer = "https://auth.example.com"
+
+	tests := []struct {
+		name        string
+		callbackURL string
+		expectError bool
+	}{
+		{
+			name:        "matching issuer",
+			callbackURL: "http://localhost:5000/callback?code=abc&state=xyz&iss=https%3A%2F%2Fauth.example.com",
+		},
+		{
+			name:        "mismatched issuer",
+			callbackURL: "http://localhost:5000/callback?code=abc&state=xyz&iss=https%3A%2F%2Fattacker.example.com",
+			expectError: true,
+		},
+		{
+			name:        "iss not sent",
+			callbackURL: "http://localhost:5000/callback?code=abc&state=xyz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseAuthorizationCallback(tt.callbackURL, WithExpectedIssuer(expectedIssuer))
+			if tt.expectError {
+				if !errors.Is(err, ErrIssuerMismatch) {
+					t.Fatalf("Expected ErrIssuerMismatch, got %v", err)
+				}
+				if result != nil {
+					t.Error("Expected nil result on issuer mismatch")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestParseAuthorizationCallback_DiscoveryIssuer verifies iss is required when the server
+// advertises authorization_response_iss_parameter_supported
+func TestParseAuthorizationCallback_DiscoveryIssuer(t *testing.T) {
+	const (
+		withIss     = "http://localhost:5000/callback?code=abc&state=xyz&iss=https%3A%2F%2Fauth.example.com"
+		wrongIss    = "http://localhost:5000/callback?code=abc&state=xyz&iss=https%3A%2F%2Fattacker.example.com"
+		withoutIss  = "http://localhost:5000/callback?code=abc&state=xyz"
+		issuerValue = "https://auth.example.com"
+	)
+
+	tests := []struct {
+		name          string
+		supported     bool
+		callbackURL   string
+		expectedError error
+	}{
+		{"matching iss", true, withIss, nil},
+		{"mismatched iss", true, wrongIss, ErrIssuerMismatch},
+		{"missing iss when required", true, withoutIss, ErrIssuerMissing},
+		{"missing iss when not advertised", false, withoutIss, nil},
+		{"mismatched iss when not advertised", false, wrongIss, ErrIssuerMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discovery := &Discovery{Issuer: issuerValue, SupportsIssParameter: tt.supported}
+			result, err := ParseAuthorizationCallback(tt.callbackURL, WithDiscoveryIssuer(discovery))
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("Expected %v, got %v", tt.expectedError, err)
+				}
+				if result != nil {
+					t.Error("Expected nil result on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestParseAuthorizationCallback_ErrorIssuer verifies error responses are subject to the
+// RFC 9207 issuer checks before being reported as an OAuthCallbackError
+func TestParseAuthorizationCallback_ErrorIssuer(t *testing.T) {
+	discovery := &Discovery{Issuer: "https://auth.example.com", SupportsIssParameter: true}
+
+	tests := []struct {
+		name          string
+		callbackURL   string
+		expectedError error
+	}{
+		{"mismatched iss", "http://localhost:5000/callback?error=access_denied&state=xyz&iss=https%3A%2F%2Fattacker.example.com", ErrIssuerMismatch},
+		{"missing iss when required", "http://localhost:5000/callback?error=access_denied&state=xyz", ErrIssuerMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAuthorizationCallback(tt.callbackURL, WithDiscoveryIssuer(discovery))
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Expected %v, got %v", tt.expectedError, err)
+			}
+			var callbackErr *OAuthCallbackError
+			if errors.As(err, &callbackErr) {
+				t.Error("Expected the issuer error instead of the authorization server's error")
+			}
+		})
+	}
+
+	_, err := ParseAuthorizationCallback("http://localhost:5000/callback?error=access_denied&state=xyz&iss=https%3A%2F%2Fauth.example.com",
+		WithDiscoveryIssuer(discovery))
+	var callbackErr *OAuthCallbackError
+	if !errors.As(err, &callbackErr) || callbackErr.Code != "access_denied" {
+		t.Errorf("Expected OAuthCallbackError for a matching iss, got %v", err)
+	}
+}
+
+// TestParseAuthorizationCallback_NilDiscoveryIssuer verifies WithDiscoveryIssuer(nil) fails
+// instead of silently skipping the issuer check
+func TestParseAuthorizationCallback_NilDiscoveryIssuer(t *testing.T) {
+	if _, err := ParseAuthorizationCallback("http://localhost:5000/callback?code=abc&state=xyz", WithDiscoveryIssuer(nil)); err == nil {
+		t.Error("Expected error for WithDiscoveryIssuer without discovery")
+	}
+}
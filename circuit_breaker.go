@@ -0,0 +1,259 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults for CircuitBreaker
+const (
+	DefaultCircuitFailureThreshold = 5                // Consecutive failures that open the circuit
+	DefaultCircuitCooldown         = 30 * time.Second // How long an open circuit rejects requests
+)
+
+// ErrCircuitOpen is returned without contacting the server while a host's circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitState is the state of a host's circuit
+type CircuitState int
+
+// Circuit states
+const (
+	CircuitClosed   CircuitState = iota // Requests flow normally
+	CircuitOpen                         // Requests fail fast with ErrCircuitOpen until the cooldown ends
+	CircuitHalfOpen                     // Cooldown ended; one probe request decides whether to close again
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitBreaker stops sending requests to authorization servers that keep failing
+//
+// Each host has its own circuit. After the failure threshold of consecutive failures
+// (transport errors or 5xx responses) the circuit opens and requests fail immediately with
+// ErrCircuitOpen. Once the cooldown has passed, a single probe request is let through
+// (half-open): success closes the circuit, failure opens it for another cooldown.
+//
+// Share one CircuitBreaker across discoveries and token requests (see WithCircuitBreaker and
+// WithTokenCircuitBreaker) so a server that is down is not retried by every gateway request.
+// A CircuitBreaker is safe for concurrent use.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	clock     Clock
+
+	mu    sync.Mutex
+	hosts map[string]*circuit
+}
+
+// circuit is the state of one host in a CircuitBreaker
+type circuit struct {
+	failures int           // Consecutive failures
+	openedAt time.Time     // When the circuit last opened (zero = closed)
+	cooldown time.Duration // Server-requested cooldown for this opening (0 = the breaker's cooldown)
+	probing  bool          // A half-open probe is in flight
+}
+
+// CircuitBreakerOption configures a CircuitBreaker
+type CircuitBreakerOption func(*CircuitBreaker)
+
+// WithFailureThreshold sets how many consecutive failures open a circuit (default DefaultCircuitFailureThreshold)
+func WithFailureThreshold(n int) CircuitBreakerOption {
+	return func(b *CircuitBreaker) {
+		b.threshold = n
+	}
+}
+
+// WithCooldown sets how long an open circuit rejects requests before a probe (default DefaultCircuitCooldown)
+func WithCooldown(d time.Duration) CircuitBreakerOption {
+	return func(b *CircuitBreaker) {
+		b.cooldown = d
+	}
+}
+
+// WithCircuitBreakerClock sets the clock used for cooldowns (defaults to the real clock)
+func WithCircuitBreakerClock(clock Clock) CircuitBreakerOption {
+	return func(b *CircuitBreaker) {
+		b.clock = clock
+	}
+}
+
+// NewCircuitBreaker creates a CircuitBreaker with all circuits closed
+// Non-positive thresholds and cooldowns fall back to the defaults
+func NewCircuitBreaker(opts ...CircuitBreakerOption) *CircuitBreaker {
+	b := &CircuitBreaker{
+		threshold: DefaultCircuitFailureThreshold,
+		cooldown:  DefaultCircuitCooldown,
+		clock:     realClock{},
+		hosts:     make(map[string]*circuit),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.threshold <= 0 {
+		b.threshold = DefaultCircuitFailureThreshold
+	}
+	if b.cooldown <= 0 {
+		b.cooldown = DefaultCircuitCooldown
+	}
+	return b
+}
+
+// State returns the current state of host's circuit
+func (b *CircuitBreaker) State(host string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stateLocked(b.hosts[host])
+}
+
+// stateLocked derives the state of c (nil = never used); b.mu must be held
+func (b *CircuitBreaker) stateLocked(c *circuit) CircuitState {
+	if c == nil || c.openedAt.IsZero() {
+		return CircuitClosed
+	}
+	cooldown := b.cooldown
+	if c.cooldown > 0 {
+		cooldown = c.cooldown
+	}
+	if b.clock.Now().Sub(c.openedAt) < cooldown {
+		return CircuitOpen
+	}
+	return CircuitHalfOpen
+}
+
+// Allow reports whether a request to host may be sent
+//
+// Returns an error wrapping ErrCircuitOpen while the circuit is open, or while it is
+// half-open and another request is already probing. A nil error must be followed by
+// RecordSuccess or RecordFailure for host.
+func (b *CircuitBreaker) Allow(host string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.hosts[host]
+	switch b.stateLocked(c) {
+	case CircuitOpen:
+		return fmt.Errorf("%w for %s", ErrCircuitOpen, host)
+	case CircuitHalfOpen:
+		if c.probing {
+			return fmt.Errorf("%w for %s (probe in progress)", ErrCircuitOpen, host)
+		}
+		c.probing = true
+	}
+	return nil
+}
+
+// RecordSuccess closes host's circuit and resets its failure count
+func (b *CircuitBreaker) RecordSuccess(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.hosts, host)
+}
+
+// RecordFailure counts a failed request to host, opening the circuit at the threshold
+// (a failed half-open probe reopens it for another cooldown)
+func (b *CircuitBreaker) RecordFailure(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.hosts[host]
+	if !ok {
+		c = &circuit{}
+		b.hosts[host] = c
+	}
+	c.failures++
+	if c.probing || c.failures >= b.threshold {
+		c.openedAt = b.clock.Now()
+		c.cooldown = 0
+	}
+	c.probing = false
+}
+
+// RecordRetryAfter records a response from host that asked to retry after retryAfter
+// (a 429 or 503 with Retry-After): the circuit opens at once, for retryAfter instead of
+// the configured cooldown. A non-positive retryAfter is recorded as an ordinary failure.
+func (b *CircuitBreaker) RecordRetryAfter(host string, retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		b.RecordFailure(host)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.hosts[host]
+	if !ok {
+		c = &circuit{}
+		b.hosts[host] = c
+	}
+	c.failures++
+	c.openedAt = b.clock.Now()
+	c.cooldown = retryAfter
+	c.probing = false
+}
+
+// release ends a half-open probe without an outcome (e.g. the caller canceled the request)
+func (b *CircuitBreaker) release(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.hosts[host]; ok {
+		c.probing = false
+	}
+}
+
+// Transport wraps base so that requests go through the circuit of their host
+// (nil base uses http.DefaultTransport)
+//
+// Transport errors and 5xx responses count as failures; any other response is a success.
+// A 429 or 503 response with a Retry-After header opens the circuit until then (see
+// RecordRetryAfter). Requests whose context was canceled do not count either way.
+func (b *CircuitBreaker) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &circuitBreakerTransport{base: base, breaker: b}
+}
+
+// circuitBreakerTransport is the http.RoundTripper returned by CircuitBreaker.Transport
+type circuitBreakerTransport struct {
+	base    http.RoundTripper
+	breaker *CircuitBreaker
+}
+
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	if err := t.breaker.Allow(host); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	switch {
+	case req.Context().Err() != nil:
+		t.breaker.release(host)
+	case err == nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) &&
+		resp.Header.Get("Retry-After") != "":
+		retryAfter, _ := ParseRetryAfter(resp.Header.Get("Retry-After"), t.breaker.clock.Now())
+		t.breaker.RecordRetryAfter(host, retryAfter)
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		t.breaker.RecordFailure(host)
+	default:
+		t.breaker.RecordSuccess(host)
+	}
+	return resp, err
+}
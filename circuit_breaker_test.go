@@ -0,0 +1,211 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker_States drives a circuit open, through the cooldown and half-open probes
+func TestCircuitBreaker_States(t *testing.T) {
+	clock := newFakeClock()
+	breaker := NewCircuitBreaker(WithFailureThreshold(3), WithCooldown(10*time.Second), WithCircuitBreakerClock(clock))
+	const host = "auth.example.com"
+
+	for i := range 2 {
+		if err := breaker.Allow(host); err != nil {
+			t.Fatalf("Attempt %d: expected closed circuit, got %v", i, err)
+		}
+		breaker.RecordFailure(host)
+	}
+	if state := breaker.State(host); state != CircuitClosed {
+		t.Fatalf("Expected closed below the threshold, got %s", state)
+	}
+
+	breaker.RecordFailure(host)
+	if state := breaker.State(host); state != CircuitOpen {
+		t.Fatalf("Expected open at the threshold, got %s", state)
+	}
+	if err := breaker.Allow(host); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if err := breaker.Allow("other.example.com"); err != nil {
+		t.Errorf("Expected other hosts to be unaffected, got %v", err)
+	}
+
+	// Still open just before the cooldown ends
+	clock.Advance(9 * time.Second)
+	if err := breaker.Allow(host); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen during cooldown, got %v", err)
+	}
+
+	// Half-open: one probe is let through, concurrent requests still fail fast
+	clock.Advance(time.Second)
+	if state := breaker.State(host); state != CircuitHalfOpen {
+		t.Fatalf("Expected half-open after cooldown, got %s", state)
+	}
+	if err := breaker.Allow(host); err != nil {
+		t.Fatalf("Expected half-open probe to be allowed, got %v", err)
+	}
+	if err := breaker.Allow(host); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected second request during probe to fail fast, got %v", err)
+	}
+
+	// A failed probe reopens the circuit for a full cooldown
+	breaker.RecordFailure(host)
+	if state := breaker.State(host); state != CircuitOpen {
+		t.Fatalf("Expected open after failed probe, got %s", state)
+	}
+
+	// A successful probe closes it
+	clock.Advance(10 * time.Second)
+	if err := breaker.Allow(host); err != nil {
+		t.Fatalf("Expected half-open probe to be allowed, got %v", err)
+	}
+	breaker.RecordSuccess(host)
+	if state := breaker.State(host); state != CircuitClosed {
+		t.Fatalf("Expected closed after successful probe, got %s", state)
+	}
+
+	// The failure count starts over
+	breaker.RecordFailure(host)
+	if state := breaker.State(host); state != CircuitClosed {
+		t.Errorf("Expected closed after a single new failure, got %s", state)
+	}
+}
+
+// TestCircuitBreaker_TokenRequests verifies a failing token endpoint is short-circuited
+// and recovers through a half-open probe
+func TestCircuitBreaker_TokenRequests(t *testing.T) {
+	var requests atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"at","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	breaker := NewCircuitBreaker(WithFailureThreshold(2), WithCooldown(time.Minute), WithCircuitBreakerClock(clock))
+	discovery := &Discovery{TokenEndpoint: server.URL + "/token"}
+	creds := &ClientCredentials{ClientID: "client", IsPublic: true}
+	refresh := func(refreshToken string) error {
+		_, err := RefreshAccessToken(context.Background(), discovery, creds, refreshToken, WithTokenCircuitBreaker(breaker))
+		return err
+	}
+
+	for i := range 2 {
+		if err := refresh("rt"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Attempt %d: expected server error, got %v", i, err)
+		}
+	}
+	if err := refresh("rt"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected the open circuit to skip the server, got %d requests", got)
+	}
+
+	healthy.Store(true)
+	clock.Advance(time.Minute)
+	if err := refresh("rt"); err != nil {
+		t.Fatalf("Expected half-open probe to succeed, got %v", err)
+	}
+	host := mustParseURL(t, server.URL).Host
+	if state := breaker.State(host); state != CircuitClosed {
+		t.Errorf("Expected closed circuit after recovery, got %s", state)
+	}
+}
+
+// TestCircuitBreaker_Discovery verifies discovery fails fast once the MCP host's circuit is open
+func TestCircuitBreaker_Discovery(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(WithFailureThreshold(1), WithCircuitBreakerClock(newFakeClock()))
+	if _, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithCircuitBreaker(breaker)); err == nil {
+		t.Fatal("Expected discovery against a failing server to fail")
+	}
+
+	before := requests.Load()
+	_, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithCircuitBreaker(breaker))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if requests.Load() != before {
+		t.Error("Expected no requests while the circuit is open")
+	}
+}
+
+// mustParseURL parses rawURL or fails the test
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", rawURL, err)
+	}
+	return parsed
+}
+
+// TestCircuitBreaker_RetryAfter verifies a 429 with Retry-After opens the circuit for the
+// requested delay rather than waiting for the failure threshold
+func TestCircuitBreaker_RetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	breaker := NewCircuitBreaker(WithCooldown(10*time.Second), WithCircuitBreakerClock(clock))
+	client := &http.Client{Transport: breaker.Transport(nil)}
+	send := func() error {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := send(); err != nil {
+		t.Fatalf("First request failed: %v", err)
+	}
+	host := strings.TrimPrefix(server.URL, "http://")
+	if state := breaker.State(host); state != CircuitOpen {
+		t.Fatalf("Expected open after Retry-After, got %s", state)
+	}
+
+	// The server's delay applies, not the shorter configured cooldown
+	clock.Advance(time.Minute)
+	if err := send(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen before Retry-After elapsed, got %v", err)
+	}
+	clock.Advance(time.Minute)
+	if state := breaker.State(host); state != CircuitHalfOpen {
+		t.Errorf("Expected half-open once Retry-After elapsed, got %s", state)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected 1 request to reach the server, got %d", requests.Load())
+	}
+}
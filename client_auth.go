@@ -0,0 +1,91 @@
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Token endpoint client authentication methods supported by token requests
+// (RFC 7591 Section 2 token_endpoint_auth_method values)
+const (
+	TokenEndpointAuthNone              = "none"                // Public client: client_id only, relies on PKCE
+	TokenEndpointAuthClientSecretPost  = "client_secret_post"  // client_id and client_secret in the form body
+	TokenEndpointAuthClientSecretBasic = "client_secret_basic" // HTTP Basic authentication header
+)
+
+// resolveTokenEndpointAuthMethod picks the client authentication method for a token request
+//
+// Precedence: explicit override (WithTokenEndpointAuthMethod), then the method recorded on
+// the credentials at registration, then none for public clients, client_secret_post for
+// clients without a secret and client_secret_basic for confidential clients
+// (RFC 6749 Section 2.3.1: servers MUST support Basic for clients that have a password)
+func resolveTokenEndpointAuthMethod(creds *ClientCredentials, override string) string {
+	if override != "" {
+		return override
+	}
+	if creds.TokenEndpointAuthMethod != "" {
+		return creds.TokenEndpointAuthMethod
+	}
+	if creds.IsPublic {
+		return TokenEndpointAuthNone
+	}
+	if creds.ClientSecret == "" {
+		return TokenEndpointAuthClientSecretPost
+	}
+	return TokenEndpointAuthClientSecretBasic
+}
+
+// postBodyFields are client authentication parameters sent in the token request body
+type postBodyFields map[string]string
+
+// noneAuth returns the body fields of a public client (token_endpoint_auth_method=none)
+//
+// RFC 6749 Section 3.2.1 / RFC 7636: the client only identifies itself with client_id and
+// proves possession of the authorization code through PKCE; no secret is sent, even if
+// the credentials happen to hold one
+func noneAuth(clientID string) postBodyFields {
+	return postBodyFields{"client_id": clientID}
+}
+
+// clientSecretPostAuth returns the body fields for client_secret_post (RFC 6749 Section 2.3.1)
+// The secret is omitted when empty
+func clientSecretPostAuth(clientID, clientSecret string) postBodyFields {
+	fields := postBodyFields{"client_id": clientID}
+	if clientSecret != "" {
+		fields["client_secret"] = clientSecret
+	}
+	return fields
+}
+
+// applyClientAuth authenticates a token endpoint request with the given method
+//
+// RFC 6749 COMPLIANCE:
+// - Section 2.3.1: client_secret_basic encodes client_id and client_secret with
+// application/x-www-form-urlencoded before Base64 (Basic auth)
+// - Section 2.3.1: client_secret_post sends client_id and client_secret in the body
+// - Section 3.2.1: public clients send client_id in the body
+//
+// form must be encoded into the request body after this call
+func applyClientAuth(req *http.Request, form url.Values, creds *ClientCredentials, method string) error {
+	var fields postBodyFields
+	switch method {
+	case TokenEndpointAuthNone:
+		fields = noneAuth(creds.ClientID)
+	case TokenEndpointAuthClientSecretPost:
+		fields = clientSecretPostAuth(creds.ClientID, creds.ClientSecret)
+	case TokenEndpointAuthClientSecretBasic:
+		if creds.ClientSecret == "" {
+			return fmt.Errorf("client_secret_basic requires a client secret")
+		}
+		req.SetBasicAuth(url.QueryEscape(creds.ClientID), url.QueryEscape(creds.ClientSecret))
+	default:
+		return fmt.Errorf("unsupported token endpoint auth method %q (use %q, %q or %q)", method,
+			TokenEndpointAuthNone, TokenEndpointAuthClientSecretPost, TokenEndpointAuthClientSecretBasic)
+	}
+
+	for key, value := range fields {
+		form.Set(key, value)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// TestTokenRequest_ClientAuthentication verifies client_secret_basic, client_secret_post and
+// public client authentication of token requests
+func TestTokenRequest_ClientAuthentication(t *testing.T) {
+	var basicUser, basicPass string
+	var hasBasic bool
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		basicUser, basicPass, hasBasic = r.BasicAuth()
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse form: %v", err)
+		}
+		form = r.PostForm
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: "access", TokenType: "Bearer"})
+	}))
+	defer server.Close()
+	discovery := &Discovery{TokenEndpoint: server.URL}
+
+	confidential := &ClientCredentials{ClientID: "client:123", ClientSecret: "s3cr3t/+"}
+	public := &ClientCredentials{ClientID: "client-123", IsPublic: true}
+
+	tests := []struct {
+		name             string
+		creds            *ClientCredentials
+		opts             []TokenOption
+		expectBasic      bool
+		expectBodyID     string
+		expectBodySecret string
+	}{
+		{
+			name:        "confidential client defaults to basic",
+			creds:       confidential,
+			expectBasic: true,
+		},
+		{
+			name:             "confidential client with client_secret_post",
+			creds:            confidential,
+			opts:             []TokenOption{WithTokenEndpointAuthMethod(TokenEndpointAuthClientSecretPost)},
+			expectBodyID:     "client:123",
+			expectBodySecret: "s3cr3t/+",
+		},
+		{
+			name:             "method recorded on credentials",
+			creds:            &ClientCredentials{ClientID: "client:123", ClientSecret: "s3cr3t/+", TokenEndpointAuthMethod: TokenEndpointAuthClientSecretPost},
+			expectBodyID:     "client:123",
+			expectBodySecret: "s3cr3t/+",
+		},
+		{
+			name:         "public client uses none",
+			creds:        public,
+			expectBodyID: "client-123",
+		},
+		{
+			name:         "none never sends a secret",
+			creds:        confidential,
+			opts:         []TokenOption{WithTokenEndpointAuthMethod(TokenEndpointAuthNone)},
+			expectBodyID: "client:123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := RefreshAccessToken(context.Background(), discovery, tt.creds, "refresh", tt.opts...); err != nil {
+				t.Fatalf("RefreshAccessToken failed: %v", err)
+			}
+
+			if hasBasic != tt.expectBasic {
+				t.Fatalf("Expected Basic auth=%v, got %v", tt.expectBasic, hasBasic)
+			}
+			if tt.expectBasic {
+				// RFC 6749 Section 2.3.1: credentials are form-urlencoded before Base64
+				if basicUser != url.QueryEscape(tt.creds.ClientID) || basicPass != url.QueryEscape(tt.creds.ClientSecret) {
+					t.Errorf("Unexpected Basic credentials %q:%q", basicUser, basicPass)
+				}
+			}
+			if got := form.Get("client_id"); got != tt.expectBodyID {
+				t.Errorf("Expected body client_id %q, got %q", tt.expectBodyID, got)
+			}
+			if got := form.Get("client_secret"); got != tt.expectBodySecret {
+				t.Errorf("Expected body client_secret %q, got %q", tt.expectBodySecret, got)
+			}
+		})
+	}
+}
+
+// TestTokenRequest_ClientAuthenticationErrors verifies invalid method configurations fail before sending
+func TestTokenRequest_ClientAuthenticationErrors(t *testing.T) {
+	discovery := &Discovery{TokenEndpoint: "http://127.0.0.1:1/token"}
+
+	_, err := RefreshAccessToken(context.Background(), discovery, &ClientCredentials{ClientID: "client-123"}, "refresh",
+		WithTokenEndpointAuthMethod(TokenEndpointAuthClientSecretBasic))
+	if err == nil || err.Error() != "client_secret_basic requires a client secret" {
+		t.Errorf("Expected missing secret error, got %v", err)
+	}
+
+	_, err = RefreshAccessToken(context.Background(), discovery, &ClientCredentials{ClientID: "client-123"}, "refresh",
+		WithTokenEndpointAuthMethod("private_key_jwt"))
+	if err == nil {
+		t.Error("Expected unsupported method error")
+	}
+}
+
+// TestNoneAuth verifies public clients only send client_id
+func TestNoneAuth(t *testing.T) {
+	fields := noneAuth("client-123")
+	if len(fields) != 1 || fields["client_id"] != "client-123" {
+		t.Errorf("Expected only client_id, got %v", fields)
+	}
+
+	public := &ClientCredentials{ClientID: "client-123", ClientSecret: "stale-secret", IsPublic: true}
+	if method := resolveTokenEndpointAuthMethod(public, ""); method != TokenEndpointAuthNone {
+		t.Errorf("Expected public client to resolve to none, got %s", method)
+	}
+}
@@ -0,0 +1,16 @@
+package oauth
+
+import "time"
+
+// Clock abstracts the current time for expiry and backoff calculations
+// Tests inject a fake implementation to control time deterministically
+type Clock interface {
+	Now() time.Time                         // Current time
+	After(d time.Duration) <-chan time.Time // Fires once d has elapsed
+}
+
+// realClock is the default Clock backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
@@ -0,0 +1,165 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestMemoryStateStore_FakeClockExpiry verifies expiry transitions driven by an injected clock
+func TestMemoryStateStore_FakeClockExpiry(t *testing.T) {
+	clock := newFakeClock()
+	store := NewMemoryStateStore(5*time.Minute, WithStateStoreClock(clock))
+
+	if err := store.Save("state-1", &PendingAuthorization{CodeVerifier: "v1"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := store.Save("state-2", &PendingAuthorization{CodeVerifier: "v2"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Still valid just before the TTL
+	clock.Advance(5*time.Minute - time.Second)
+	if _, err := store.Consume("state-1"); err != nil {
+		t.Fatalf("Expected state-1 to be valid before TTL, got %v", err)
+	}
+
+	// Expired just after the TTL
+	clock.Advance(2 * time.Second)
+	if _, err := store.Consume("state-2"); !errors.Is(err, ErrStateExpired) {
+		t.Errorf("Expected ErrStateExpired after TTL, got %v", err)
+	}
+}
+
+// TestTokenResponse_FakeClockExpiry verifies token expiry transitions driven by an injected clock
+func TestTokenResponse_FakeClockExpiry(t *testing.T) {
+	server := NewFakeTokenServer(WithIssuedTokens(map[string]TokenResponse{"code": {ExpiresIn: 300}}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	discovery := &Discovery{TokenEndpoint: server.URL() + "/token"}
+	token, err := ExchangeAuthorizationCode(context.Background(), discovery, &ClientCredentials{ClientID: "client-123"},
+		"code", "verifier", DefaultRedirectURI, WithTokenClock(clock))
+	if err != nil {
+		t.Fatalf("ExchangeAuthorizationCode failed: %v", err)
+	}
+
+	if expected := clock.Now().Add(5 * time.Minute); !token.ExpiresAt().Equal(expected) {
+		t.Fatalf("Expected ExpiresAt=%s, got %s", expected, token.ExpiresAt())
+	}
+
+	// Still valid just before expiry
+	clock.Advance(5*time.Minute - time.Second)
+	if token.IsExpired(clock) {
+		t.Error("Expected token to be valid before expires_in elapsed")
+	}
+
+	// Expired once expires_in has elapsed
+	clock.Advance(time.Second)
+	if !token.IsExpired(clock) {
+		t.Error("Expected token to be expired after expires_in elapsed")
+	}
+
+	// Without a known expiry a token never reports expired
+	unknown := &TokenResponse{AccessToken: "access", ExpiresIn: 300}
+	if !unknown.ExpiresAt().IsZero() || unknown.IsExpired(clock) {
+		t.Error("Expected no expiry for a token without IssuedAt")
+	}
+}
+
+// TestMemoryStateStore_FakeClockEviction verifies expired entries are evicted on Save
+func TestMemoryStateStore_FakeClockEviction(t *testing.T) {
+	clock := newFakeClock()
+	store := NewMemoryStateStore(time.Minute, WithStateStoreClock(clock))
+
+	_ = store.Save("abandoned", &PendingAuthorization{CodeVerifier: "v1"})
+	clock.Advance(2 * time.Minute)
+	_ = store.Save("fresh", &PendingAuthorization{CodeVerifier: "v2"})
+
+	store.mu.Lock()
+	_, abandonedPresent := store.pending["abandoned"]
+	entries := len(store.pending)
+	store.mu.Unlock()
+
+	if abandonedPresent {
+		t.Error("Expected expired entry to be evicted")
+	}
+	if entries != 1 {
+		t.Errorf("Expected 1 entry after eviction, got %d", entries)
+	}
+}
+
+// TestFakeClock_After verifies waiters fire only once their deadline passes
+func TestFakeClock_After(t *testing.T) {
+	clock := newFakeClock()
+	fired := clock.After(time.Minute)
+
+	clock.Advance(30 * time.Second)
+	select {
+	case <-fired:
+		t.Fatal("After fired before deadline")
+	default:
+	}
+
+	clock.Advance(30 * time.Second)
+	select {
+	case <-fired:
+	default:
+		t.Fatal("After did not fire at deadline")
+	}
+}
+
+// fakeClock is a Clock whose time only moves when Advance is called
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeClockWaiter
+}
+
+type fakeClockWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	deadline := c.now.Add(d)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeClockWaiter{deadline: deadline, ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward and fires every waiter whose deadline has passed
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !c.now.Before(w.deadline) {
+			w.ch <- c.now
+			continue
+		}
+		remaining = append(remaining, w)
+	}
+	c.waiters = remaining
+}
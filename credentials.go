@@ -0,0 +1,16 @@
+package oauth
+
+// Equal reports whether c and other describe the same client registration
+//
+// Only the identifying fields are compared: ClientID, ServerURL and the effective token
+// endpoint auth method (as resolved for token requests, so an unset method equals its
+// default). Secrets, endpoints, redirect URIs and RFC 7592 management fields may change
+// on re-registration without making it a different client. Two nil credentials are equal.
+func (c *ClientCredentials) Equal(other *ClientCredentials) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	return c.ClientID == other.ClientID &&
+		c.ServerURL == other.ServerURL &&
+		resolveTokenEndpointAuthMethod(c, "") == resolveTokenEndpointAuthMethod(other, "")
+}
@@ -0,0 +1,40 @@
+package oauth
+
+import "testing"
+
+// TestClientCredentials_Equal verifies only identifying fields are compared
+func TestClientCredentials_Equal(t *testing.T) {
+	base := &ClientCredentials{
+		ClientID:                "client-123",
+		ServerURL:               "https://mcp.example.com",
+		IsPublic:                true,
+		TokenEndpointAuthMethod: TokenEndpointAuthNone,
+		RegistrationAccessToken: "token-1",
+	}
+
+	tests := []struct {
+		name  string
+		other *ClientCredentials
+		want  bool
+	}{
+		{"identical", &ClientCredentials{ClientID: "client-123", ServerURL: "https://mcp.example.com", IsPublic: true, TokenEndpointAuthMethod: TokenEndpointAuthNone, RegistrationAccessToken: "token-1"}, true},
+		{"volatile fields differ", &ClientCredentials{ClientID: "client-123", ServerURL: "https://mcp.example.com", IsPublic: true, RegistrationAccessToken: "token-2", RedirectURIs: []string{DefaultRedirectURI}}, true},
+		{"different client id", &ClientCredentials{ClientID: "client-456", ServerURL: "https://mcp.example.com", IsPublic: true}, false},
+		{"different server", &ClientCredentials{ClientID: "client-123", ServerURL: "https://other.example.com", IsPublic: true}, false},
+		{"different auth method", &ClientCredentials{ClientID: "client-123", ServerURL: "https://mcp.example.com", ClientSecret: "secret"}, false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.Equal(tt.other); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var none *ClientCredentials
+	if !none.Equal(nil) {
+		t.Error("Expected nil credentials to be equal")
+	}
+}
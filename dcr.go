@@ -6,15 +6,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"net/netip"
 	"net/url"
+	"slices"
 )
 
+// DefaultRedirectURI is the mcp.docker.com OAuth callback used when no redirect URI is given
 const DefaultRedirectURI = "https://mcp.docker.com/oauth/callback"
 
+// DefaultRedirectURIForServer returns DefaultRedirectURI with the MCP server URL in the
+// "server" query parameter, so a single callback can route multi-server flows
+//
+// Returns DefaultRedirectURI unchanged if serverURL is empty
+func DefaultRedirectURIForServer(serverURL string) string {
+	if serverURL == "" {
+		return DefaultRedirectURI
+	}
+	return DefaultRedirectURI + "?" + url.Values{"server": {serverURL}}.Encode()
+}
+
 // isValidRedirectURI validates that the redirect URI is allowed for this library
-// Only localhost and mcp.docker.com are permitted for security
-func isValidRedirectURI(redirectURI string) error {
+// Only localhost and mcp.docker.com are permitted for security (loopback can be disabled by policy)
+func isValidRedirectURI(redirectURI string, policy RedirectURIPolicy) error {
 	if redirectURI == "" {
 		return nil // Empty is OK (will use default)
 	}
@@ -27,8 +42,11 @@ func isValidRedirectURI(redirectURI string) error {
 	// Extract hostname (handles ports automatically)
 	hostname := parsed.Hostname()
 
-	// Allow localhost variations
-	if hostname == "localhost" || hostname == "127.0.0.1" || hostname == "::1" {
+	// Allow localhost variations unless the policy disables them
+	if isLoopbackHost(hostname) {
+		if policy.DisableLoopback {
+			return fmt.Errorf("loopback redirect URI host %q disabled by policy", hostname)
+		}
 		return nil
 	}
 
@@ -40,22 +58,51 @@ func isValidRedirectURI(redirectURI string) error {
 	return fmt.Errorf("redirect URI host %q not allowed - must be localhost or mcp.docker.com", hostname)
 }
 
+// isLoopbackHost reports whether hostname refers to the local machine
+//
+// hostname is a URL host without brackets and port (url.URL.Hostname). IP literals are
+// parsed, so IPv6 loopback with a zone identifier (::1%lo0, from [::1%25lo0]) and
+// IPv4-mapped loopback (::ffff:127.0.0.1) count as loopback; other IPv6 addresses do not.
+func isLoopbackHost(hostname string) bool {
+	if hostname == "localhost" {
+		return true
+	}
+	addr, err := netip.ParseAddr(hostname)
+	if err != nil {
+		return false
+	}
+	return addr.WithZone("").Unmap().IsLoopback()
+}
+
 // PerformDCR performs Dynamic Client Registration with the authorization server
 // Returns client credentials for the registered public client
 //
 // RFC 7591 COMPLIANCE:
-// - Uses token_endpoint_auth_method="none" for public clients
+// - Uses token_endpoint_auth_method="none" for public clients (see WithConfidentialClient)
 // - Includes redirect_uris pointing to mcp-oauth proxy
 // - Requests authorization_code and refresh_token grant types
 //
 // redirectURI: The OAuth callback URI to register. If empty, uses DefaultRedirectURI.
-func PerformDCR(ctx context.Context, discovery *Discovery, serverName string, redirectURI string) (*ClientCredentials, error) {
+//
+// OPTIONS: See DCROption (e.g. WithRedirectURIPolicy) to customize the registration, or
+// WithDCRRequest / WithDCRRequestBuilder to send a request built elsewhere
+func PerformDCR(ctx context.Context, discovery *Discovery, serverName string, redirectURI string, opts ...DCROption) (*ClientCredentials, error) {
+	config, err := newDCRConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	if discovery.RegistrationEndpoint == "" {
 		return nil, fmt.Errorf("no registration endpoint found for %s", serverName)
 	}
 
+	// Explicit request (WithDCRRequest / WithDCRRequestBuilder): register it as-is
+	if config.registration != nil {
+		return performDCRRequest(ctx, discovery, serverName, redirectURI, config)
+	}
+
 	// Validate redirect URI for security (only localhost or mcp.docker.com allowed)
-	if err := isValidRedirectURI(redirectURI); err != nil {
+	if err := isValidRedirectURI(redirectURI, config.redirectPolicy); err != nil {
 		return nil, fmt.Errorf("invalid redirect URI: %w", err)
 	}
 
@@ -64,26 +111,152 @@ func PerformDCR(ctx context.Context, discovery *Discovery, serverName string, re
 		redirectURI = DefaultRedirectURI
 	}
 
-	// Build DCR request for PUBLIC client
-	registration := DCRRequest{
+	// Build DCR request (PUBLIC client unless WithConfidentialClient) with the configured metadata
+	registration, err := newDCRRequest(discovery, serverName, []string{redirectURI}, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return withSpan(ctx, SpanDCR, "dcr", discovery.RegistrationEndpoint, func(ctx context.Context) (*ClientCredentials, error) {
+		return registerClient(ctx, discovery, serverName, registration, config.strictJSON)
+	})
+}
+
+// performDCRRequest registers the explicit request in config.registration
+func performDCRRequest(ctx context.Context, discovery *Discovery, serverName, redirectURI string, config *dcrConfig) (*ClientCredentials, error) {
+	registration := config.registration
+	if redirectURI != "" && !slices.Contains(registration.RedirectURIs, redirectURI) {
+		return nil, fmt.Errorf("redirect URI %q is not in the registration request", redirectURI)
+	}
+	for _, uri := range registration.RedirectURIs {
+		if err := isValidRedirectURI(uri, config.redirectPolicy); err != nil {
+			return nil, fmt.Errorf("invalid redirect URI: %w", err)
+		}
+	}
+
+	return withSpan(ctx, SpanDCR, "dcr", discovery.RegistrationEndpoint, func(ctx context.Context) (*ClientCredentials, error) {
+		return registerClient(ctx, discovery, serverName, registration, config.strictJSON)
+	})
+}
+
+// MarshalJSON serializes the registration fields followed by ExtraMetadata
+// Extra fields never override a standard field of the same name
+func (r DCRRequest) MarshalJSON() ([]byte, error) {
+	type plain DCRRequest
+	data, err := json.Marshal(plain(r))
+	if err != nil || len(r.ExtraMetadata) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range r.ExtraMetadata {
+		if _, ok := fields[name]; ok {
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal extra metadata %q: %w", name, err)
+		}
+		fields[name] = encoded
+	}
+	return json.Marshal(fields)
+}
+
+// NewDCRRequest builds the registration request PerformDCR sends for an MCP server
+//
+// The request registers a PUBLIC client (token_endpoint_auth_method="none", as the gateway
+// cannot keep a client secret confidential) named "MCP Gateway - <serverName>" for the
+// authorization_code and refresh_token grants with the "code" response type, and requests
+// the scopes found during discovery. WithConfidentialClient registers a confidential client
+// instead; the registration metadata options (WithGrantTypes, WithContacts, ...) apply as
+// they do for PerformDCR.
+//
+// redirectURIs must contain at least one absolute URI without fragment (RFC 6749 Section 3.1.2)
+func NewDCRRequest(discovery *Discovery, serverName string, redirectURIs []string, opts ...DCROption) (*DCRRequest, error) {
+	config, err := newDCRConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return newDCRRequest(discovery, serverName, redirectURIs, config)
+}
+
+// newDCRRequest builds the registration request described on NewDCRRequest from config
+func newDCRRequest(discovery *Discovery, serverName string, redirectURIs []string, config *dcrConfig) (*DCRRequest, error) {
+	if discovery == nil {
+		return nil, fmt.Errorf("discovery is required")
+	}
+	if len(redirectURIs) == 0 {
+		return nil, fmt.Errorf("at least one redirect URI is required")
+	}
+	if err := validateRedirectURIFormat(redirectURIs); err != nil {
+		return nil, err
+	}
+
+	authMethod := "none" // PUBLIC client (no client secret)
+	if config.confidential {
+		authMethod = confidentialAuthMethod(discovery.TokenEndpointAuthMethodsSupported)
+	}
+
+	registration := &DCRRequest{
 		ClientName:              fmt.Sprintf("MCP Gateway - %s", serverName),
-		RedirectURIs:            []string{redirectURI},
-		TokenEndpointAuthMethod: "none", // PUBLIC client (no client secret)
-		GrantTypes:              []string{"authorization_code", "refresh_token"},
-		ResponseTypes:           []string{"code"},
+		RedirectURIs:            slices.Clone(redirectURIs),
+		TokenEndpointAuthMethod: authMethod,
+		GrantTypes:              slices.Clone(config.grantTypes),
+		ResponseTypes:           slices.Clone(config.responseTypes),
+		Scope:                   joinScopes(discovery.Scopes),
+		ApplicationType:         config.appType,
 
 		// Additional metadata for better client identification
-		ClientURI:       "https://github.com/docker/mcp-gateway",
+		ClientURI:       config.clientURI,
+		LogoURI:         config.logoURI,
+		TOSURI:          config.tosURI,
+		PolicyURI:       config.policyURI,
 		SoftwareID:      "mcp-gateway",
 		SoftwareVersion: "1.0.0",
-		Contacts:        []string{"support@docker.com"},
+		Contacts:        slices.Clone(config.contacts),
+		ExtraMetadata:   maps.Clone(config.extraMetadata),
+	}
+	if config.pkceHint && slices.Contains(discovery.CodeChallengeMethod, "S256") {
+		if registration.ExtraMetadata == nil {
+			registration.ExtraMetadata = map[string]any{}
+		}
+		registration.ExtraMetadata["code_challenge_methods"] = []string{"S256"}
+	}
+	return registration, nil
+}
+
+// confidentialAuthMethod picks the token_endpoint_auth_method for a confidential client
+//
+// The first client secret method the server advertises wins; without one, RFC 7591
+// Section 2's default client_secret_basic is used.
+func confidentialAuthMethod(supported []string) string {
+	for _, method := range supported {
+		if method == "client_secret_basic" || method == "client_secret_post" {
+			return method
+		}
 	}
+	return "client_secret_basic"
+}
 
-	// Add requested scopes if provided
-	if len(discovery.Scopes) > 0 {
-		registration.Scope = joinScopes(discovery.Scopes)
+// RegisterClient performs Dynamic Client Registration with an explicit registration request
+//
+// Use this with a request from DCRRequestBuilder.Build (or a hand-built DCRRequest) when
+// PerformDCR's defaults don't fit. Redirect URIs are checked against the default
+// RedirectURIPolicy before anything is sent. It is a shorthand for PerformDCR with
+// WithDCRRequest, which also accepts the other DCR options.
+func RegisterClient(ctx context.Context, discovery *Discovery, registration *DCRRequest) (*ClientCredentials, error) {
+	if registration == nil {
+		return nil, fmt.Errorf("registration request is required")
 	}
+	return PerformDCR(ctx, discovery, registration.ClientName, "", WithDCRRequest(registration))
+}
 
+// registerClient sends the registration request and converts the response into credentials
+// label identifies the client in error messages; strict rejects unknown response fields
+func registerClient(ctx context.Context, discovery *Discovery, label string, registration *DCRRequest, strict bool) (*ClientCredentials, error) {
 	// Marshal the registration request
 	body, err := json.Marshal(registration)
 	if err != nil {
@@ -107,56 +280,87 @@ func PerformDCR(ctx context.Context, discovery *Discovery, serverName string, re
 		return nil, fmt.Errorf("failed to send DCR request to %s: %w", discovery.RegistrationEndpoint, err)
 	}
 	defer resp.Body.Close()
+	setSpanStatus(ctx, resp.StatusCode)
 
 	// Check response status (201 Created or 200 OK are acceptable)
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		// Read error response body to understand why DCR failed
-		errorBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("DCR failed with status %d for %s", resp.StatusCode, serverName)
+		dcrErr := &DCRError{
+			Label:            label,
+			StatusCode:       resp.StatusCode,
+			capturedResponse: captureResponse(resp),
 		}
-
-		errorMsg := string(errorBody)
+		dcrErr.Description = string(dcrErr.body)
 
 		// Try to parse as JSON for structured error
 		var errorResp map[string]any
-		if err := json.Unmarshal(errorBody, &errorResp); err == nil {
+		if err := json.Unmarshal(dcrErr.body, &errorResp); err == nil {
+			dcrErr.Code, _ = errorResp["error"].(string)
 			// Successfully parsed as JSON - look for common error fields
 			if errDesc, ok := errorResp["error_description"].(string); ok {
-				errorMsg = errDesc
-			} else if errField, ok := errorResp["error"].(string); ok {
-				errorMsg = errField
+				dcrErr.Description = errDesc
+			} else if dcrErr.Code != "" {
+				dcrErr.Description = dcrErr.Code
 			} else if message, ok := errorResp["message"].(string); ok {
-				errorMsg = message
+				dcrErr.Description = message
 			}
 		}
 
-		return nil, fmt.Errorf("DCR failed with status %d for %s: %s", resp.StatusCode, serverName, errorMsg)
+		return nil, dcrErr
 	}
 
 	// Parse the response
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DCR response: %w", err)
+	}
+
 	var dcrResponse DCRResponse
-	if err := json.NewDecoder(resp.Body).Decode(&dcrResponse); err != nil {
+	if err := decodeJSON(ctx, responseBody, &dcrResponse, strict); err != nil {
 		return nil, fmt.Errorf("failed to decode DCR response: %w", err)
 	}
 
 	if dcrResponse.ClientID == "" {
-		return nil, fmt.Errorf("DCR response missing client_id for %s", serverName)
+		return nil, fmt.Errorf("DCR response missing client_id for %s", label)
 	}
 
-	// Create client credentials (public client - no secret)
-	creds := &ClientCredentials{
-		ClientID:              dcrResponse.ClientID,
-		ServerURL:             discovery.ResourceURL,
-		IsPublic:              true,
-		AuthorizationEndpoint: discovery.AuthorizationEndpoint,
-		TokenEndpoint:         discovery.TokenEndpoint,
-		// No ClientSecret for public clients
+	// Servers may omit registered metadata from the response; keep the requested auth method
+	if dcrResponse.TokenEndpointAuthMethod == "" {
+		dcrResponse.TokenEndpointAuthMethod = registration.TokenEndpointAuthMethod
 	}
+	if len(dcrResponse.RedirectURIs) == 0 {
+		dcrResponse.RedirectURIs = slices.Clone(registration.RedirectURIs)
+	}
+
+	creds := CredentialsFromDCRResponse(&dcrResponse, discovery.ResourceURL, discovery.RegistrationEndpoint)
+	creds.AuthorizationEndpoint = discovery.AuthorizationEndpoint
+	creds.TokenEndpoint = discovery.TokenEndpoint
 
 	return creds, nil
 }
 
+// CredentialsFromDCRResponse converts a registration response into client credentials
+//
+// Copies the client_id and client_secret, the token endpoint auth method, the registered
+// redirect URIs and the RFC 7592 management fields (registration_client_uri, registration_access_token). IsPublic is set
+// when token_endpoint_auth_method is "none", or when it is absent and no secret was issued.
+// The authorization and token endpoints are left empty for the caller to fill from discovery.
+func CredentialsFromDCRResponse(resp *DCRResponse, serverURL, registrationEndpoint string) *ClientCredentials {
+	isPublic := resp.TokenEndpointAuthMethod == "none" ||
+		(resp.TokenEndpointAuthMethod == "" && resp.ClientSecret == "")
+
+	return &ClientCredentials{
+		ClientID:                resp.ClientID,
+		ClientSecret:            resp.ClientSecret,
+		ServerURL:               serverURL,
+		IsPublic:                isPublic,
+		TokenEndpointAuthMethod: resp.TokenEndpointAuthMethod,
+		RedirectURIs:            resp.RedirectURIs,
+		RegistrationEndpoint:    registrationEndpoint,
+		RegistrationClientURI:   resp.RegistrationClientURI,
+		RegistrationAccessToken: resp.RegistrationAccessToken,
+	}
+}
+
 // joinScopes joins a slice of scopes into a space-separated string
 // per OAuth 2.0 specification (RFC 6749 Section 3.3)
 func joinScopes(scopes []string) string {
@@ -171,3 +375,35 @@ func joinScopes(scopes []string) string {
 	}
 	return result
 }
+
+// DeleteClientRegistration deletes the client at its RFC 7592 client configuration endpoint
+//
+// RFC 7592 COMPLIANCE:
+// - Section 2.3: DELETE the registration_client_uri with the registration_access_token as Bearer token
+// - Section 2.3: 204 No Content means the client and its grants were removed
+//
+// Requires creds.RegistrationClientURI and creds.RegistrationAccessToken (see CredentialsFromDCRResponse)
+func DeleteClientRegistration(ctx context.Context, creds *ClientCredentials) error {
+	if creds == nil || creds.RegistrationClientURI == "" || creds.RegistrationAccessToken == "" {
+		return fmt.Errorf("client registration cannot be managed: registration_client_uri and registration_access_token are required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, creds.RegistrationClientURI, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create registration delete request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+creds.RegistrationAccessToken)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send registration delete request to %s: %w", creds.RegistrationClientURI, err)
+	}
+	defer resp.Body.Close()
+
+	// Some servers answer 200 instead of 204
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("deleting client registration %s failed with status %d", creds.ClientID, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package oauth
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// knownTokenEndpointAuthMethods are the token_endpoint_auth_method values accepted by DCRRequestBuilder
+// (RFC 7591 Section 2, RFC 8705 Section 2.1 for the mTLS methods)
+var knownTokenEndpointAuthMethods = []string{
+	"none",
+	"client_secret_post",
+	"client_secret_basic",
+	"client_secret_jwt",
+	"private_key_jwt",
+	"tls_client_auth",
+	"self_signed_tls_client_auth",
+}
+
+// DCRRequestBuilder builds a validated DCRRequest
+//
+// Create with NewDCRRequestBuilder, chain the Set* methods and call Build. Defaults match
+// PerformDCR: public client (token_endpoint_auth_method=none), authorization_code and
+// refresh_token grants, "code" response type and "web" application type.
+type DCRRequestBuilder struct {
+	request DCRRequest
+	scopes  []string
+}
+
+// NewDCRRequestBuilder starts a registration request for the given client name and redirect URI
+func NewDCRRequestBuilder(clientName, redirectURI string) *DCRRequestBuilder {
+	return &DCRRequestBuilder{
+		request: DCRRequest{
+			ClientName:              clientName,
+			RedirectURIs:            []string{redirectURI},
+			TokenEndpointAuthMethod: "none",
+			GrantTypes:              defaultGrantTypes,
+			ResponseTypes:           defaultResponseTypes,
+			ApplicationType:         ApplicationTypeWeb,
+		},
+	}
+}
+
+// SetRedirectURIs replaces the redirect URIs
+func (b *DCRRequestBuilder) SetRedirectURIs(redirectURIs ...string) *DCRRequestBuilder {
+	b.request.RedirectURIs = redirectURIs
+	return b
+}
+
+// SetGrantTypes sets the grant_types (see WithGrantTypes for accepted values)
+func (b *DCRRequestBuilder) SetGrantTypes(grantTypes ...string) *DCRRequestBuilder {
+	b.request.GrantTypes = grantTypes
+	return b
+}
+
+// SetResponseTypes sets the response_types (see WithResponseTypes for accepted values)
+func (b *DCRRequestBuilder) SetResponseTypes(responseTypes ...string) *DCRRequestBuilder {
+	b.request.ResponseTypes = responseTypes
+	return b
+}
+
+// SetScopes sets the requested scopes (sent space-separated)
+func (b *DCRRequestBuilder) SetScopes(scopes ...string) *DCRRequestBuilder {
+	b.scopes = scopes
+	return b
+}
+
+// SetTokenEndpointAuthMethod sets how the client authenticates at the token endpoint
+func (b *DCRRequestBuilder) SetTokenEndpointAuthMethod(method string) *DCRRequestBuilder {
+	b.request.TokenEndpointAuthMethod = method
+	return b
+}
+
+// SetApplicationType sets the application_type (ApplicationTypeWeb or ApplicationTypeNative)
+func (b *DCRRequestBuilder) SetApplicationType(appType string) *DCRRequestBuilder {
+	b.request.ApplicationType = appType
+	return b
+}
+
+// SetContacts sets the administrator email addresses
+func (b *DCRRequestBuilder) SetContacts(emails ...string) *DCRRequestBuilder {
+	b.request.Contacts = emails
+	return b
+}
+
+// SetClientURI sets the client_uri display metadata
+func (b *DCRRequestBuilder) SetClientURI(uri string) *DCRRequestBuilder {
+	b.request.ClientURI = uri
+	return b
+}
+
+// SetLogoURI sets the logo_uri display metadata
+func (b *DCRRequestBuilder) SetLogoURI(uri string) *DCRRequestBuilder {
+	b.request.LogoURI = uri
+	return b
+}
+
+// SetTOSURI sets the tos_uri display metadata
+func (b *DCRRequestBuilder) SetTOSURI(uri string) *DCRRequestBuilder {
+	b.request.TOSURI = uri
+	return b
+}
+
+// SetPolicyURI sets the policy_uri display metadata
+func (b *DCRRequestBuilder) SetPolicyURI(uri string) *DCRRequestBuilder {
+	b.request.PolicyURI = uri
+	return b
+}
+
+// SetSoftware sets the software_id and software_version identifying the client software
+func (b *DCRRequestBuilder) SetSoftware(id, version string) *DCRRequestBuilder {
+	b.request.SoftwareID = id
+	b.request.SoftwareVersion = version
+	return b
+}
+
+// SetSoftwareStatement sets the RFC 7591 Section 2.3 software statement (a signed JWT)
+func (b *DCRRequestBuilder) SetSoftwareStatement(statement string) *DCRRequestBuilder {
+	b.request.SoftwareStatement = statement
+	return b
+}
+
+// SetExtraMetadata adds an extension metadata field to the request (see DCRRequest.ExtraMetadata)
+func (b *DCRRequestBuilder) SetExtraMetadata(name string, value any) *DCRRequestBuilder {
+	if b.request.ExtraMetadata == nil {
+		b.request.ExtraMetadata = map[string]any{}
+	}
+	b.request.ExtraMetadata[name] = value
+	return b
+}
+
+// Build validates the complete request and returns it
+//
+// Checks required fields (client name, redirect URIs), URI formats, known grant types,
+// response types and auth methods, and that grant and response types are consistent
+// (RFC 7591 Section 2.1: "code" needs authorization_code, "token" and "id_token" need implicit).
+func (b *DCRRequestBuilder) Build() (*DCRRequest, error) {
+	request := b.request
+	request.RedirectURIs = slices.Clone(b.request.RedirectURIs)
+	request.GrantTypes = slices.Clone(b.request.GrantTypes)
+	request.ResponseTypes = slices.Clone(b.request.ResponseTypes)
+	request.Contacts = slices.Clone(b.request.Contacts)
+	request.ExtraMetadata = maps.Clone(b.request.ExtraMetadata)
+	request.Scope = joinScopes(b.scopes)
+
+	if strings.TrimSpace(request.ClientName) == "" {
+		return nil, fmt.Errorf("client_name is required")
+	}
+
+	if len(request.RedirectURIs) == 0 {
+		return nil, fmt.Errorf("at least one redirect URI is required")
+	}
+	if err := validateRedirectURIFormat(request.RedirectURIs); err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(knownTokenEndpointAuthMethods, request.TokenEndpointAuthMethod) {
+		return nil, fmt.Errorf("unknown token_endpoint_auth_method %q", request.TokenEndpointAuthMethod)
+	}
+	if err := validateGrantTypes(request.GrantTypes); err != nil {
+		return nil, err
+	}
+	if err := validateResponseTypes(request.ResponseTypes); err != nil {
+		return nil, err
+	}
+	if err := validateResponseGrantConsistency(request.ResponseTypes, request.GrantTypes); err != nil {
+		return nil, err
+	}
+	if err := validateApplicationType(request.ApplicationType); err != nil {
+		return nil, err
+	}
+	if err := validateDisplayURIs(request.ClientURI, request.LogoURI, request.TOSURI, request.PolicyURI); err != nil {
+		return nil, err
+	}
+	if err := validateContacts(request.Contacts); err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// validateRedirectURIFormat checks that every redirect URI is absolute and has no fragment
+// (RFC 6749 Section 3.1.2)
+func validateRedirectURIFormat(redirectURIs []string) error {
+	for _, redirectURI := range redirectURIs {
+		parsed, err := url.Parse(redirectURI)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+			return fmt.Errorf("redirect URI %q must be an absolute URI without fragment", redirectURI)
+		}
+	}
+	return nil
+}
+
+// validateResponseGrantConsistency checks the RFC 7591 Section 2.1 correspondence between
+// response_types and grant_types
+func validateResponseGrantConsistency(responseTypes, grantTypes []string) error {
+	for _, responseType := range responseTypes {
+		parts := strings.Fields(responseType)
+		if slices.Contains(parts, "code") && !slices.Contains(grantTypes, "authorization_code") {
+			return fmt.Errorf("response type %q requires the authorization_code grant type", responseType)
+		}
+		if (slices.Contains(parts, "token") || slices.Contains(parts, "id_token")) && !slices.Contains(grantTypes, "implicit") {
+			return fmt.Errorf("response type %q requires the implicit grant type", responseType)
+		}
+	}
+	if slices.Contains(grantTypes, "authorization_code") && !slices.ContainsFunc(responseTypes, func(responseType string) bool {
+		return slices.Contains(strings.Fields(responseType), "code")
+	}) {
+		return fmt.Errorf("authorization_code grant type requires the \"code\" response type")
+	}
+	return nil
+}
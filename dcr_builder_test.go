@@ -0,0 +1,130 @@
+package oauth
+
+import (
+	"context"
+	"testing"
+)
+
+// TestDCRRequestBuilder_Defaults verifies the builder defaults match PerformDCR
+func TestDCRRequestBuilder_Defaults(t *testing.T) {
+	request, err := NewDCRRequestBuilder("MCP Gateway - test", DefaultRedirectURI).
+		SetScopes("read", "write").
+		SetSoftwareStatement("eyJhbGciOiJSUzI1NiJ9.e30.sig").
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if request.TokenEndpointAuthMethod != "none" {
+		t.Errorf("Expected token_endpoint_auth_method=none, got %s", request.TokenEndpointAuthMethod)
+	}
+	if len(request.GrantTypes) != 2 || len(request.ResponseTypes) != 1 || request.ApplicationType != ApplicationTypeWeb {
+		t.Errorf("Unexpected defaults: %+v", request)
+	}
+	if request.Scope != "read write" {
+		t.Errorf("Expected scope=\"read write\", got %q", request.Scope)
+	}
+	if request.SoftwareStatement == "" {
+		t.Error("Expected software statement to be set")
+	}
+}
+
+// TestDCRRequestBuilder_Validation verifies Build rejects incomplete, malformed and conflicting requests
+func TestDCRRequestBuilder_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *DCRRequestBuilder
+	}{
+		{"missing client name", NewDCRRequestBuilder(" ", DefaultRedirectURI)},
+		{"relative redirect URI", NewDCRRequestBuilder("client", "/callback")},
+		{"redirect URI with fragment", NewDCRRequestBuilder("client", DefaultRedirectURI+"#frag")},
+		{"no redirect URIs", NewDCRRequestBuilder("client", DefaultRedirectURI).SetRedirectURIs()},
+		{"unknown grant type", NewDCRRequestBuilder("client", DefaultRedirectURI).SetGrantTypes("magic")},
+		{"unknown auth method", NewDCRRequestBuilder("client", DefaultRedirectURI).SetTokenEndpointAuthMethod("secret")},
+		{"code without authorization_code", NewDCRRequestBuilder("client", DefaultRedirectURI).SetGrantTypes("refresh_token")},
+		{"token without implicit", NewDCRRequestBuilder("client", DefaultRedirectURI).SetResponseTypes("code", "token")},
+		{"http logo URI", NewDCRRequestBuilder("client", DefaultRedirectURI).SetLogoURI("http://example.com/logo.png")},
+		{"invalid contact", NewDCRRequestBuilder("client", DefaultRedirectURI).SetContacts("admin")},
+		{"unknown application type", NewDCRRequestBuilder("client", DefaultRedirectURI).SetApplicationType("desktop")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.builder.Build(); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+// TestRegisterClient verifies a built request is sent as-is
+func TestRegisterClient(t *testing.T) {
+	var captured DCRRequest
+	server := newMockRegistrationServer(t, &captured)
+	discovery := &Discovery{RegistrationEndpoint: server.URL}
+
+	request, err := NewDCRRequestBuilder("Custom Client", "http://localhost:5000/callback").
+		SetApplicationType(ApplicationTypeNative).
+		SetGrantTypes("authorization_code").
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	creds, err := RegisterClient(context.Background(), discovery, request)
+	if err != nil {
+		t.Fatalf("RegisterClient failed: %v", err)
+	}
+
+	if creds.ClientID != "test-client-id-123" || !creds.IsPublic {
+		t.Errorf("Unexpected credentials: %+v", creds)
+	}
+	if captured.ClientName != "Custom Client" || captured.ApplicationType != ApplicationTypeNative || len(captured.GrantTypes) != 1 {
+		t.Errorf("Request not sent as built: %+v", captured)
+	}
+
+	// Redirect URI policy still applies to explicit requests
+	request.RedirectURIs = []string{"https://evil.com/callback"}
+	if _, err := RegisterClient(context.Background(), discovery, request); err == nil {
+		t.Error("Expected error for disallowed redirect URI")
+	}
+}
+
+// TestPerformDCR_WithDCRRequestBuilder verifies PerformDCR sends a builder's request and
+// keeps applying the redirect URI policy and the redirectURI argument check
+func TestPerformDCR_WithDCRRequestBuilder(t *testing.T) {
+	var captured DCRRequest
+	server := newMockRegistrationServer(t, &captured)
+	discovery := &Discovery{RegistrationEndpoint: server.URL}
+
+	builder := NewDCRRequestBuilder("Custom Client", "http://localhost:5000/callback").
+		SetApplicationType(ApplicationTypeNative)
+	creds, err := PerformDCR(context.Background(), discovery, "test-server", "http://localhost:5000/callback",
+		WithDCRRequestBuilder(builder))
+	if err != nil {
+		t.Fatalf("PerformDCR failed: %v", err)
+	}
+	if creds.ClientID != "test-client-id-123" {
+		t.Errorf("Unexpected credentials: %+v", creds)
+	}
+	if captured.ClientName != "Custom Client" || captured.ApplicationType != ApplicationTypeNative {
+		t.Errorf("Request not sent as built: %+v", captured)
+	}
+
+	tests := []struct {
+		name        string
+		redirectURI string
+		opts        []DCROption
+	}{
+		{"redirect URI not in request", "http://localhost:6000/callback", []DCROption{WithDCRRequestBuilder(builder)}},
+		{"builder error", "", []DCROption{WithDCRRequestBuilder(NewDCRRequestBuilder("", DefaultRedirectURI))}},
+		{"loopback disabled", "", []DCROption{WithDCRRequestBuilder(builder), WithRedirectURIPolicy(RedirectURIPolicy{DisableLoopback: true})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := PerformDCR(context.Background(), discovery, "test-server", tt.redirectURI, tt.opts...); err == nil {
+				t.Error("Expected PerformDCR to fail")
+			}
+		})
+	}
+}
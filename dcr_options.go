@@ -0,0 +1,368 @@
+package oauth
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// defaultGrantTypes are the grant types requested for a registered client
+var defaultGrantTypes = []string{"authorization_code", "refresh_token"}
+
+// knownGrantTypes are the grant type values accepted by WithGrantTypes
+//
+// RFC 7591 Section 2 lists the core values; the URN values come from
+// RFC 7523 (JWT bearer), RFC 8628 (device code) and RFC 8693 (token exchange)
+var knownGrantTypes = []string{
+	"authorization_code",
+	"implicit",
+	"password",
+	"client_credentials",
+	"refresh_token",
+	"urn:ietf:params:oauth:grant-type:jwt-bearer",
+	"urn:ietf:params:oauth:grant-type:saml2-bearer",
+	"urn:ietf:params:oauth:grant-type:device_code",
+	"urn:ietf:params:oauth:grant-type:token-exchange",
+}
+
+// defaultResponseTypes are the response types requested for a registered client
+var defaultResponseTypes = []string{"code"}
+
+// knownResponseTypeParts are the components a response_types value may combine
+// (space-separated, e.g. "code id_token") per RFC 7591 Section 2 and OAuth 2.0
+// Multiple Response Type Encoding Practices
+var knownResponseTypeParts = []string{"code", "token", "id_token", "none"}
+
+// Application types for WithApplicationType
+//
+// OpenID Connect Dynamic Client Registration 1.0 Section 2 (registered for RFC 7591 use):
+// web clients must use https redirect URIs on non-loopback hosts, native clients may use
+// loopback or custom-scheme redirect URIs
+const (
+	ApplicationTypeWeb    = "web"
+	ApplicationTypeNative = "native"
+)
+
+// defaultClientURI is the client_uri registered when WithClientURI is not used
+const defaultClientURI = "https://github.com/docker/mcp-gateway"
+
+// defaultContacts are the contacts registered when WithContacts is not used
+var defaultContacts = []string{"support@docker.com"}
+
+// DCROption configures PerformDCR
+type DCROption func(*dcrConfig)
+
+// dcrConfig holds the settings applied by DCROption values
+type dcrConfig struct {
+	redirectPolicy RedirectURIPolicy  // Which redirect URI hosts may be registered
+	grantTypes     []string           // grant_types sent in the registration request
+	responseTypes  []string           // response_types sent in the registration request
+	appType        string             // application_type sent in the registration request
+	clientURI      string             // client_uri display metadata
+	logoURI        string             // logo_uri display metadata
+	tosURI         string             // tos_uri display metadata
+	policyURI      string             // policy_uri display metadata
+	contacts       []string           // contacts (administrator email addresses)
+	strictJSON     bool               // Reject unknown fields in the registration response
+	extraMetadata  map[string]any     // Extension metadata added to the registration request
+	pkceHint       bool               // Declare the discovered PKCE methods as code_challenge_methods
+	confidential   bool               // Register a confidential client (client secret) instead of a public one
+	registration   *DCRRequest        // Explicit registration request (nil = built from the options above)
+	builder        *DCRRequestBuilder // Builds registration when set
+}
+
+// newDCRConfig applies options over the defaults and validates the result
+func newDCRConfig(opts []DCROption) (*dcrConfig, error) {
+	config := &dcrConfig{
+		grantTypes:    defaultGrantTypes,
+		responseTypes: defaultResponseTypes,
+		appType:       ApplicationTypeWeb,
+		clientURI:     defaultClientURI,
+		contacts:      defaultContacts,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.builder != nil {
+		registration, err := config.builder.Build()
+		if err != nil {
+			return nil, fmt.Errorf("invalid registration request: %w", err)
+		}
+		config.registration = registration
+	}
+
+	if err := validateGrantTypes(config.grantTypes); err != nil {
+		return nil, err
+	}
+	if err := validateResponseTypes(config.responseTypes); err != nil {
+		return nil, err
+	}
+	if err := validateApplicationType(config.appType); err != nil {
+		return nil, err
+	}
+	if err := validateDisplayURIs(config.clientURI, config.logoURI, config.tosURI, config.policyURI); err != nil {
+		return nil, err
+	}
+	if err := validateContacts(config.contacts); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// validateGrantTypes checks that grantTypes is non-empty and only contains known values
+func validateGrantTypes(grantTypes []string) error {
+	if len(grantTypes) == 0 {
+		return fmt.Errorf("at least one grant type is required")
+	}
+	for _, grantType := range grantTypes {
+		if !slices.Contains(knownGrantTypes, grantType) {
+			return fmt.Errorf("unknown grant type %q", grantType)
+		}
+	}
+	return nil
+}
+
+// validateResponseTypes checks that responseTypes is non-empty and each value only combines known parts
+func validateResponseTypes(responseTypes []string) error {
+	if len(responseTypes) == 0 {
+		return fmt.Errorf("at least one response type is required")
+	}
+	for _, responseType := range responseTypes {
+		parts := strings.Fields(responseType)
+		if len(parts) == 0 {
+			return fmt.Errorf("empty response type")
+		}
+		for _, part := range parts {
+			if !slices.Contains(knownResponseTypeParts, part) {
+				return fmt.Errorf("unknown response type %q", responseType)
+			}
+		}
+	}
+	return nil
+}
+
+// validateApplicationType checks that appType is "web" or "native"
+func validateApplicationType(appType string) error {
+	if appType != ApplicationTypeWeb && appType != ApplicationTypeNative {
+		return fmt.Errorf("unknown application type %q (use %q or %q)", appType, ApplicationTypeWeb, ApplicationTypeNative)
+	}
+	return nil
+}
+
+// validateDisplayURIs checks the non-empty display metadata URIs
+// RFC 7591 Section 2: these URLs are shown to users on consent screens
+func validateDisplayURIs(clientURI, logoURI, tosURI, policyURI string) error {
+	displayURIs := []struct {
+		name  string
+		value string
+	}{
+		{"client_uri", clientURI},
+		{"logo_uri", logoURI},
+		{"tos_uri", tosURI},
+		{"policy_uri", policyURI},
+	}
+	for _, uri := range displayURIs {
+		if uri.value == "" {
+			continue
+		}
+		if err := validateHTTPSURL(uri.name, uri.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateContacts checks every contact with validateContactEmail
+func validateContacts(contacts []string) error {
+	for _, contact := range contacts {
+		if err := validateContactEmail(contact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateContactEmail performs a basic email format check (local@domain, no whitespace)
+func validateContactEmail(contact string) error {
+	local, domain, found := strings.Cut(contact, "@")
+	if !found || local == "" || domain == "" || strings.ContainsAny(contact, " \t\r\n") {
+		return fmt.Errorf("invalid contact email %q", contact)
+	}
+	return nil
+}
+
+// validateHTTPSURL checks that value is a well-formed absolute https URL with a host
+func validateHTTPSURL(name, value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("%s %q is not a valid URL: %w", name, value, err)
+	}
+	if parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("%s %q must be an absolute https URL", name, value)
+	}
+	return nil
+}
+
+// RedirectURIPolicy controls which redirect URIs PerformDCR accepts
+//
+// The zero value is the default policy: loopback hosts (localhost, 127.0.0.1, ::1)
+// and mcp.docker.com are allowed.
+type RedirectURIPolicy struct {
+	// DisableLoopback rejects loopback redirect URIs, for hardened server deployments
+	// that never bind a local callback listener
+	DisableLoopback bool
+}
+
+// WithRedirectURIPolicy sets the redirect URI policy used to validate the registered redirect URI
+func WithRedirectURIPolicy(policy RedirectURIPolicy) DCROption {
+	return func(c *dcrConfig) {
+		c.redirectPolicy = policy
+	}
+}
+
+// WithGrantTypes overrides the grant_types requested at registration
+// (default: authorization_code and refresh_token)
+//
+// For example, short-lived sessions may register only "authorization_code", and device
+// flows need "urn:ietf:params:oauth:grant-type:device_code". Unknown values make
+// PerformDCR fail before contacting the server.
+func WithGrantTypes(grantTypes ...string) DCROption {
+	return func(c *dcrConfig) {
+		c.grantTypes = grantTypes
+	}
+}
+
+// WithResponseTypes overrides the response_types requested at registration (default: "code")
+//
+// Each value is a response type or a space-separated combination such as "code id_token".
+// Unknown values make PerformDCR fail before contacting the server.
+func WithResponseTypes(types ...string) DCROption {
+	return func(c *dcrConfig) {
+		c.responseTypes = types
+	}
+}
+
+// WithApplicationType sets the application_type sent at registration (default: "web")
+//
+// Use ApplicationTypeNative for desktop clients that receive the callback on a loopback
+// redirect URI; some servers apply stricter redirect URI policies to web clients.
+func WithApplicationType(appType string) DCROption {
+	return func(c *dcrConfig) {
+		c.appType = appType
+	}
+}
+
+// WithClientURI sets the client_uri registered for the client (default: the MCP Gateway repository)
+// The URI must be an absolute https URL
+func WithClientURI(uri string) DCROption {
+	return func(c *dcrConfig) {
+		c.clientURI = uri
+	}
+}
+
+// WithLogoURI sets the logo_uri shown on the authorization server's consent screen
+// The URI must be an absolute https URL
+func WithLogoURI(uri string) DCROption {
+	return func(c *dcrConfig) {
+		c.logoURI = uri
+	}
+}
+
+// WithTOSURI sets the tos_uri (terms of service) shown on the consent screen
+// The URI must be an absolute https URL
+func WithTOSURI(uri string) DCROption {
+	return func(c *dcrConfig) {
+		c.tosURI = uri
+	}
+}
+
+// WithPolicyURI sets the policy_uri (privacy policy) shown on the consent screen
+// The URI must be an absolute https URL
+func WithPolicyURI(uri string) DCROption {
+	return func(c *dcrConfig) {
+		c.policyURI = uri
+	}
+}
+
+// WithContacts sets the contacts (administrator email addresses) registered for the client
+// (default: support@docker.com)
+//
+// RFC 7591 Section 2: some enterprise authorization servers require contacts before they
+// approve a registration. Each value must look like an email address.
+func WithContacts(emails ...string) DCROption {
+	return func(c *dcrConfig) {
+		c.contacts = emails
+	}
+}
+
+// WithStrictDCRJSON rejects registration responses that contain fields this library does not know
+//
+// DIAGNOSTIC MODE: see WithStrictJSON. RFC 7591 allows servers to return extension
+// metadata, so keep the default (ignore unknown fields) in production.
+func WithStrictDCRJSON() DCROption {
+	return func(c *dcrConfig) {
+		c.strictJSON = true
+	}
+}
+
+// WithDCRExtraMetadata adds extension client metadata fields to the registration request
+//
+// RFC 7591 Section 2: authorization servers may define additional client metadata; servers
+// ignore fields they do not understand. Fields named like a standard registration field
+// are ignored. Repeated calls accumulate.
+func WithDCRExtraMetadata(fields map[string]any) DCROption {
+	return func(c *dcrConfig) {
+		if c.extraMetadata == nil {
+			c.extraMetadata = map[string]any{}
+		}
+		maps.Copy(c.extraMetadata, fields)
+	}
+}
+
+// WithCodeChallengeMethodsHint declares the PKCE methods the client will use as the
+// code_challenge_methods registration field
+//
+// The field is not standardized; some servers use it to enforce PKCE per client. The value
+// is the S256 method when the server advertises it (Discovery.CodeChallengeMethod), and the
+// field is omitted otherwise.
+func WithCodeChallengeMethodsHint() DCROption {
+	return func(c *dcrConfig) {
+		c.pkceHint = true
+	}
+}
+
+// WithConfidentialClient registers a confidential client that authenticates with a client secret
+//
+// token_endpoint_auth_method is the first of client_secret_basic and client_secret_post in
+// Discovery.TokenEndpointAuthMethodsSupported, or client_secret_basic when the server
+// advertises neither. Only use this where the client secret can be kept confidential.
+func WithConfidentialClient() DCROption {
+	return func(c *dcrConfig) {
+		c.confidential = true
+	}
+}
+
+// WithDCRRequest makes PerformDCR send registration instead of building its default request
+//
+// The request's redirect URIs are registered; a non-empty redirectURI argument must be one
+// of them. The registration metadata options (WithGrantTypes, WithContacts, ...) do not
+// apply, while WithRedirectURIPolicy and WithStrictDCRJSON still do.
+func WithDCRRequest(registration *DCRRequest) DCROption {
+	return func(c *dcrConfig) {
+		c.registration = registration
+		c.builder = nil
+	}
+}
+
+// WithDCRRequestBuilder is WithDCRRequest for the request built by builder
+// Build errors make PerformDCR fail before contacting the server
+func WithDCRRequestBuilder(builder *DCRRequestBuilder) DCROption {
+	return func(c *dcrConfig) {
+		c.registration = nil
+		c.builder = builder
+	}
+}
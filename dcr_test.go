@@ -6,6 +6,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -25,7 +28,7 @@ func TestPerformDCR_PublicClient(t *testing.T) {
 			ClientID:                "test-client-id-123",
 			TokenEndpointAuthMethod: "none",
 			GrantTypes:              []string{"authorization_code", "refresh_token"},
-			RedirectURIs:            []string{"https://mcp.docker.com/oauth/callback"},
+			RedirectURIs:            []string{DefaultRedirectURI},
 		})
 	}))
 	defer regServer.Close()
@@ -72,6 +75,38 @@ func TestPerformDCR_PublicClient(t *testing.T) {
 	}
 }
 
+// TestPerformDCR_RedirectURIsInCredentials verifies the registered redirect URIs are returned
+// in the credentials: the defaulted URI when the server omits them, otherwise the server's list
+func TestPerformDCR_RedirectURIsInCredentials(t *testing.T) {
+	var registered []string
+	regServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(DCRResponse{
+			ClientID:     "client-123",
+			RedirectURIs: registered,
+		})
+	}))
+	defer regServer.Close()
+
+	discovery := &Discovery{RegistrationEndpoint: regServer.URL}
+
+	creds, err := PerformDCR(context.Background(), discovery, "test-server", "")
+	if err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+	if !slices.Equal(creds.RedirectURIs, []string{"https://mcp.docker.com/oauth/callback"}) {
+		t.Errorf("Expected default redirect URI in credentials, got %v", creds.RedirectURIs)
+	}
+
+	registered = []string{"http://localhost:8080/callback", "http://127.0.0.1:8080/callback"}
+	creds, err = PerformDCR(context.Background(), discovery, "test-server", "http://localhost:8080/callback")
+	if err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+	if !slices.Equal(creds.RedirectURIs, registered) {
+		t.Errorf("Expected redirect URIs from the registration response, got %v", creds.RedirectURIs)
+	}
+}
+
 // TestPerformDCR_NoRegistrationEndpoint verifies error handling
 // when registration endpoint is not available
 func TestPerformDCR_NoRegistrationEndpoint(t *testing.T) {
@@ -132,12 +167,42 @@ func TestIsValidRedirectURI(t *testing.T) {
 			expectError: false,
 			description: "IPv6 localhost should be allowed",
 		},
+		{
+			name:        "IPv6 localhost with zone",
+			redirectURI: "http://[::1%25lo0]:8080/callback",
+			expectError: false,
+			description: "Zoned IPv6 loopback should be allowed",
+		},
+		{
+			name:        "IPv4-mapped IPv6 localhost",
+			redirectURI: "http://[::ffff:127.0.0.1]:8080/callback",
+			expectError: false,
+			description: "IPv4-mapped loopback should be allowed",
+		},
+		{
+			name:        "IPv6 link-local with zone",
+			redirectURI: "http://[fe80::1%25eth0]:8080/callback",
+			expectError: true,
+			description: "Zoned non-loopback IPv6 should be blocked",
+		},
+		{
+			name:        "public IPv6",
+			redirectURI: "https://[2001:db8::1]/callback",
+			expectError: true,
+			description: "Public IPv6 addresses should be blocked",
+		},
 		{
 			name:        "mcp.docker.com production",
-			redirectURI: "https://mcp.docker.com/oauth/callback",
+			redirectURI: DefaultRedirectURI,
 			expectError: false,
 			description: "Production mcp.docker.com should be allowed",
 		},
+		{
+			name:        "mcp.docker.com per-server",
+			redirectURI: DefaultRedirectURIForServer("https://api.example.com/mcp"),
+			expectError: false,
+			description: "Per-server mcp.docker.com callback should be allowed",
+		},
 		{
 			name:        "evil domain",
 			redirectURI: "https://evil.com/callback",
@@ -166,7 +231,7 @@ func TestIsValidRedirectURI(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := isValidRedirectURI(tt.redirectURI)
+			err := isValidRedirectURI(tt.redirectURI, RedirectURIPolicy{})
 			if tt.expectError && err == nil {
 				t.Errorf("Expected error for %q (%s)", tt.redirectURI, tt.description)
 			}
@@ -176,3 +241,381 @@ func TestIsValidRedirectURI(t *testing.T) {
 		})
 	}
 }
+
+// TestIsValidRedirectURI_DisableLoopback verifies loopback is allowed by default and rejected by policy
+func TestIsValidRedirectURI_DisableLoopback(t *testing.T) {
+	policy := RedirectURIPolicy{DisableLoopback: true}
+
+	for _, redirectURI := range []string{
+		"http://localhost:5000/callback",
+		"http://127.0.0.1:8080/callback",
+		"http://[::1]:8080/callback",
+	} {
+		if err := isValidRedirectURI(redirectURI, RedirectURIPolicy{}); err != nil {
+			t.Errorf("Expected %s to be allowed by default, got %v", redirectURI, err)
+		}
+		if err := isValidRedirectURI(redirectURI, policy); err == nil {
+			t.Errorf("Expected %s to be rejected when loopback is disabled", redirectURI)
+		}
+	}
+
+	if err := isValidRedirectURI(DefaultRedirectURI, policy); err != nil {
+		t.Errorf("Expected %s to stay allowed, got %v", DefaultRedirectURI, err)
+	}
+}
+
+// TestPerformDCR_LoopbackDisabled verifies the policy is applied before contacting the server
+func TestPerformDCR_LoopbackDisabled(t *testing.T) {
+	discovery := &Discovery{RegistrationEndpoint: "https://auth.example.com/register"}
+
+	_, err := PerformDCR(context.Background(), discovery, "test-server", "http://localhost:5000/callback",
+		WithRedirectURIPolicy(RedirectURIPolicy{DisableLoopback: true}))
+	if err == nil {
+		t.Fatal("Expected error for loopback redirect URI")
+	}
+}
+
+// TestDefaultRedirectURIForServer verifies the server URL is carried in the callback query
+func TestDefaultRedirectURIForServer(t *testing.T) {
+	redirectURI := DefaultRedirectURIForServer("https://api.example.com/mcp?x=1")
+
+	parsed, err := url.Parse(redirectURI)
+	if err != nil {
+		t.Fatalf("Invalid redirect URI %q: %v", redirectURI, err)
+	}
+	if got := parsed.Scheme + "://" + parsed.Host + parsed.Path; got != DefaultRedirectURI {
+		t.Errorf("Expected base %s, got %s", DefaultRedirectURI, got)
+	}
+	if server := parsed.Query().Get("server"); server != "https://api.example.com/mcp?x=1" {
+		t.Errorf("Expected server query parameter to round-trip, got %q", server)
+	}
+
+	if got := DefaultRedirectURIForServer(""); got != DefaultRedirectURI {
+		t.Errorf("Expected DefaultRedirectURI for empty server URL, got %s", got)
+	}
+}
+
+// newMockRegistrationServer starts a registration endpoint that decodes each request
+// into captured and registers the client as "test-client-id-123"
+func newMockRegistrationServer(t *testing.T, captured any) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(captured); err != nil {
+			t.Errorf("Failed to decode DCR request: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(DCRResponse{ClientID: "test-client-id-123"})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestPerformDCR_WithGrantTypes verifies custom grant types are sent and unknown values rejected
+func TestPerformDCR_WithGrantTypes(t *testing.T) {
+	var captured DCRRequest
+	server := newMockRegistrationServer(t, &captured)
+	discovery := &Discovery{RegistrationEndpoint: server.URL}
+
+	_, err := PerformDCR(context.Background(), discovery, "test-server", "",
+		WithGrantTypes("authorization_code", "urn:ietf:params:oauth:grant-type:device_code"))
+	if err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+	if len(captured.GrantTypes) != 2 || captured.GrantTypes[1] != "urn:ietf:params:oauth:grant-type:device_code" {
+		t.Errorf("Expected custom grant types, got %v", captured.GrantTypes)
+	}
+
+	// Default grant types
+	captured = DCRRequest{}
+	if _, err := PerformDCR(context.Background(), discovery, "test-server", ""); err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+	if len(captured.GrantTypes) != 2 || captured.GrantTypes[0] != "authorization_code" || captured.GrantTypes[1] != "refresh_token" {
+		t.Errorf("Expected default grant types, got %v", captured.GrantTypes)
+	}
+
+	// Unknown and empty grant types fail before the request is sent
+	for _, grantTypes := range [][]string{{"authorization_code", "magic_link"}, {}} {
+		if _, err := PerformDCR(context.Background(), discovery, "test-server", "", WithGrantTypes(grantTypes...)); err == nil {
+			t.Errorf("Expected error for grant types %v", grantTypes)
+		}
+	}
+}
+
+// TestPerformDCR_WithResponseTypes verifies the default and overridden response_types
+func TestPerformDCR_WithResponseTypes(t *testing.T) {
+	var captured DCRRequest
+	server := newMockRegistrationServer(t, &captured)
+	discovery := &Discovery{RegistrationEndpoint: server.URL}
+
+	if _, err := PerformDCR(context.Background(), discovery, "test-server", ""); err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+	if len(captured.ResponseTypes) != 1 || captured.ResponseTypes[0] != "code" {
+		t.Errorf("Expected default response_types [code], got %v", captured.ResponseTypes)
+	}
+
+	captured = DCRRequest{}
+	if _, err := PerformDCR(context.Background(), discovery, "test-server", "", WithResponseTypes("code", "code id_token")); err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+	if len(captured.ResponseTypes) != 2 || captured.ResponseTypes[1] != "code id_token" {
+		t.Errorf("Expected overridden response_types, got %v", captured.ResponseTypes)
+	}
+
+	for _, types := range [][]string{{"code", "magic"}, {""}, {}} {
+		if _, err := PerformDCR(context.Background(), discovery, "test-server", "", WithResponseTypes(types...)); err == nil {
+			t.Errorf("Expected error for response types %q", types)
+		}
+	}
+}
+
+// TestPerformDCR_WithApplicationType verifies the default and overridden application_type
+func TestPerformDCR_WithApplicationType(t *testing.T) {
+	var captured DCRRequest
+	server := newMockRegistrationServer(t, &captured)
+	discovery := &Discovery{RegistrationEndpoint: server.URL}
+
+	if _, err := PerformDCR(context.Background(), discovery, "test-server", ""); err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+	if captured.ApplicationType != ApplicationTypeWeb {
+		t.Errorf("Expected default application_type=web, got %q", captured.ApplicationType)
+	}
+
+	captured = DCRRequest{}
+	_, err := PerformDCR(context.Background(), discovery, "test-server", "http://localhost:5000/callback",
+		WithApplicationType(ApplicationTypeNative))
+	if err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+	if captured.ApplicationType != ApplicationTypeNative {
+		t.Errorf("Expected application_type=native, got %q", captured.ApplicationType)
+	}
+
+	if _, err := PerformDCR(context.Background(), discovery, "test-server", "", WithApplicationType("desktop")); err == nil {
+		t.Error("Expected error for unknown application type")
+	}
+}
+
+// TestPerformDCR_DisplayMetadata verifies client display URIs are sent and must be absolute https URLs
+func TestPerformDCR_DisplayMetadata(t *testing.T) {
+	var captured DCRRequest
+	server := newMockRegistrationServer(t, &captured)
+	discovery := &Discovery{RegistrationEndpoint: server.URL}
+
+	_, err := PerformDCR(context.Background(), discovery, "test-server", "",
+		WithClientURI("https://example.com"),
+		WithLogoURI("https://example.com/logo.png"),
+		WithTOSURI("https://example.com/tos"),
+		WithPolicyURI("https://example.com/privacy"))
+	if err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+
+	if captured.ClientURI != "https://example.com" ||
+		captured.LogoURI != "https://example.com/logo.png" ||
+		captured.TOSURI != "https://example.com/tos" ||
+		captured.PolicyURI != "https://example.com/privacy" {
+		t.Errorf("Unexpected display metadata: %+v", captured)
+	}
+
+	invalid := []DCROption{
+		WithClientURI("http://example.com"),
+		WithLogoURI("/logo.png"),
+		WithTOSURI("https://"),
+		WithPolicyURI("ftp://example.com/privacy"),
+	}
+	for _, opt := range invalid {
+		if _, err := PerformDCR(context.Background(), discovery, "test-server", "", opt); err == nil {
+			t.Error("Expected error for non-https display URI")
+		}
+	}
+}
+
+// TestPerformDCR_WithContacts verifies contacts are sent and validated as email addresses
+func TestPerformDCR_WithContacts(t *testing.T) {
+	var captured DCRRequest
+	server := newMockRegistrationServer(t, &captured)
+	discovery := &Discovery{RegistrationEndpoint: server.URL}
+
+	if _, err := PerformDCR(context.Background(), discovery, "test-server", "", WithContacts("admin@example.com", "security@example.com")); err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+	if len(captured.Contacts) != 2 || captured.Contacts[0] != "admin@example.com" {
+		t.Errorf("Expected custom contacts, got %v", captured.Contacts)
+	}
+
+	for _, contact := range []string{"admin", "@example.com", "admin@", "ad min@example.com"} {
+		if _, err := PerformDCR(context.Background(), discovery, "test-server", "", WithContacts(contact)); err == nil {
+			t.Errorf("Expected error for contact %q", contact)
+		}
+	}
+}
+
+// TestPerformDCR_ExtraMetadata verifies extension metadata and the PKCE hint are serialized
+// into the request body without overriding standard fields
+func TestPerformDCR_ExtraMetadata(t *testing.T) {
+	var captured map[string]any
+	server := newMockRegistrationServer(t, &captured)
+	discovery := &Discovery{RegistrationEndpoint: server.URL, CodeChallengeMethod: []string{"S256"}}
+
+	_, err := PerformDCR(context.Background(), discovery, "test-server", "",
+		WithDCRExtraMetadata(map[string]any{"tenant": "acme", "client_name": "override", "max_sessions": 3}),
+		WithCodeChallengeMethodsHint())
+	if err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+
+	if captured["tenant"] != "acme" || captured["max_sessions"] != float64(3) {
+		t.Errorf("Expected extra metadata in the request body, got %v", captured)
+	}
+	if captured["client_name"] != "MCP Gateway - test-server" {
+		t.Errorf("Expected standard client_name to win, got %v", captured["client_name"])
+	}
+	methods, _ := captured["code_challenge_methods"].([]any)
+	if len(methods) != 1 || methods[0] != "S256" {
+		t.Errorf("Expected code_challenge_methods=[S256], got %v", captured["code_challenge_methods"])
+	}
+
+	// Without S256 support there is nothing to declare
+	captured = nil
+	discovery.CodeChallengeMethod = nil
+	if _, err := PerformDCR(context.Background(), discovery, "test-server", "", WithCodeChallengeMethodsHint()); err != nil {
+		t.Fatalf("DCR failed: %v", err)
+	}
+	if _, ok := captured["code_challenge_methods"]; ok {
+		t.Error("Expected no code_challenge_methods without advertised S256")
+	}
+}
+
+// TestCredentialsFromDCRResponse verifies the registration response mapping
+func TestCredentialsFromDCRResponse(t *testing.T) {
+	confidential := CredentialsFromDCRResponse(&DCRResponse{
+		ClientID:                "client-123",
+		ClientSecret:            "secret-456",
+		TokenEndpointAuthMethod: "client_secret_basic",
+		RegistrationClientURI:   "https://auth.example.com/register/client-123",
+		RegistrationAccessToken: "rat-789",
+	}, "https://api.example.com/mcp", "https://auth.example.com/register")
+
+	if confidential.ClientID != "client-123" || confidential.ClientSecret != "secret-456" {
+		t.Errorf("Unexpected client identity: %+v", confidential)
+	}
+	if confidential.IsPublic {
+		t.Error("Expected confidential client for client_secret_basic")
+	}
+	if confidential.ServerURL != "https://api.example.com/mcp" || confidential.RegistrationEndpoint != "https://auth.example.com/register" {
+		t.Errorf("Unexpected server fields: %+v", confidential)
+	}
+	if confidential.RegistrationClientURI != "https://auth.example.com/register/client-123" || confidential.RegistrationAccessToken != "rat-789" {
+		t.Errorf("Expected RFC 7592 fields to be copied: %+v", confidential)
+	}
+
+	public := CredentialsFromDCRResponse(&DCRResponse{ClientID: "client-123", TokenEndpointAuthMethod: "none"}, "", "")
+	if !public.IsPublic {
+		t.Error("Expected public client for token_endpoint_auth_method=none")
+	}
+}
+
+// TestPerformDCR_StrictJSON verifies unknown registration response fields are only rejected in strict mode
+func TestPerformDCR_StrictJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"client_id":"test-client-id-123","vendor_extension":true}`))
+	}))
+	defer server.Close()
+	discovery := &Discovery{RegistrationEndpoint: server.URL}
+
+	creds, err := PerformDCR(context.Background(), discovery, "test-server", "")
+	if err != nil {
+		t.Fatalf("Expected lenient decoding to succeed, got %v", err)
+	}
+	if creds.ClientID != "test-client-id-123" {
+		t.Errorf("Expected client ID test-client-id-123, got %s", creds.ClientID)
+	}
+
+	_, err = PerformDCR(context.Background(), discovery, "test-server", "", WithStrictDCRJSON())
+	if err == nil || !strings.Contains(err.Error(), "vendor_extension") {
+		t.Errorf("Expected unknown field error in strict mode, got %v", err)
+	}
+}
+
+// TestNewDCRRequest verifies the minimal request carries the discovered scopes and the
+// given redirect URIs, and rejects missing or malformed redirect URIs
+func TestNewDCRRequest(t *testing.T) {
+	discovery := &Discovery{Scopes: []string{"tools:read", "tools:write"}}
+	redirectURIs := []string{DefaultRedirectURI, "http://localhost:8080/callback"}
+
+	request, err := NewDCRRequest(discovery, "notion", redirectURIs)
+	if err != nil {
+		t.Fatalf("NewDCRRequest failed: %v", err)
+	}
+
+	if request.ClientName != "MCP Gateway - notion" {
+		t.Errorf("Unexpected client name %q", request.ClientName)
+	}
+	if request.Scope != "tools:read tools:write" {
+		t.Errorf("Expected discovered scopes, got %q", request.Scope)
+	}
+	if !slices.Equal(request.RedirectURIs, redirectURIs) {
+		t.Errorf("Expected redirect URIs %v, got %v", redirectURIs, request.RedirectURIs)
+	}
+	if request.TokenEndpointAuthMethod != "none" {
+		t.Errorf("Expected public client, got token_endpoint_auth_method=%q", request.TokenEndpointAuthMethod)
+	}
+	if !slices.Equal(request.GrantTypes, defaultGrantTypes) || !slices.Equal(request.ResponseTypes, defaultResponseTypes) {
+		t.Errorf("Unexpected grant/response types: %v / %v", request.GrantTypes, request.ResponseTypes)
+	}
+
+	// The request must not alias the caller's slice
+	redirectURIs[0] = "https://changed.example.com/callback"
+	if request.RedirectURIs[0] != DefaultRedirectURI {
+		t.Error("Expected redirect URIs to be copied")
+	}
+
+	if request, err := NewDCRRequest(&Discovery{}, "notion", []string{DefaultRedirectURI}); err != nil || request.Scope != "" {
+		t.Errorf("Expected no scope without discovered scopes, got %+v, %v", request, err)
+	}
+
+	invalid := [][]string{
+		nil,
+		{"/callback"},
+		{"https://mcp.docker.com/oauth/callback#frag"},
+	}
+	for _, uris := range invalid {
+		if _, err := NewDCRRequest(discovery, "notion", uris); err == nil {
+			t.Errorf("Expected error for redirect URIs %v", uris)
+		}
+	}
+}
+
+// TestNewDCRRequest_ConfidentialClient verifies the token endpoint auth method follows the
+// public/confidential intent and the server's advertised methods
+func TestNewDCRRequest_ConfidentialClient(t *testing.T) {
+	tests := []struct {
+		name         string
+		supported    []string
+		opts         []DCROption
+		expectedAuth string
+	}{
+		{"public", []string{"client_secret_post"}, nil, "none"},
+		{"confidential default", nil, []DCROption{WithConfidentialClient()}, "client_secret_basic"},
+		{"confidential advertised post", []string{"none", "private_key_jwt", "client_secret_post", "client_secret_basic"}, []DCROption{WithConfidentialClient()}, "client_secret_post"},
+		{"confidential without secret methods", []string{"private_key_jwt"}, []DCROption{WithConfidentialClient()}, "client_secret_basic"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discovery := &Discovery{TokenEndpointAuthMethodsSupported: tt.supported}
+			request, err := NewDCRRequest(discovery, "notion", []string{DefaultRedirectURI}, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewDCRRequest failed: %v", err)
+			}
+			if request.TokenEndpointAuthMethod != tt.expectedAuth {
+				t.Errorf("Expected token_endpoint_auth_method=%q, got %q", tt.expectedAuth, request.TokenEndpointAuthMethod)
+			}
+		})
+	}
+}
@@ -1,10 +1,14 @@
 package oauth
 
 import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"slices"
@@ -28,53 +32,162 @@ import (
 // 5. Fetch Authorization Server Metadata (REQUIRED)
 // 6. Build discovery result with all gathered information
 //
+// NO OAUTH REQUIRED: If the MCP server answers the unauthenticated probe with a 2xx status,
+// returns &Discovery{RequiresOAuth: false} and a nil error (never a nil Discovery)
+//
 // FALLBACK BEHAVIOR: If WWW-Authenticate missing/unparseable, falls back to
 // RFC 9728-required /.well-known/oauth-protected-resource endpoint
-func DiscoverOAuthRequirements(ctx context.Context, serverURL string) (*Discovery, error) {
+// (path-specific location first, then the root location)
+//
+// LOGGING: log lines are prefixed with the correlation ID from WithCorrelationID; if the
+// context has none, a random 8 hex character ID is generated for the call
+//
+// OPTIONS: See DiscoveryOption (e.g. WithProbeMethod) to customize the discovery flow,
+// and WithEventEmitter to observe it
+func DiscoverOAuthRequirements(ctx context.Context, serverURL string, opts ...DiscoveryOption) (*Discovery, error) {
+	// Every log line of this discovery carries the caller's correlation ID, or a generated one
+	ctx = ensureCorrelationID(ctx)
+
 	// Extract logger from context (or use noop if not provided)
 	logger := loggerFromContext(ctx)
 
 	logger.Infof("starting OAuth discovery for server: %s", serverURL)
 
-	// Create HTTP client with reasonable timeout
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	config, err := newDiscoveryConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	logDuration := TimedOperation(ctx, "OAuth discovery")
+	discovery, err := withSpan(ctx, SpanDiscovery, "discovery", serverURL, func(ctx context.Context) (*Discovery, error) {
+		return discoverOAuthRequirements(ctx, serverURL, config)
+	})
+	if err != nil {
+		config.events.OnDiscoveryFailed(serverURL, err)
+		return nil, err
 	}
+	logDuration()
+	discovery.Timings.Total = time.Since(start)
+	config.events.OnDiscoveryComplete(discovery, discovery.Timings.Total)
+
+	return discovery, nil
+}
+
+// DiscoverFromResponse runs discovery from an MCP server response the caller already received
+//
+// The status code, WWW-Authenticate and Link headers of resp are examined exactly as the
+// probe response would be, then resource and authorization server metadata are fetched;
+// the MCP server itself is not contacted again. mcpBaseURL identifies the MCP server
+// (defaults for the resource and authorization server are derived from it); if it is
+// empty, the URL of resp.Request is used. Discovery takes ownership of resp and closes its body.
+//
+// Equivalent to DiscoverOAuthRequirements(ctx, mcpBaseURL, WithInitialResponse(resp), opts...)
+func DiscoverFromResponse(ctx context.Context, resp *http.Response, mcpBaseURL string, opts ...DiscoveryOption) (*Discovery, error) {
+	if resp == nil {
+		return nil, fmt.Errorf("MCP server response is required")
+	}
+	if mcpBaseURL == "" {
+		if resp.Request == nil || resp.Request.URL == nil {
+			if resp.Body != nil {
+				resp.Body.Close()
+			}
+			return nil, fmt.Errorf("MCP server URL is required when the response has no request")
+		}
+		mcpBaseURL = resp.Request.URL.String()
+	}
+
+	return DiscoverOAuthRequirements(ctx, mcpBaseURL, append([]DiscoveryOption{WithInitialResponse(resp)}, opts...)...)
+}
+
+// discoverOAuthRequirements runs the discovery flow described on DiscoverOAuthRequirements
+func discoverOAuthRequirements(ctx context.Context, serverURL string, config *discoveryConfig) (*Discovery, error) {
+	logger := loggerFromContext(ctx)
+
+	if config.insecureSkipVerify {
+		logger.Warnf("TLS certificate verification is DISABLED for discovery of %s - never use this in production", serverURL)
+	}
+
+	// Create HTTP client with reasonable timeout (and custom DNS resolution if configured)
+	client := config.newHTTPClient()
 
 	// Parse server URL to extract base domain for defaults
 	parsedURL, err := url.Parse(serverURL)
 	if err != nil {
+		config.closeInitialResponse()
 		return nil, fmt.Errorf("invalid server URL: %w", err)
 	}
 
+	var timings Timings
+
 	// STEP 1: Make initial MCP request to trigger 401 Unauthorized
 	// MCP Spec Section 4.1: "MCP request without token" should trigger 401
-	// Use POST with initialize request as per spec diagrams
-	mcpPayload := `{"jsonrpc":"2.0","method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"mcp-gateway","version":"1.0.0"}},"id":1}`
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serverURL, strings.NewReader(mcpPayload))
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-
-	// Set headers for MCP protocol request
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "docker-mcp-gateway/1.0.0")
-	req.Header.Set("Accept", "application/json")
+	// (skipped when the caller already has the response via WithInitialResponse)
+	var resp *http.Response
+	if config.initialResponse != nil {
+		logger.Infof("using caller-supplied MCP server response, skipping probe")
+		resp = config.initialResponse
+	} else {
+		probeStart := time.Now()
+		timings.ProbeAttempts++
+		resp, err = withSpan(ctx, SpanProbe, "probe", serverURL, func(ctx context.Context) (*http.Response, error) {
+			return probeMCPServer(ctx, client, serverURL, config.probeMethod, config.probeHeaders, config.probeBody)
+		})
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("connecting to server %s: %w", serverURL, err)
+		// Some servers don't implement HEAD - fall back to the default initialize probe
+		if config.probeMethod == http.MethodHead && resp.StatusCode == http.StatusMethodNotAllowed {
+			resp.Body.Close()
+			logger.Infof("HEAD probe returned 405 Method Not Allowed, retrying with %s", defaultProbeMethod)
+			timings.ProbeAttempts++
+			resp, err = withSpan(ctx, SpanProbe, "probe", serverURL, func(ctx context.Context) (*http.Response, error) {
+				return probeMCPServer(ctx, client, serverURL, defaultProbeMethod, config.probeHeaders, nil)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		timings.Probe = time.Since(probeStart)
+		config.events.OnProbeComplete(serverURL, resp.StatusCode, timings.Probe)
+	}
+	// Only the status, headers and (in lenient mode) a problem+json body of the probe are
+	// used. The body is closed before the metadata requests, so that the probe's connection
+	// and its HostLimiter slot are free for those requests to the same host.
+	closeProbe := func() {
+		if resp.Body != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxProblemBodySize))
+			resp.Body.Close()
+		}
 	}
-	defer resp.Body.Close()
 
 	logger.Infof("MCP server response: status=%d", resp.StatusCode)
 
-	// If not 401, OAuth might not be required (Authorization is OPTIONAL per MCP spec Section 2.1)
-	// We log a warning but continue discovery attempt in case server is misconfigured
+	// A successful response means the server accepted the unauthenticated request:
+	// OAuth is not required (Authorization is OPTIONAL per MCP spec Section 2.1)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		logger.Infof("MCP server accepted unauthenticated request - OAuth not required")
+		closeProbe()
+		return &Discovery{RequiresOAuth: false, ResponseHeaders: resp.Header.Clone(), Timings: timings}, nil
+	}
+
+	// Any other non-401 status is unexpected - log a warning but continue discovery
+	// attempt in case server is misconfigured
 	if resp.StatusCode != http.StatusUnauthorized {
 		logger.Warnf("expected 401 Unauthorized, got %d - OAuth may not be required", resp.StatusCode)
 	}
 
+	// LENIENT MODE: some servers signal auth requirements with an RFC 7807 problem+json
+	// body (often on a 400) instead of, or in addition to, the WWW-Authenticate header
+	var problem *problemDetails
+	if config.lenient {
+		if problem = parseAuthProblem(resp); problem != nil {
+			logger.Infof("problem+json response references OAuth: type=%q title=%q", problem.Type, problem.Title)
+		}
+	}
+	closeProbe()
+
 	// STEP 2: Parse WWW-Authenticate header (if present)
 	// MCP Spec Section 4.1: "MCP servers MUST use the HTTP header WWW-Authenticate when returning a 401 Unauthorized"
 	wwwAuth := resp.Header.Get("WWW-Authenticate")
@@ -95,6 +208,11 @@ func DiscoverOAuthRequirements(ctx context.Context, serverURL string) (*Discover
 		logger.Infof("no WWW-Authenticate header present - will try well-known endpoint")
 	}
 
+	// Hints from a problem+json body are handled like an extra Bearer challenge
+	if problem != nil {
+		challenges = append(challenges, problem.challenge())
+	}
+
 	// STEP 3: Initialize with intelligent defaults (Inspector pattern)
 	// Default authorization server to MCP server's domain
 	defaultAuthServerURL := fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
@@ -104,47 +222,158 @@ func DiscoverOAuthRequirements(ctx context.Context, serverURL string) (*Discover
 	var resourceMetadata *ProtectedResourceMetadata
 	var resourceMetadataError error
 	authServerURL := defaultAuthServerURL
+	if problem != nil && problem.authorizationServer() != "" {
+		authServerURL = problem.authorizationServer()
+		logger.Infof("authorization server hint from problem+json: %s", authServerURL)
+	}
 
 	// STEP 4: Try to get resource metadata (OPTIONAL - don't fail if missing)
 	// RFC 9728 Section 5.1: resource_metadata parameter in WWW-Authenticate
-	resourceMetadataURL := ""
+	// Multiple protection spaces may each advertise a different URL - try them in order
+	var resourceMetadataURLs []string
 	if challenges != nil {
-		resourceMetadataURL = FindResourceMetadataURL(challenges)
-	}
-
-	if resourceMetadataURL != "" {
-		// Resource metadata URL found - try to fetch it
-		logger.Infof("fetching protected resource metadata from: %s", resourceMetadataURL)
-		resourceMetadata, resourceMetadataError = fetchOAuthProtectedResourceMetadata(ctx, client, resourceMetadataURL)
-		if resourceMetadataError == nil && resourceMetadata != nil && resourceMetadata.AuthorizationServer != "" {
-			// Use authorization server from resource metadata if available
-			authServerURL = resourceMetadata.AuthorizationServer
-			logger.Infof("resource metadata retrieved, auth server: %s", authServerURL)
-		} else if resourceMetadataError != nil {
-			logger.Warnf("failed to fetch resource metadata: %v", resourceMetadataError)
+		resourceMetadataURLs = FindAllResourceMetadataURLs(challenges)
+	}
+	if len(resourceMetadataURLs) > 0 {
+		logger.Infof("resource metadata URL discovered via WWW-Authenticate header")
+	} else if linkURL := FindResourceMetadataLink(resp.Header.Values("Link")); linkURL != "" {
+		// RFC 9728 Section 4: metadata URL may also be advertised in a Link header
+		logger.Infof("resource metadata URL discovered via Link header: %s", linkURL)
+		resourceMetadataURLs = []string{linkURL}
+	}
+
+	if config.authServerURL != "" {
+		// The caller named the authorization server - skip resource metadata entirely
+		authServerURL = config.authServerURL
+		logger.Infof("using caller-supplied authorization server %s, skipping resource metadata", authServerURL)
+	} else if len(resourceMetadataURLs) > 0 {
+		// Resource metadata URL(s) found - try each until one succeeds
+		for _, resourceMetadataURL := range resourceMetadataURLs {
+			logger.Infof("fetching protected resource metadata from: %s", resourceMetadataURL)
+			fetchStart := time.Now()
+			resourceMetadata, resourceMetadataError = withSpan(ctx, SpanResourceMetadata, "resource_metadata", resourceMetadataURL,
+				func(ctx context.Context) (*ProtectedResourceMetadata, error) {
+					return fetchOAuthProtectedResourceMetadata(ctx, client, resourceMetadataURL, config.strictJSON)
+				})
+			fetchDuration := time.Since(fetchStart)
+			timings.ResourceMetadata += fetchDuration
+			timings.ResourceMetadataAttempts++
+			if resourceMetadataError == nil {
+				config.events.OnResourceMetadataFetched(resourceMetadataURL, fetchDuration)
+			}
+			if resourceMetadataError == nil && resourceMetadata != nil && resourceMetadata.AuthorizationServer != "" {
+				// Use authorization server from resource metadata if available
+				authServerURL = resourceMetadata.AuthorizationServer
+				logger.Infof("resource metadata retrieved, auth server: %s", authServerURL)
+				break
+			} else if resourceMetadataError != nil {
+				logger.Warnf("failed to fetch resource metadata: %v", resourceMetadataError)
+			}
 		}
 	} else {
-		// No resource_metadata in WWW-Authenticate - try well-known endpoint
-		wellKnownURL := fmt.Sprintf("%s/.well-known/oauth-protected-resource", defaultAuthServerURL)
-		logger.Infof("fallback: trying well-known resource metadata endpoint: %s", wellKnownURL)
-		resourceMetadata, resourceMetadataError = fetchOAuthProtectedResourceMetadata(ctx, client, wellKnownURL)
-		if resourceMetadataError == nil && resourceMetadata != nil && resourceMetadata.AuthorizationServer != "" {
-			authServerURL = resourceMetadata.AuthorizationServer
-			logger.Infof("resource metadata from well-known endpoint, auth server: %s", authServerURL)
+		// No resource_metadata in WWW-Authenticate - try well-known endpoints
+		// RFC 9728 Section 3.1: path-specific location first, then the root location
+		for _, wellKnownURL := range protectedResourceMetadataURLs(parsedURL) {
+			logger.Infof("fallback: trying well-known resource metadata endpoint: %s", wellKnownURL)
+			fetchStart := time.Now()
+			resourceMetadata, resourceMetadataError = withSpan(ctx, SpanResourceMetadata, "resource_metadata", wellKnownURL,
+				func(ctx context.Context) (*ProtectedResourceMetadata, error) {
+					return fetchOAuthProtectedResourceMetadata(ctx, client, wellKnownURL, config.strictJSON)
+				})
+			fetchDuration := time.Since(fetchStart)
+			timings.ResourceMetadata += fetchDuration
+			timings.ResourceMetadataAttempts++
+			if resourceMetadataError == nil {
+				config.events.OnResourceMetadataFetched(wellKnownURL, fetchDuration)
+			}
+			if resourceMetadataError == nil && resourceMetadata != nil && resourceMetadata.AuthorizationServer != "" {
+				authServerURL = resourceMetadata.AuthorizationServer
+				logger.Infof("resource metadata from well-known endpoint, auth server: %s", authServerURL)
+				break
+			}
+			logger.Debugf("well-known resource metadata endpoint %s failed: %v", wellKnownURL, resourceMetadataError)
 		}
 	}
 
+	// From here on OAuth is known to be required; failures carry what was found so far
+	partialDiscovery := func() *Discovery {
+		partial := &Discovery{
+			RequiresOAuth:       true,
+			ResourceURL:         defaultAuthServerURL,
+			ResourceServer:      defaultAuthServerURL,
+			AuthorizationServer: authServerURL,
+			Scopes:              FindRequiredScopes(challenges),
+			ResponseHeaders:     resp.Header.Clone(),
+			Timings:             timings,
+		}
+		if resourceMetadata != nil && resourceMetadata.Resource != "" {
+			partial.ResourceURL = resourceMetadata.Resource
+			partial.ResourceServer = resourceMetadata.Resource
+		}
+		return partial
+	}
+
+	// RFC 9728 Section 3.3: the metadata resource must identify the MCP server we probed,
+	// otherwise tokens would be audience-bound to a different resource
+	if resourceMetadata != nil && resourceMetadata.Resource != "" && !resourceMatchesServer(resourceMetadata.Resource, parsedURL) {
+		if config.strictAudience {
+			return nil, &DiscoveryError{
+				Stage:   DiscoveryStageAudience,
+				Partial: partialDiscovery(),
+				Err: fmt.Errorf("%w: resource metadata declares %s but server is %s",
+					ErrAudienceMismatch, resourceMetadata.Resource, serverURL),
+			}
+		}
+		logger.Warnf("resource metadata declares resource %s which does not match server %s - tokens may be bound to the wrong audience",
+			resourceMetadata.Resource, serverURL)
+	}
+
 	// STEP 5: Fetch Authorization Server Metadata (REQUIRED)
 	// MCP Spec Section 3.1: "Authorization servers MUST provide OAuth 2.0 Authorization Server Metadata (RFC8414)"
 	logger.Infof("fetching authorization server metadata from: %s", authServerURL)
-	authServerMetadata, err := fetchAuthorizationServerMetadata(ctx, client, authServerURL)
+	fetchStart := time.Now()
+	authServerMetadata, err := withSpan(ctx, SpanAuthServerMetadata, "auth_server_metadata", authServerURL,
+		func(ctx context.Context) (*AuthorizationServerMetadata, error) {
+			return fetchAuthorizationServerMetadata(ctx, client, authServerURL, config.strictJSON)
+		})
+	timings.AuthServerMetadata = time.Since(fetchStart)
+	timings.AuthServerMetadataAttempts++
 	if err != nil {
 		logger.Warnf("failed to fetch authorization server metadata: %v", err)
-		return nil, fmt.Errorf("fetching authorization server metadata from %s: %w", authServerURL, err)
+		return nil, &DiscoveryError{
+			Stage:   DiscoveryStageAuthServerMetadata,
+			Partial: partialDiscovery(),
+			Err:     fmt.Errorf("fetching authorization server metadata from %s: %w", authServerURL, err),
+		}
 	}
+	config.events.OnAuthServerMetadataFetched(authServerURL, timings.AuthServerMetadata)
 	logger.Infof("auth server metadata retrieved: token_endpoint=%s, registration_endpoint=%s",
 		authServerMetadata.TokenEndpoint, authServerMetadata.RegistrationEndpoint)
 
+	// Authorization server endpoints must use TLS (loopback is allowed for local development)
+	for _, insecure := range insecureEndpoints(authServerMetadata) {
+		if config.enforceHTTPS {
+			return nil, &DiscoveryError{
+				Stage:   DiscoveryStageEndpointTLS,
+				Partial: partialDiscovery(),
+				Err:     fmt.Errorf("authorization server %s uses non-TLS endpoint %s", authServerURL, insecure),
+			}
+		}
+		logger.Warnf("authorization server endpoint does not use HTTPS: %s", insecure)
+	}
+
+	// Endpoints must share the issuer's origin unless allowlisted
+	for _, foreign := range crossOriginEndpoints(authServerMetadata, config.allowedOrigins) {
+		if config.strictOrigin {
+			return nil, &DiscoveryError{
+				Stage:   DiscoveryStageEndpointOrigin,
+				Partial: partialDiscovery(),
+				Err:     fmt.Errorf("%w: issuer %s, %s", ErrEndpointOriginMismatch, authServerMetadata.Issuer, foreign),
+			}
+		}
+		logger.Warnf("authorization server endpoint is not on the issuer origin %s: %s", authServerMetadata.Issuer, foreign)
+	}
+
 	// STEP 6: Build discovery result with all available information
 	discovery := &Discovery{
 		RequiresOAuth: true,
@@ -159,6 +388,7 @@ func DiscoverOAuthRequirements(ctx context.Context, serverURL string) (*Discover
 		AuthorizationEndpoint:             authServerMetadata.AuthorizationEndpoint,
 		TokenEndpoint:                     authServerMetadata.TokenEndpoint,
 		RegistrationEndpoint:              authServerMetadata.RegistrationEndpoint,
+		RevocationEndpoint:                authServerMetadata.RevocationEndpoint,
 		JWKSUri:                           authServerMetadata.JWKSUri,
 		ScopesSupported:                   authServerMetadata.ScopesSupported,
 		ResponseTypesSupported:            authServerMetadata.ResponseTypesSupported,
@@ -169,6 +399,22 @@ func DiscoverOAuthRequirements(ctx context.Context, serverURL string) (*Discover
 		// PKCE support detection (OAuth 2.1 MUST requirement)
 		SupportsPKCE:        slices.Contains(authServerMetadata.CodeChallengeMethodsSupported, "S256"),
 		CodeChallengeMethod: authServerMetadata.CodeChallengeMethodsSupported,
+
+		// Mix-up attack mitigation (RFC 9207)
+		SupportsIssParameter: authServerMetadata.AuthorizationResponseIssSupported,
+
+		// JWT-Secured Authorization Requests (RFC 9101)
+		RequireRequestObject:     authServerMetadata.RequireSignedRequestObject,
+		RequestObjectSigningAlgs: authServerMetadata.RequestObjectSigningAlgsSupported,
+
+		// Rich Authorization Requests (RFC 9396)
+		SupportsRAR:                        len(authServerMetadata.AuthorizationDetailsTypesSupported) > 0,
+		AuthorizationDetailsTypesSupported: authServerMetadata.AuthorizationDetailsTypesSupported,
+
+		// Probe response headers for advanced callers (DPoP-Nonce, Retry-After, ...)
+		ResponseHeaders: resp.Header.Clone(),
+
+		Timings: timings,
 	}
 
 	// Override with resource metadata if successfully fetched
@@ -177,8 +423,12 @@ func DiscoverOAuthRequirements(ctx context.Context, serverURL string) (*Discover
 			discovery.ResourceURL = resourceMetadata.Resource
 			discovery.ResourceServer = resourceMetadata.Resource
 		}
+		// RFC 9728 names the field scopes_supported; some servers send "scopes" instead
+		discovery.BearerMethods = resourceMetadata.BearerMethodsSupported
 		if len(resourceMetadata.Scopes) > 0 {
 			discovery.Scopes = resourceMetadata.Scopes
+		} else if len(resourceMetadata.ScopesSupported) > 0 {
+			discovery.Scopes = resourceMetadata.ScopesSupported
 		}
 	}
 
@@ -193,13 +443,179 @@ func DiscoverOAuthRequirements(ctx context.Context, serverURL string) (*Discover
 	return discovery, nil
 }
 
+// resourceMatchesServer reports whether a protected resource identifier covers the MCP server URL
+//
+// Scheme and host are compared case-insensitively (default ports ignored). The resource
+// path must equal the server path or be a parent of it, so an origin-wide resource such as
+// https://api.example.com matches https://api.example.com/mcp.
+func resourceMatchesServer(resource string, serverURL *url.URL) bool {
+	parsed, err := url.Parse(resource)
+	if err != nil {
+		return false
+	}
+	if !strings.EqualFold(parsed.Scheme, serverURL.Scheme) || canonicalHost(parsed) != canonicalHost(serverURL) {
+		return false
+	}
+
+	resourcePath := strings.TrimSuffix(parsed.Path, "/")
+	serverPath := strings.TrimSuffix(serverURL.Path, "/")
+	return resourcePath == serverPath || strings.HasPrefix(serverPath, resourcePath+"/")
+}
+
+// canonicalHost returns the lowercase host of u with the scheme's default port removed
+func canonicalHost(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" || isDefaultPort(u.Scheme, port) {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// isDefaultPort reports whether port is the default for scheme (443 for https, 80 for http)
+func isDefaultPort(scheme, port string) bool {
+	return (strings.EqualFold(scheme, "https") && port == "443") || (strings.EqualFold(scheme, "http") && port == "80")
+}
+
+// insecureEndpoints returns the authorization server endpoints that use http:// on a non-loopback host
+// Each entry is formatted as "name=url"
+func insecureEndpoints(metadata *AuthorizationServerMetadata) []string {
+	endpoints := []struct {
+		name string
+		url  string
+	}{
+		{"issuer", metadata.Issuer},
+		{"authorization_endpoint", metadata.AuthorizationEndpoint},
+		{"token_endpoint", metadata.TokenEndpoint},
+		{"registration_endpoint", metadata.RegistrationEndpoint},
+		{"revocation_endpoint", metadata.RevocationEndpoint},
+		{"jwks_uri", metadata.JWKSUri},
+	}
+
+	var insecure []string
+	for _, endpoint := range endpoints {
+		if endpoint.url == "" {
+			continue
+		}
+		parsed, err := url.Parse(endpoint.url)
+		if err != nil || !strings.EqualFold(parsed.Scheme, "http") {
+			continue
+		}
+		if isLoopbackHost(parsed.Hostname()) {
+			continue
+		}
+		insecure = append(insecure, endpoint.name+"="+endpoint.url)
+	}
+	return insecure
+}
+
+// crossOriginEndpoints returns the authorization, token and registration endpoints whose
+// origin differs from the issuer's and is not in allowed (normalized origins)
+// Each entry is formatted as "name=url"
+func crossOriginEndpoints(metadata *AuthorizationServerMetadata, allowed []string) []string {
+	issuerOrigin, err := endpointOrigin(metadata.Issuer)
+	if err != nil {
+		return nil
+	}
+
+	endpoints := []struct {
+		name string
+		url  string
+	}{
+		{"authorization_endpoint", metadata.AuthorizationEndpoint},
+		{"token_endpoint", metadata.TokenEndpoint},
+		{"registration_endpoint", metadata.RegistrationEndpoint},
+	}
+
+	var foreign []string
+	for _, endpoint := range endpoints {
+		if endpoint.url == "" {
+			continue
+		}
+		origin, err := endpointOrigin(endpoint.url)
+		if err == nil && (origin == issuerOrigin || slices.Contains(allowed, origin)) {
+			continue
+		}
+		foreign = append(foreign, endpoint.name+"="+endpoint.url)
+	}
+	return foreign
+}
+
+// endpointOrigin returns the normalized origin (lowercase scheme://host, default port removed) of rawURL
+func endpointOrigin(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return "", fmt.Errorf("%q is not an absolute URL", rawURL)
+	}
+	return strings.ToLower(parsed.Scheme) + "://" + canonicalHost(parsed), nil
+}
+
+// mcpInitializePayload is the JSON-RPC initialize request sent by the default POST probe
+const mcpInitializePayload = `{"jsonrpc":"2.0","method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"mcp-gateway","version":"1.0.0"}},"id":1}`
+
+// probeMCPServer sends the unauthenticated request used to elicit the 401 challenge
+//
+// POST sends an MCP initialize request as per spec diagrams and other methods send no body,
+// unless customBody is non-nil (WithProbeBody). headers (WithProbeHeaders) override the defaults.
+func probeMCPServer(ctx context.Context, client *http.Client, serverURL, method string, headers http.Header, customBody []byte) (*http.Response, error) {
+	var body io.Reader
+	switch {
+	case customBody != nil:
+		body = bytes.NewReader(customBody)
+	case method == http.MethodPost:
+		body = strings.NewReader(mcpInitializePayload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, serverURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	// Set headers for MCP protocol request
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", "docker-mcp-gateway/1.0.0")
+	req.Header.Set("Accept", "application/json")
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to server %s: %w", serverURL, err)
+	}
+	setSpanStatus(ctx, resp.StatusCode)
+	return resp, nil
+}
+
+// protectedResourceMetadataURLs returns the well-known resource metadata URLs to probe, in order
+//
+// RFC 9728 COMPLIANCE:
+// - Section 3.1: The well-known suffix is inserted between the host and the path of the resource
+// - For https://host/tenant/mcp the path-specific URL is https://host/.well-known/oauth-protected-resource/tenant/mcp
+// - The root https://host/.well-known/oauth-protected-resource is tried last for servers that only publish there
+func protectedResourceMetadataURLs(serverURL *url.URL) []string {
+	rootURL := fmt.Sprintf("%s://%s/.well-known/oauth-protected-resource", serverURL.Scheme, serverURL.Host)
+
+	path := strings.TrimSuffix(serverURL.EscapedPath(), "/")
+	if path == "" {
+		return []string{rootURL}
+	}
+
+	return []string{rootURL + path, rootURL}
+}
+
 // fetchOAuthProtectedResourceMetadata fetches metadata from /.well-known/oauth-protected-resource
 //
 // RFC 9728 COMPLIANCE:
 // - Implements RFC 9728 Section 3 "Protected Resource Metadata"
 // - Validates required fields: resource, authorization_server(s)
 // - Handles both singular and plural authorization server formats
-func fetchOAuthProtectedResourceMetadata(ctx context.Context, client *http.Client, metadataURL string) (*ProtectedResourceMetadata, error) {
+func fetchOAuthProtectedResourceMetadata(ctx context.Context, client *http.Client, metadataURL string, strict bool) (*ProtectedResourceMetadata, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
@@ -213,18 +629,19 @@ func fetchOAuthProtectedResourceMetadata(ctx context.Context, client *http.Clien
 		return nil, fmt.Errorf("fetching metadata from %s: %w", metadataURL, err)
 	}
 	defer resp.Body.Close()
+	setSpanStatus(ctx, resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("metadata endpoint returned status %d", resp.StatusCode)
+		return nil, newMetadataFetchError(metadataURL, resp)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readMetadataBody(resp)
 	if err != nil {
 		return nil, fmt.Errorf("reading response body: %w", err)
 	}
 
 	var metadata ProtectedResourceMetadata
-	if err := json.Unmarshal(body, &metadata); err != nil {
+	if err := decodeJSON(ctx, body, &metadata, strict); err != nil {
 		return nil, fmt.Errorf("parsing JSON response: %w", err)
 	}
 
@@ -247,21 +664,95 @@ func fetchOAuthProtectedResourceMetadata(ctx context.Context, client *http.Clien
 	return &metadata, nil
 }
 
+// readMetadataBody reads a metadata response body, decoding any content coding left in place
+//
+// No Accept-Encoding is set on metadata requests so Go's Transport advertises gzip and
+// decodes it transparently. Servers that compress anyway (deflate, or gzip through a custom
+// transport) still have their Content-Encoding honored here (RFC 9110 Section 8.4.1).
+func readMetadataBody(resp *http.Response) ([]byte, error) {
+	var reader io.Reader = resp.Body
+
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		gzipReader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("decoding gzip body: %w", err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case "deflate":
+		// RFC 9110 "deflate" is the zlib format (RFC 1950)
+		zlibReader, err := zlib.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("decoding deflate body: %w", err)
+		}
+		defer zlibReader.Close()
+		reader = zlibReader
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+
+	return io.ReadAll(reader)
+}
+
+// strictUnmarshaler is implemented by types with a custom UnmarshalJSON, which json.Decoder
+// calls without applying DisallowUnknownFields
+type strictUnmarshaler interface {
+	unmarshalStrict(data []byte) error
+}
+
+// decodeJSON unmarshals body into v
+//
+// By default unknown fields are ignored for forward compatibility. In strict mode they are
+// rejected (json.Decoder.DisallowUnknownFields) and logged as a warning, to surface server
+// bugs such as misspelled field names.
+func decodeJSON(ctx context.Context, body []byte, v any, strict bool) error {
+	if !strict {
+		return json.Unmarshal(body, v)
+	}
+
+	var err error
+	if su, ok := v.(strictUnmarshaler); ok {
+		err = su.unmarshalStrict(body)
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(v)
+	}
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "json: unknown field ") {
+			loggerFromContext(ctx).Warnf("strict JSON decoding rejected response: %v", err)
+		}
+		return err
+	}
+	return nil
+}
+
 // fetchAuthorizationServerMetadata fetches metadata from /.well-known/oauth-authorization-server
 //
 // RFC 8414 COMPLIANCE:
 // - Implements RFC 8414 Section 3 "Authorization Server Metadata"
 // - Validates required fields: issuer, authorization_endpoint, token_endpoint
 // - Validates issuer URL matches authorization server URL (RFC 8414 Section 3.2)
-func fetchAuthorizationServerMetadata(ctx context.Context, client *http.Client, authServerURL string) (*AuthorizationServerMetadata, error) {
-	// RFC 8414 Section 3: Construct well-known URL
-	var metadataURL string
-	if strings.HasSuffix(authServerURL, "/") {
-		metadataURL = authServerURL + ".well-known/oauth-authorization-server"
-	} else {
-		metadataURL = authServerURL + "/.well-known/oauth-authorization-server"
+func fetchAuthorizationServerMetadata(ctx context.Context, client *http.Client, authServerURL string, strict bool) (*AuthorizationServerMetadata, error) {
+	// RFC 8414 Section 2: the issuer must not have query or fragment components, which
+	// would also corrupt the well-known URL built below
+	if err := validateIssuerURL(authServerURL); err != nil {
+		return nil, fmt.Errorf("invalid authorization server URL: %w", err)
 	}
 
+	// RFC 8414 Section 3: Construct well-known URL
+	return fetchAuthorizationServerMetadataFrom(ctx, client, wellKnownURL(authServerURL, "oauth-authorization-server"), strict)
+}
+
+// wellKnownURL appends /.well-known/<suffix> to an issuer URL
+func wellKnownURL(issuer, suffix string) string {
+	return strings.TrimSuffix(issuer, "/") + "/.well-known/" + suffix
+}
+
+// fetchAuthorizationServerMetadataFrom fetches and validates authorization server metadata at metadataURL
+func fetchAuthorizationServerMetadataFrom(ctx context.Context, client *http.Client, metadataURL string, strict bool) (*AuthorizationServerMetadata, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
@@ -275,20 +766,24 @@ func fetchAuthorizationServerMetadata(ctx context.Context, client *http.Client,
 		return nil, fmt.Errorf("fetching metadata from %s: %w", metadataURL, err)
 	}
 	defer resp.Body.Close()
+	setSpanStatus(ctx, resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("authorization server metadata endpoint returned status %d", resp.StatusCode)
+		return nil, newMetadataFetchError(metadataURL, resp)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readMetadataBody(resp)
 	if err != nil {
 		return nil, fmt.Errorf("reading response body: %w", err)
 	}
 
 	var metadata AuthorizationServerMetadata
-	if err := json.Unmarshal(body, &metadata); err != nil {
+	if err := decodeJSON(ctx, body, &metadata, strict); err != nil {
 		return nil, fmt.Errorf("parsing JSON response: %w", err)
 	}
+	if metadata.pkceMethodsField != "" {
+		loggerFromContext(ctx).Debugf("PKCE methods for %s read from %s", metadataURL, metadata.pkceMethodsField)
+	}
 
 	// RFC 8414 Section 3.2: Validate required fields
 	if metadata.Issuer == "" {
@@ -305,10 +800,148 @@ func fetchAuthorizationServerMetadata(ctx context.Context, client *http.Client,
 	// Note: We trust the issuer field in the metadata as authoritative
 	// Cross-domain OAuth setups (like Stripe) are valid where resource server
 	// and authorization server are on different domains
-	_, err = url.Parse(metadata.Issuer)
-	if err != nil {
+	if err := validateIssuerURL(metadata.Issuer); err != nil {
 		return nil, fmt.Errorf("invalid issuer URL: %w", err)
 	}
 
 	return &metadata, nil
 }
+
+// validateIssuerURL checks that an issuer identifier parses and has no query or fragment
+// RFC 8414 Section 2: "The issuer identifier ... has no query or fragment components"
+func validateIssuerURL(issuer string) error {
+	parsed, err := url.Parse(issuer)
+	if err != nil {
+		return err
+	}
+	if parsed.RawQuery != "" || parsed.ForceQuery {
+		return fmt.Errorf("issuer %q must not contain a query component", issuer)
+	}
+	if parsed.Fragment != "" || strings.Contains(issuer, "#") {
+		return fmt.Errorf("issuer %q must not contain a fragment component", issuer)
+	}
+	return nil
+}
+
+// BearerMethod returns how bearer tokens should be presented to the protected resource
+//
+// RFC 9728 Section 2: bearer_methods_supported lists the RFC 6750 methods the resource accepts.
+// The Authorization header is used when it is listed or nothing is advertised; otherwise
+// body is preferred over query, since URLs are more likely to be logged (RFC 6750 Section 5.3).
+func (d *Discovery) BearerMethod() string {
+	if len(d.BearerMethods) == 0 || slices.Contains(d.BearerMethods, BearerMethodHeader) {
+		return BearerMethodHeader
+	}
+	for _, method := range []string{BearerMethodBody, BearerMethodQuery} {
+		if slices.Contains(d.BearerMethods, method) {
+			return method
+		}
+	}
+	return BearerMethodHeader
+}
+
+// Merge returns a copy of the discovery result with user-provided overrides applied
+//
+// Non-empty fields in overrides replace the discovered values (boolean fields are only
+// overridden when set to true). Overridden URL fields are validated the same way as
+// discovered endpoints, so a typo in an override fails fast instead of at token time.
+//
+// Example: an operator routes token requests through an internal hostname
+//
+//	merged, err := discovery.Merge(&Discovery{TokenEndpoint: "https://auth.internal/token"})
+func (d *Discovery) Merge(overrides *Discovery) (*Discovery, error) {
+	merged := *d
+	if overrides == nil {
+		return &merged, nil
+	}
+
+	// URL fields are validated before being applied
+	urlFields := []struct {
+		name     string
+		override string
+		target   *string
+	}{
+		{"resource_url", overrides.ResourceURL, &merged.ResourceURL},
+		{"resource_server", overrides.ResourceServer, &merged.ResourceServer},
+		{"authorization_server", overrides.AuthorizationServer, &merged.AuthorizationServer},
+		{"authorization_endpoint", overrides.AuthorizationEndpoint, &merged.AuthorizationEndpoint},
+		{"token_endpoint", overrides.TokenEndpoint, &merged.TokenEndpoint},
+		{"registration_endpoint", overrides.RegistrationEndpoint, &merged.RegistrationEndpoint},
+		{"revocation_endpoint", overrides.RevocationEndpoint, &merged.RevocationEndpoint},
+		{"jwks_uri", overrides.JWKSUri, &merged.JWKSUri},
+		{"issuer", overrides.Issuer, &merged.Issuer},
+	}
+	for _, field := range urlFields {
+		if field.override == "" {
+			continue
+		}
+		if err := validateEndpointURL(field.name, field.override); err != nil {
+			return nil, fmt.Errorf("invalid override: %w", err)
+		}
+		*field.target = field.override
+	}
+
+	if overrides.RequiresOAuth {
+		merged.RequiresOAuth = true
+	}
+	if overrides.SupportsPKCE {
+		merged.SupportsPKCE = true
+	}
+
+	if len(overrides.Scopes) > 0 {
+		merged.Scopes = overrides.Scopes
+	}
+	if len(overrides.BearerMethods) > 0 {
+		merged.BearerMethods = overrides.BearerMethods
+	}
+	if len(overrides.CodeChallengeMethod) > 0 {
+		merged.CodeChallengeMethod = overrides.CodeChallengeMethod
+	}
+	if len(overrides.ScopesSupported) > 0 {
+		merged.ScopesSupported = overrides.ScopesSupported
+	}
+	if len(overrides.ResponseTypesSupported) > 0 {
+		merged.ResponseTypesSupported = overrides.ResponseTypesSupported
+	}
+	if len(overrides.ResponseModesSupported) > 0 {
+		merged.ResponseModesSupported = overrides.ResponseModesSupported
+	}
+	if len(overrides.GrantTypesSupported) > 0 {
+		merged.GrantTypesSupported = overrides.GrantTypesSupported
+	}
+	if len(overrides.TokenEndpointAuthMethodsSupported) > 0 {
+		merged.TokenEndpointAuthMethodsSupported = overrides.TokenEndpointAuthMethodsSupported
+	}
+	if overrides.SupportsRAR {
+		merged.SupportsRAR = true
+	}
+	if overrides.SupportsIssParameter {
+		merged.SupportsIssParameter = true
+	}
+	if len(overrides.AuthorizationDetailsTypesSupported) > 0 {
+		merged.AuthorizationDetailsTypesSupported = overrides.AuthorizationDetailsTypesSupported
+	}
+	if overrides.RequireRequestObject {
+		merged.RequireRequestObject = true
+	}
+	if len(overrides.RequestObjectSigningAlgs) > 0 {
+		merged.RequestObjectSigningAlgs = overrides.RequestObjectSigningAlgs
+	}
+
+	return &merged, nil
+}
+
+// validateEndpointURL checks that an endpoint is an absolute http(s) URL with a host
+func validateEndpointURL(name, endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("%s %q is not a valid URL: %w", name, endpoint, err)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return fmt.Errorf("%s %q must use http or https", name, endpoint)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("%s %q is missing a host", name, endpoint)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package oauth
+
+import (
+	"context"
+	"slices"
+)
+
+// endpointFields returns the authorization server URLs of d compared by HasEndpointChanges, by metadata name
+func (d *Discovery) endpointFields() []struct{ name, url string } {
+	if d == nil {
+		d = &Discovery{}
+	}
+	return []struct{ name, url string }{
+		{"authorization_server", d.AuthorizationServer},
+		{"issuer", d.Issuer},
+		{"authorization_endpoint", d.AuthorizationEndpoint},
+		{"token_endpoint", d.TokenEndpoint},
+		{"registration_endpoint", d.RegistrationEndpoint},
+		{"revocation_endpoint", d.RevocationEndpoint},
+		{"jwks_uri", d.JWKSUri},
+	}
+}
+
+// HasEndpointChanges reports whether other (typically a fresh discovery) points at different
+// authorization server URLs than d (typically the cached one)
+//
+// The returned slice names each changed URL by its RFC 8414 metadata name ("issuer",
+// "token_endpoint", ...; "authorization_server" for the discovered server URL), in that
+// order. A changed token_endpoint or issuer means the authorization server was rotated
+// and tokens and client registrations from d should be considered invalid. A nil Discovery
+// is compared as if all URLs were empty. Use LogEndpointChanges to also log the changes.
+func (d *Discovery) HasEndpointChanges(other *Discovery) (bool, []string) {
+	before, after := d.endpointFields(), other.endpointFields()
+
+	var changed []string
+	for i := range before {
+		if before[i].url != after[i].url {
+			changed = append(changed, before[i].name)
+		}
+	}
+	return len(changed) > 0, changed
+}
+
+// LogEndpointChanges calls HasEndpointChanges and logs every changed URL at Warn level
+// with its old and new value, adding a warning that existing tokens are invalid when the
+// token endpoint changed
+func (d *Discovery) LogEndpointChanges(ctx context.Context, other *Discovery) (bool, []string) {
+	changed, fields := d.HasEndpointChanges(other)
+	if !changed {
+		return false, nil
+	}
+
+	logger := loggerFromContext(ctx)
+	before, after := d.endpointFields(), other.endpointFields()
+	for i := range before {
+		if before[i].url != after[i].url {
+			logger.Warnf("authorization server %s changed: %q -> %q", before[i].name, before[i].url, after[i].url)
+		}
+	}
+	if slices.Contains(fields, "token_endpoint") {
+		logger.Warnf("token endpoint changed - existing tokens are no longer valid and must be re-obtained")
+	}
+	return true, fields
+}
@@ -0,0 +1,69 @@
+package oauth
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// TestDiscovery_HasEndpointChanges verifies changed authorization server URLs are reported by name
+func TestDiscovery_HasEndpointChanges(t *testing.T) {
+	cached := &Discovery{
+		AuthorizationServer:   "https://auth.example.com",
+		Issuer:                "https://auth.example.com",
+		AuthorizationEndpoint: "https://auth.example.com/authorize",
+		TokenEndpoint:         "https://auth.example.com/token",
+		RegistrationEndpoint:  "https://auth.example.com/register",
+		Scopes:                []string{"read"},
+	}
+
+	same := *cached
+	same.Scopes = []string{"read", "write"}
+	if changed, fields := cached.HasEndpointChanges(&same); changed || fields != nil {
+		t.Errorf("Expected no endpoint changes when only scopes differ, got %v", fields)
+	}
+
+	rotated := *cached
+	rotated.TokenEndpoint = "https://auth2.example.com/token"
+	rotated.JWKSUri = "https://auth2.example.com/jwks"
+	changed, fields := cached.HasEndpointChanges(&rotated)
+	if !changed || !slices.Equal(fields, []string{"token_endpoint", "jwks_uri"}) {
+		t.Errorf("Expected token_endpoint and jwks_uri changes, got %v, %v", changed, fields)
+	}
+
+	if changed, fields := cached.HasEndpointChanges(nil); !changed || len(fields) != 5 {
+		t.Errorf("Expected every set URL to change against nil, got %v", fields)
+	}
+}
+
+// TestDiscovery_LogEndpointChanges verifies changes are logged as warnings, including the
+// token invalidation warning for a changed token endpoint
+func TestDiscovery_LogEndpointChanges(t *testing.T) {
+	cached := &Discovery{TokenEndpoint: "https://auth.example.com/token", RevocationEndpoint: "https://auth.example.com/revoke"}
+
+	logger := &testLogger{}
+	ctx := WithLogger(context.Background(), logger)
+
+	moved := *cached
+	moved.RevocationEndpoint = "https://auth.example.com/v2/revoke"
+	if changed, _ := cached.LogEndpointChanges(ctx, &moved); !changed {
+		t.Fatal("Expected revocation endpoint change")
+	}
+	if !logger.containsWarn("revocation_endpoint changed") || logger.containsWarn("existing tokens") {
+		t.Errorf("Expected only the revocation endpoint warning, got %v", logger.warns)
+	}
+
+	logger = &testLogger{}
+	ctx = WithLogger(context.Background(), logger)
+	moved.TokenEndpoint = "https://auth2.example.com/token"
+	if _, fields := cached.LogEndpointChanges(ctx, &moved); !slices.Contains(fields, "token_endpoint") {
+		t.Errorf("Expected token_endpoint change, got %v", fields)
+	}
+	if !logger.containsWarn("existing tokens are no longer valid") {
+		t.Errorf("Expected token invalidation warning, got %v", logger.warns)
+	}
+
+	if changed, fields := cached.LogEndpointChanges(ctx, cached); changed || fields != nil {
+		t.Errorf("Expected no changes comparing with itself, got %v", fields)
+	}
+}
@@ -0,0 +1,138 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// discoveryJSON is the JSON representation of Discovery
+// Key names follow the RFC 8414 / RFC 9728 metadata names where one exists
+type discoveryJSON struct {
+	RequiresOAuth bool `json:"requires_oauth"`
+
+	ResourceURL         string   `json:"resource_url,omitempty"`
+	ResourceServer      string   `json:"resource_server,omitempty"`
+	AuthorizationServer string   `json:"authorization_server,omitempty"`
+	Scopes              []string `json:"scopes,omitempty"`
+	BearerMethods       []string `json:"bearer_methods_supported,omitempty"`
+
+	AuthorizationEndpoint string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint         string   `json:"token_endpoint,omitempty"`
+	RegistrationEndpoint  string   `json:"registration_endpoint,omitempty"`
+	RevocationEndpoint    string   `json:"revocation_endpoint,omitempty"`
+	JWKSUri               string   `json:"jwks_uri,omitempty"`
+	SupportsPKCE          bool     `json:"supports_pkce"`
+	CodeChallengeMethod   []string `json:"code_challenge_methods_supported,omitempty"`
+	SupportsRAR           bool     `json:"supports_rar"`
+	SupportsIssParameter  bool     `json:"authorization_response_iss_parameter_supported"`
+	RequireRequestObject  bool     `json:"require_signed_request_object"`
+
+	Issuer                             string   `json:"issuer,omitempty"`
+	ScopesSupported                    []string `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported             []string `json:"response_types_supported,omitempty"`
+	ResponseModesSupported             []string `json:"response_modes_supported,omitempty"`
+	GrantTypesSupported                []string `json:"grant_types_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported  []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	AuthorizationDetailsTypesSupported []string `json:"authorization_details_types_supported,omitempty"`
+	RequestObjectSigningAlgs           []string `json:"request_object_signing_alg_values_supported,omitempty"`
+
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+
+	Timings Timings `json:"timings"`
+}
+
+// MarshalJSON serializes every Discovery field using snake_case keys
+// (e.g. "token_endpoint", "code_challenge_methods_supported")
+func (d Discovery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(discoveryJSON(d))
+}
+
+// UnmarshalJSON parses the representation produced by MarshalJSON
+func (d *Discovery) UnmarshalJSON(data []byte) error {
+	var decoded discoveryJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*d = Discovery(decoded)
+	return nil
+}
+
+// String returns a multi-line human-readable description of the discovery result for debugging
+//
+// ResponseHeaders are not included (see Discovery.ResponseHeaders)
+func (d *Discovery) String() string {
+	if d == nil {
+		return "<nil>"
+	}
+
+	var b strings.Builder
+	line := func(label, value string) {
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(&b, "  %-24s %s\n", label+":", value)
+	}
+
+	b.WriteString("OAuth discovery:\n")
+	line("Requires OAuth", fmt.Sprint(d.RequiresOAuth))
+	line("Resource", d.ResourceURL)
+	line("Authorization server", d.AuthorizationServer)
+	line("Issuer", d.Issuer)
+	line("Authorization endpoint", d.AuthorizationEndpoint)
+	line("Token endpoint", d.TokenEndpoint)
+	line("Registration endpoint", d.RegistrationEndpoint)
+	line("Revocation endpoint", d.RevocationEndpoint)
+	line("JWKS URI", d.JWKSUri)
+	line("Scopes", strings.Join(d.Scopes, " "))
+	line("Scopes supported", strings.Join(d.ScopesSupported, " "))
+	line("PKCE", formatSupport(d.SupportsPKCE, d.CodeChallengeMethod))
+	line("Rich authorization", formatSupport(d.SupportsRAR, d.AuthorizationDetailsTypesSupported))
+	line("Response iss parameter", fmt.Sprint(d.SupportsIssParameter))
+	line("Signed request object", formatRequirement(d.RequireRequestObject, d.RequestObjectSigningAlgs))
+	line("Grant types", strings.Join(d.GrantTypesSupported, " "))
+	line("Token auth methods", strings.Join(d.TokenEndpointAuthMethodsSupported, " "))
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Summary returns a compact single-line description of the discovery result for logs
+//
+// Example:
+//
+//	oauth=true auth_server=https://auth.example.com scopes=[read write] pkce=true dcr=true
+func (d *Discovery) Summary() string {
+	if d == nil {
+		return "<nil>"
+	}
+	if !d.RequiresOAuth {
+		return "oauth=false"
+	}
+	return fmt.Sprintf("oauth=true auth_server=%s scopes=[%s] pkce=%v dcr=%v",
+		d.AuthorizationServer, strings.Join(d.Scopes, " "), d.SupportsPKCE, d.RegistrationEndpoint != "")
+}
+
+// formatRequirement renders a required feature with its advertised values, e.g. "required (ES256)"
+func formatRequirement(required bool, values []string) string {
+	status := "optional"
+	if required {
+		status = "required"
+	}
+	if len(values) == 0 {
+		return status
+	}
+	return fmt.Sprintf("%s (%s)", status, strings.Join(values, ", "))
+}
+
+// formatSupport renders a feature flag with its advertised values, e.g. "supported (S256)"
+func formatSupport(supported bool, values []string) string {
+	status := "not supported"
+	if supported {
+		status = "supported"
+	}
+	if len(values) == 0 {
+		return status
+	}
+	return fmt.Sprintf("%s (%s)", status, strings.Join(values, ", "))
+}
@@ -0,0 +1,99 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFormatTestDiscovery returns a Discovery with every field populated
+func newFormatTestDiscovery() *Discovery {
+	return &Discovery{
+		RequiresOAuth:                      true,
+		ResourceURL:                        "https://mcp.example.com/mcp",
+		ResourceServer:                     "https://mcp.example.com/mcp",
+		AuthorizationServer:                "https://auth.example.com",
+		Scopes:                             []string{"read", "write"},
+		AuthorizationEndpoint:              "https://auth.example.com/authorize",
+		TokenEndpoint:                      "https://auth.example.com/token",
+		RegistrationEndpoint:               "https://auth.example.com/register",
+		RevocationEndpoint:                 "https://auth.example.com/revoke",
+		JWKSUri:                            "https://auth.example.com/jwks",
+		SupportsPKCE:                       true,
+		CodeChallengeMethod:                []string{"S256"},
+		SupportsRAR:                        true,
+		Issuer:                             "https://auth.example.com",
+		ScopesSupported:                    []string{"read", "write", "admin"},
+		ResponseTypesSupported:             []string{"code"},
+		ResponseModesSupported:             []string{"query"},
+		GrantTypesSupported:                []string{"authorization_code", "refresh_token"},
+		TokenEndpointAuthMethodsSupported:  []string{"none"},
+		AuthorizationDetailsTypesSupported: []string{"payment_initiation"},
+		RequireRequestObject:               true,
+		RequestObjectSigningAlgs:           []string{"ES256"},
+		ResponseHeaders:                    http.Header{"Retry-After": {"5"}},
+		Timings:                            Timings{Total: 120 * time.Millisecond, Probe: 40 * time.Millisecond, ProbeAttempts: 1},
+	}
+}
+
+// TestDiscoveryString verifies the multi-line output contains the key endpoints and flags
+func TestDiscoveryString(t *testing.T) {
+	output := newFormatTestDiscovery().String()
+
+	for _, expected := range []string{
+		"Authorization server:    https://auth.example.com",
+		"Token endpoint:          https://auth.example.com/token",
+		"Scopes:                  read write",
+		"PKCE:                    supported (S256)",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	if output := (&Discovery{}).String(); !strings.Contains(output, "Token endpoint:          -") {
+		t.Errorf("Expected placeholder for empty fields, got:\n%s", output)
+	}
+}
+
+// TestDiscoverySummary verifies the compact single-line form
+func TestDiscoverySummary(t *testing.T) {
+	expected := "oauth=true auth_server=https://auth.example.com scopes=[read write] pkce=true dcr=true"
+	if got := newFormatTestDiscovery().Summary(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if got := (&Discovery{}).Summary(); got != "oauth=false" {
+		t.Errorf("Expected oauth=false, got %q", got)
+	}
+}
+
+// TestDiscoveryMarshalJSON verifies snake_case keys and a lossless round-trip
+func TestDiscoveryMarshalJSON(t *testing.T) {
+	discovery := newFormatTestDiscovery()
+
+	data, err := json.Marshal(discovery)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var object map[string]any
+	if err := json.Unmarshal(data, &object); err != nil {
+		t.Fatalf("Unmarshal into map failed: %v", err)
+	}
+	for _, key := range []string{"requires_oauth", "token_endpoint", "code_challenge_methods_supported", "response_headers"} {
+		if _, ok := object[key]; !ok {
+			t.Errorf("Expected key %q in %s", key, data)
+		}
+	}
+
+	var decoded Discovery
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, discovery) {
+		t.Errorf("Round-trip mismatch:\n got %+v\nwant %+v", decoded, *discovery)
+	}
+}
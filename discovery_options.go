@@ -0,0 +1,320 @@
+package oauth
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// discoveryTimeout bounds every HTTP request made during discovery
+const discoveryTimeout = 30 * time.Second
+
+// defaultProbeMethod is the HTTP method of the initial MCP probe (POST with an initialize request)
+const defaultProbeMethod = http.MethodPost
+
+// DiscoveryOption configures DiscoverOAuthRequirements
+type DiscoveryOption func(*discoveryConfig)
+
+// discoveryConfig holds the settings applied by DiscoveryOption values
+type discoveryConfig struct {
+	probeMethod        string                // HTTP method for the initial MCP probe
+	probeHeaders       http.Header           // Extra headers for the initial MCP probe
+	probeBody          []byte                // Probe request body (nil = initialize request for POST)
+	initialResponse    *http.Response        // Caller-supplied probe response (skips the probe)
+	enforceHTTPS       bool                  // Fail (instead of warn) on non-loopback http:// endpoints
+	dohProvider        string                // DNS-over-HTTPS provider URL for hostname resolution
+	strictAudience     bool                  // Fail (instead of warn) when the metadata resource does not match the server
+	lenient            bool                  // Accept non-standard auth signals (e.g. problem+json probe responses)
+	hostLimiter        *HostLimiter          // Shared per-host concurrency limit (nil = unlimited)
+	insecureSkipVerify bool                  // Disable TLS certificate verification (development only)
+	events             DiscoveryEventEmitter // Telemetry hooks (never nil)
+	strictJSON         bool                  // Reject unknown fields in metadata documents
+	strictOrigin       bool                  // Fail (instead of warn) on endpoints outside the issuer origin
+	allowedOrigins     []string              // Additional endpoint origins accepted by the origin check
+	authServerURL      string                // Caller-supplied authorization server (skips resource metadata)
+	circuitBreaker     *CircuitBreaker       // Shared per-host circuit breaker (nil = disabled)
+}
+
+// newDiscoveryConfig applies options over the defaults and validates the result
+func newDiscoveryConfig(opts []DiscoveryOption) (*discoveryConfig, error) {
+	config := &discoveryConfig{
+		probeMethod: defaultProbeMethod,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.events == nil {
+		config.events = noopEventEmitter{}
+	}
+
+	if err := config.validate(); err != nil {
+		config.closeInitialResponse()
+		return nil, err
+	}
+	return config, nil
+}
+
+// closeInitialResponse closes the body of a WithInitialResponse response
+// Discovery owns that response even when it fails before reading it
+func (c *discoveryConfig) closeInitialResponse() {
+	if c.initialResponse != nil && c.initialResponse.Body != nil {
+		c.initialResponse.Body.Close()
+	}
+}
+
+// validate checks and normalizes the applied options
+func (c *discoveryConfig) validate() error {
+	switch c.probeMethod {
+	case http.MethodPost, http.MethodGet, http.MethodHead:
+	default:
+		return fmt.Errorf("unsupported probe method %q (use POST, GET or HEAD)", c.probeMethod)
+	}
+	if c.probeMethod == http.MethodHead && c.probeBody != nil {
+		return fmt.Errorf("a HEAD probe cannot have a body")
+	}
+
+	if c.dohProvider != "" {
+		provider, err := url.Parse(c.dohProvider)
+		if err != nil || provider.Scheme != "https" || provider.Host == "" {
+			return fmt.Errorf("invalid DNS-over-HTTPS provider %q (must be an absolute https URL)", c.dohProvider)
+		}
+	}
+
+	if c.authServerURL != "" {
+		parsed, err := url.Parse(c.authServerURL)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return fmt.Errorf("invalid authorization server URL %q (must be an absolute http(s) URL)", c.authServerURL)
+		}
+		if err := validateIssuerURL(c.authServerURL); err != nil {
+			return fmt.Errorf("invalid authorization server URL: %w", err)
+		}
+	}
+
+	for i, origin := range c.allowedOrigins {
+		normalized, err := endpointOrigin(origin)
+		if err != nil {
+			return fmt.Errorf("invalid allowed endpoint origin %q: %w", origin, err)
+		}
+		c.allowedOrigins[i] = normalized
+	}
+
+	return nil
+}
+
+// newHTTPClient creates the HTTP client used for all discovery requests
+func (c *discoveryConfig) newHTTPClient() *http.Client {
+	client := &http.Client{
+		Timeout: discoveryTimeout,
+	}
+
+	if c.dohProvider != "" || c.insecureSkipVerify {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if c.dohProvider != "" {
+			dialer := &net.Dialer{
+				Timeout:  discoveryTimeout,
+				Resolver: newDoHResolver(c.dohProvider, &http.Client{Timeout: discoveryTimeout}),
+			}
+			transport.DialContext = dialer.DialContext
+		}
+		if c.insecureSkipVerify {
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		client.Transport = transport
+	}
+
+	if c.hostLimiter != nil {
+		client.Transport = c.hostLimiter.Transport(client.Transport)
+	}
+	if c.circuitBreaker != nil {
+		client.Transport = c.circuitBreaker.Transport(client.Transport)
+	}
+
+	return client
+}
+
+// WithProbeMethod sets the HTTP method of the initial MCP probe
+//
+// The default is POST with an MCP initialize request. GET and HEAD send no body;
+// HEAD avoids side effects and payload while still receiving the 401 status and
+// WWW-Authenticate header. If a HEAD probe returns 405 Method Not Allowed, discovery
+// automatically retries with the default POST probe.
+func WithProbeMethod(method string) DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.probeMethod = strings.ToUpper(method)
+	}
+}
+
+// WithProbeHeaders adds headers to the initial MCP probe
+//
+// Use this for servers that only answer with their auth challenge when the request carries
+// MCP transport headers (e.g. Mcp-Protocol-Version or an Accept including text/event-stream).
+// Values replace the probe's defaults for the same header name. Repeated calls accumulate.
+func WithProbeHeaders(headers http.Header) DiscoveryOption {
+	return func(c *discoveryConfig) {
+		if c.probeHeaders == nil {
+			c.probeHeaders = http.Header{}
+		}
+		for name, values := range headers {
+			c.probeHeaders[http.CanonicalHeaderKey(name)] = slices.Clone(values)
+		}
+	}
+}
+
+// WithProbeBody sets the body of the initial MCP probe, replacing the default initialize request
+//
+// The body is sent with POST and GET probes (with Content-Type application/json unless set via
+// WithProbeHeaders); combining it with a HEAD probe is an error.
+func WithProbeBody(body []byte) DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.probeBody = slices.Clone(body)
+		if c.probeBody == nil {
+			c.probeBody = []byte{}
+		}
+	}
+}
+
+// WithInitialResponse uses an MCP server response the caller already received instead of probing
+//
+// When the caller's own HTTP stack got the 401 from the MCP server, passing it here saves a
+// round-trip: discovery inspects its status code and headers (WWW-Authenticate) directly.
+// Discovery takes ownership of the response and closes its body before returning.
+func WithInitialResponse(resp *http.Response) DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.initialResponse = resp
+	}
+}
+
+// WithEnforceHTTPS makes discovery fail when the authorization server metadata
+// advertises a plain http:// endpoint on a non-loopback host
+//
+// By default such endpoints are only logged as warnings (OAuth 2.1 Section 1.5 requires TLS)
+func WithEnforceHTTPS() DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.enforceHTTPS = true
+	}
+}
+
+// WithDNSOverHTTPS resolves hostnames during discovery through a DNS-over-HTTPS provider
+// (e.g. "https://cloudflare-dns.com/dns-query") instead of the system resolver
+//
+// Use this where standard DNS is blocked or internal auth server domains only resolve
+// via corporate DoH (RFC 8484). The provider's own hostname is resolved with the system
+// resolver, so use an IP literal in the provider URL if that is not possible.
+func WithDNSOverHTTPS(provider string) DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.dohProvider = provider
+	}
+}
+
+// WithStrictAudience makes discovery fail with ErrAudienceMismatch when the protected
+// resource metadata declares a resource that does not identify the probed MCP server
+//
+// By default a mismatch is only logged as a warning (RFC 9728 Section 3.3)
+func WithStrictAudience() DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.strictAudience = true
+	}
+}
+
+// WithLenientMode accepts non-standard ways servers signal OAuth requirements
+//
+// Currently this enables RFC 7807 problem details detection: a 400, 401 or 403 probe
+// response with an application/problem+json body that references OAuth is treated as
+// an auth challenge, and its resource_metadata, authorization_server(s) and scope
+// members are used as discovery hints.
+func WithLenientMode() DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.lenient = true
+	}
+}
+
+// WithHostLimiter throttles discovery requests through a per-host concurrency limit
+//
+// Pass the same HostLimiter to concurrent discoveries so that together they never have
+// more than its limit of requests in flight to any one MCP or authorization server host.
+func WithHostLimiter(limiter *HostLimiter) DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.hostLimiter = limiter
+	}
+}
+
+// WithCircuitBreaker fails discovery requests fast with ErrCircuitOpen while the target host's
+// circuit is open
+//
+// Pass the same CircuitBreaker to all discoveries (and token requests, see
+// WithTokenCircuitBreaker) so repeated failures of one server are shared.
+func WithCircuitBreaker(breaker *CircuitBreaker) DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.circuitBreaker = breaker
+	}
+}
+
+// WithInsecureSkipVerify disables TLS certificate verification for all discovery requests
+//
+// DEVELOPMENT ONLY: intended for local authorization servers with self-signed certificates.
+// It makes discovery vulnerable to man-in-the-middle attacks, so a warning is logged on
+// every discovery that uses it.
+func WithInsecureSkipVerify(skip bool) DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.insecureSkipVerify = skip
+	}
+}
+
+// WithEventEmitter reports discovery progress (probe, metadata fetches, outcome) to e
+//
+// Use this to collect metrics or traces; see DiscoveryEventEmitter
+func WithEventEmitter(e DiscoveryEventEmitter) DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.events = e
+	}
+}
+
+// WithStrictJSON rejects metadata documents that contain fields this library does not know
+//
+// DIAGNOSTIC MODE: unknown fields are logged as warnings and fail discovery, which helps
+// catch server bugs such as misspelled field names. Valid documents using extensions the
+// library does not model are rejected too, so keep the default (ignore unknown fields) in
+// production.
+func WithStrictJSON() DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.strictJSON = true
+	}
+}
+
+// WithStrictEndpointOrigin makes discovery fail with ErrEndpointOriginMismatch when the
+// authorization, token or registration endpoint is not on the issuer's origin (scheme and host)
+//
+// This stops a compromised resource server or metadata document from sending token requests
+// to a third party. By default a mismatch is only logged as a warning, since some providers
+// legitimately serve endpoints from another host; allow those with WithAllowedEndpointOrigins.
+func WithStrictEndpointOrigin() DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.strictOrigin = true
+	}
+}
+
+// WithAllowedEndpointOrigins accepts endpoints on the given origins (e.g. "https://login.example.com")
+// in addition to the issuer's origin
+func WithAllowedEndpointOrigins(origins ...string) DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.allowedOrigins = append(c.allowedOrigins, origins...)
+	}
+}
+
+// WithAuthorizationServerURL uses the given authorization server instead of discovering it
+//
+// Protected resource metadata (RFC 9728) is not fetched: after the probe, discovery goes
+// straight to the RFC 8414 metadata of this issuer. Use it for MCP servers that do not
+// serve /.well-known/oauth-protected-resource or advertise the wrong authorization server.
+// Scopes then come from the WWW-Authenticate challenge only, and the resource defaults to
+// the MCP server's origin.
+func WithAuthorizationServerURL(authServerURL string) DiscoveryOption {
+	return func(c *discoveryConfig) {
+		c.authServerURL = authServerURL
+	}
+}
@@ -1,13 +1,20 @@
 package oauth
 
 import (
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestDiscoveryFallback_NoWWWAuthenticate verifies the critical fallback behavior
@@ -97,6 +104,11 @@ func TestDiscoveryHappyPath_WithWWWAuthenticate(t *testing.T) {
 				AuthorizationEndpoint:         baseURL + "/authorize",
 				TokenEndpoint:                 baseURL + "/token",
 				CodeChallengeMethodsSupported: []string{"S256"},
+
+				AuthorizationDetailsTypesSupported: []string{"payment_initiation"},
+				AuthorizationResponseIssSupported:  true,
+				RequireSignedRequestObject:         true,
+				RequestObjectSigningAlgsSupported:  []string{"ES256"},
 			})
 			return
 		}
@@ -150,6 +162,18 @@ func TestDiscoveryHappyPath_WithWWWAuthenticate(t *testing.T) {
 	if len(discovery.Scopes) != 2 {
 		t.Errorf("Expected 2 scopes from metadata, got %d", len(discovery.Scopes))
 	}
+	if !discovery.SupportsRAR {
+		t.Error("Expected SupportsRAR=true from authorization_details_types_supported")
+	}
+	if !discovery.SupportsIssParameter {
+		t.Error("Expected SupportsIssParameter=true from authorization_response_iss_parameter_supported")
+	}
+	if !discovery.RequireRequestObject {
+		t.Error("Expected RequireRequestObject=true from require_signed_request_object")
+	}
+	if len(discovery.RequestObjectSigningAlgs) != 1 || discovery.RequestObjectSigningAlgs[0] != "ES256" {
+		t.Errorf("Expected RequestObjectSigningAlgs=[ES256], got %v", discovery.RequestObjectSigningAlgs)
+	}
 }
 
 // TestDiscoveryError_AuthServerFails verifies error handling
@@ -187,3 +211,1300 @@ func TestDiscoveryError_AuthServerFails(t *testing.T) {
 		t.Errorf("Expected auth server error, got: %v", err)
 	}
 }
+
+// TestDiscoveryFallback_PathSpecificWellKnown verifies that for a path-based MCP URL
+// the path-specific resource metadata URL is probed before the root one (RFC 9728 Section 3.1)
+func TestDiscoveryFallback_PathSpecificWellKnown(t *testing.T) {
+	var probed []string
+
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/tenant/mcp":
+			w.WriteHeader(http.StatusUnauthorized)
+		case strings.HasPrefix(r.URL.Path, "/.well-known/oauth-protected-resource"):
+			probed = append(probed, r.URL.Path)
+			// Only the root location is published
+			if r.URL.Path != "/.well-known/oauth-protected-resource" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(ProtectedResourceMetadata{
+				Resource:            serverURL + "/tenant/mcp",
+				AuthorizationServer: serverURL,
+			})
+		case r.URL.Path == "/.well-known/oauth-authorization-server":
+			_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+				Issuer:                serverURL,
+				AuthorizationEndpoint: serverURL + "/authorize",
+				TokenEndpoint:         serverURL + "/token",
+			})
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/tenant/mcp")
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	expected := []string{
+		"/.well-known/oauth-protected-resource/tenant/mcp",
+		"/.well-known/oauth-protected-resource",
+	}
+	if len(probed) != len(expected) {
+		t.Fatalf("Expected probe order %v, got %v", expected, probed)
+	}
+	for i := range expected {
+		if probed[i] != expected[i] {
+			t.Errorf("Probe %d: expected %s, got %s", i, expected[i], probed[i])
+		}
+	}
+
+	if discovery.ResourceURL != server.URL+"/tenant/mcp" {
+		t.Errorf("Expected ResourceURL from root metadata, got %s", discovery.ResourceURL)
+	}
+}
+
+// TestProtectedResourceMetadataURLs verifies well-known URL construction for root and path-based MCP URLs
+func TestProtectedResourceMetadataURLs(t *testing.T) {
+	tests := []struct {
+		name      string
+		serverURL string
+		expected  []string
+	}{
+		{
+			name:      "root URL",
+			serverURL: "https://example.com",
+			expected:  []string{"https://example.com/.well-known/oauth-protected-resource"},
+		},
+		{
+			name:      "root URL with trailing slash",
+			serverURL: "https://example.com/",
+			expected:  []string{"https://example.com/.well-known/oauth-protected-resource"},
+		},
+		{
+			name:      "path-based URL",
+			serverURL: "https://example.com/tenant/mcp",
+			expected: []string{
+				"https://example.com/.well-known/oauth-protected-resource/tenant/mcp",
+				"https://example.com/.well-known/oauth-protected-resource",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, _ := url.Parse(tt.serverURL)
+			urls := protectedResourceMetadataURLs(parsed)
+			if len(urls) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, urls)
+			}
+			for i := range tt.expected {
+				if urls[i] != tt.expected[i] {
+					t.Errorf("URL %d: expected %s, got %s", i, tt.expected[i], urls[i])
+				}
+			}
+		})
+	}
+}
+
+// TestDiscovery_BareBearerChallenge verifies that a bare "WWW-Authenticate: Bearer" header
+// is treated as OAuth required and triggers the well-known fallback
+func TestDiscovery_BareBearerChallenge(t *testing.T) {
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-protected-resource":
+			_ = json.NewEncoder(w).Encode(ProtectedResourceMetadata{
+				Resource:            serverURL,
+				AuthorizationServer: serverURL,
+			})
+		case "/.well-known/oauth-authorization-server":
+			_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+				Issuer:                serverURL,
+				AuthorizationEndpoint: serverURL + "/authorize",
+				TokenEndpoint:         serverURL + "/token",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	logger := &testLogger{}
+	ctx := WithLogger(context.Background(), logger)
+
+	discovery, err := DiscoverOAuthRequirements(ctx, server.URL+"/mcp")
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	if !logger.containsInfo("parsed 1 WWW-Authenticate challenge(s)") {
+		t.Error("Expected bare Bearer challenge to be parsed")
+	}
+	if !logger.containsInfo("fallback: trying well-known") {
+		t.Error("Expected fallback to well-known endpoint to be triggered")
+	}
+	if !discovery.RequiresOAuth {
+		t.Error("Expected RequiresOAuth=true")
+	}
+	if discovery.TokenEndpoint != server.URL+"/token" {
+		t.Errorf("Expected TokenEndpoint=%s, got %s", server.URL+"/token", discovery.TokenEndpoint)
+	}
+}
+
+// TestDiscoveryMerge_TokenEndpointOverride verifies that a non-empty override replaces
+// the discovered value while other fields are preserved
+func TestDiscoveryMerge_TokenEndpointOverride(t *testing.T) {
+	discovered := &Discovery{
+		RequiresOAuth:         true,
+		AuthorizationEndpoint: "https://auth.example.com/authorize",
+		TokenEndpoint:         "https://auth.example.com/token",
+		Scopes:                []string{"read"},
+		SupportsPKCE:          true,
+	}
+
+	merged, err := discovered.Merge(&Discovery{TokenEndpoint: "https://auth.internal.example.com/token"})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if merged.TokenEndpoint != "https://auth.internal.example.com/token" {
+		t.Errorf("Expected overridden TokenEndpoint, got %s", merged.TokenEndpoint)
+	}
+	if merged.AuthorizationEndpoint != discovered.AuthorizationEndpoint {
+		t.Errorf("Expected AuthorizationEndpoint to be preserved, got %s", merged.AuthorizationEndpoint)
+	}
+	if !merged.RequiresOAuth || !merged.SupportsPKCE {
+		t.Error("Expected boolean fields to be preserved")
+	}
+	if len(merged.Scopes) != 1 || merged.Scopes[0] != "read" {
+		t.Errorf("Expected Scopes to be preserved, got %v", merged.Scopes)
+	}
+
+	// The original discovery must not be modified
+	if discovered.TokenEndpoint != "https://auth.example.com/token" {
+		t.Errorf("Merge modified the original discovery: %s", discovered.TokenEndpoint)
+	}
+}
+
+// TestDiscoveryMerge_InvalidOverride verifies that an invalid override URL is rejected
+func TestDiscoveryMerge_InvalidOverride(t *testing.T) {
+	discovered := &Discovery{TokenEndpoint: "https://auth.example.com/token"}
+
+	tests := []struct {
+		name     string
+		override *Discovery
+	}{
+		{
+			name:     "relative URL",
+			override: &Discovery{TokenEndpoint: "/token"},
+		},
+		{
+			name:     "unsupported scheme",
+			override: &Discovery{TokenEndpoint: "ftp://auth.example.com/token"},
+		},
+		{
+			name:     "unparseable URL",
+			override: &Discovery{AuthorizationEndpoint: "https://auth example.com/%zz"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := discovered.Merge(tt.override)
+			if err == nil {
+				t.Fatal("Expected error for invalid override")
+			}
+			if merged != nil {
+				t.Error("Expected nil discovery on error")
+			}
+		})
+	}
+}
+
+// newMockOAuthServer starts a server that acts as MCP server, resource metadata server
+// and authorization server at once; requests to /mcp are delegated to mcpHandler
+func newMockOAuthServer(t *testing.T, mcpHandler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			mcpHandler(w, r)
+		case "/.well-known/oauth-protected-resource":
+			_ = json.NewEncoder(w).Encode(ProtectedResourceMetadata{
+				Resource:            server.URL + "/mcp",
+				AuthorizationServer: server.URL,
+			})
+		case "/.well-known/oauth-authorization-server":
+			_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+				Issuer:                        server.URL,
+				AuthorizationEndpoint:         server.URL + "/authorize",
+				TokenEndpoint:                 server.URL + "/token",
+				RegistrationEndpoint:          server.URL + "/register",
+				CodeChallengeMethodsSupported: []string{"S256"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestDiscoveryProbeMethod_Head verifies a HEAD probe is used when configured
+func TestDiscoveryProbeMethod_Head(t *testing.T) {
+	var methods []string
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithProbeMethod("head"))
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	if len(methods) != 1 || methods[0] != http.MethodHead {
+		t.Errorf("Expected a single HEAD probe, got %v", methods)
+	}
+	if !discovery.RequiresOAuth {
+		t.Error("Expected RequiresOAuth=true")
+	}
+}
+
+// TestDiscoveryProbeMethod_HeadNotAllowed verifies a 405 HEAD response is retried with the default probe
+func TestDiscoveryProbeMethod_HeadNotAllowed(t *testing.T) {
+	var methods []string
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	if _, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithProbeMethod(http.MethodHead)); err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	expected := []string{http.MethodHead, http.MethodPost}
+	if len(methods) != len(expected) || methods[0] != expected[0] || methods[1] != expected[1] {
+		t.Errorf("Expected probes %v, got %v", expected, methods)
+	}
+}
+
+// TestDiscoveryProbeMethod_Invalid verifies unsupported probe methods are rejected
+func TestDiscoveryProbeMethod_Invalid(t *testing.T) {
+	if _, err := DiscoverOAuthRequirements(context.Background(), "https://example.com/mcp", WithProbeMethod("DELETE")); err == nil {
+		t.Error("Expected error for unsupported probe method")
+	}
+}
+
+// TestDiscoveryProbeBody verifies a custom probe body and headers are sent, for servers that
+// only challenge requests that look like real MCP traffic
+func TestDiscoveryProbeBody(t *testing.T) {
+	const payload = `{"jsonrpc":"2.0","method":"initialize","id":"probe"}`
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || string(body) != payload ||
+			r.Header.Get("Mcp-Protocol-Version") != "2025-06-18" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp",
+		WithProbeBody([]byte(payload)),
+		WithProbeHeaders(http.Header{"mcp-protocol-version": {"2025-06-18"}}))
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	if !discovery.RequiresOAuth {
+		t.Error("Expected RequiresOAuth=true when the custom probe elicits the 401")
+	}
+
+	// The default initialize request does not trigger the challenge on this server
+	discovery, err = DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp")
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	if discovery.RequiresOAuth {
+		t.Error("Expected RequiresOAuth=false for the default probe")
+	}
+}
+
+// TestDiscoveryProbeBody_Head verifies a probe body cannot be combined with HEAD
+func TestDiscoveryProbeBody_Head(t *testing.T) {
+	_, err := DiscoverOAuthRequirements(context.Background(), "https://example.com/mcp",
+		WithProbeMethod(http.MethodHead), WithProbeBody([]byte("{}")))
+	if err == nil {
+		t.Error("Expected error for a HEAD probe with a body")
+	}
+}
+
+// trackingBody records whether the response body was closed
+type trackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+// TestDiscoveryWithInitialResponse verifies a caller-supplied 401 response is used instead of probing
+// and that its body is closed before discovery returns
+func TestDiscoveryWithInitialResponse(t *testing.T) {
+	probed := false
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		probed = true
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	body := &trackingBody{Reader: strings.NewReader("unauthorized")}
+	resp := &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header: http.Header{
+			"Www-Authenticate": []string{fmt.Sprintf(`Bearer resource_metadata="%s/.well-known/oauth-protected-resource"`, server.URL)},
+		},
+		Body: body,
+	}
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithInitialResponse(resp))
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	if probed {
+		t.Error("Expected no probe request when an initial response is supplied")
+	}
+	if !body.closed {
+		t.Error("Expected initial response body to be closed")
+	}
+	if discovery.TokenEndpoint != server.URL+"/token" {
+		t.Errorf("Expected TokenEndpoint=%s, got %s", server.URL+"/token", discovery.TokenEndpoint)
+	}
+}
+
+// TestDiscoveryWithInitialResponse_InvalidOptions verifies the initial response body is closed
+// when the other options or the server URL are rejected
+func TestDiscoveryWithInitialResponse_InvalidOptions(t *testing.T) {
+	body := &trackingBody{Reader: strings.NewReader("unauthorized")}
+	resp := &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{}, Body: body}
+
+	_, err := DiscoverOAuthRequirements(context.Background(), "https://example.com/mcp",
+		WithInitialResponse(resp), WithProbeMethod("DELETE"))
+	if err == nil {
+		t.Fatal("Expected error for unsupported probe method")
+	}
+	if !body.closed {
+		t.Error("Expected initial response body to be closed")
+	}
+
+	// Invalid server URL
+	body = &trackingBody{Reader: strings.NewReader("unauthorized")}
+	resp = &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{}, Body: body}
+	if _, err := DiscoverOAuthRequirements(context.Background(), "http://[::1/mcp", WithInitialResponse(resp)); err == nil {
+		t.Fatal("Expected error for invalid server URL")
+	}
+	if !body.closed {
+		t.Error("Expected initial response body to be closed for an invalid server URL")
+	}
+}
+
+// TestDiscovery_MultipleResourceMetadataURLs verifies each resource_metadata candidate is tried in order
+func TestDiscovery_MultipleResourceMetadataURLs(t *testing.T) {
+	var fetched []string
+	var server *httptest.Server
+	server = newMockOAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("rm") {
+		case "broken":
+			fetched = append(fetched, "broken")
+			w.WriteHeader(http.StatusNotFound)
+		case "working":
+			fetched = append(fetched, "working")
+			_ = json.NewEncoder(w).Encode(ProtectedResourceMetadata{
+				Resource:            server.URL + "/mcp",
+				AuthorizationServer: server.URL,
+				Scopes:              []string{"tools"},
+			})
+		default:
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="one", resource_metadata="%[1]s/mcp?rm=broken", Bearer realm="two", resource_metadata="%[1]s/mcp?rm=working"`,
+				server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp")
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	if len(fetched) != 2 || fetched[0] != "broken" || fetched[1] != "working" {
+		t.Errorf("Expected candidates fetched in order [broken working], got %v", fetched)
+	}
+	if len(discovery.Scopes) != 1 || discovery.Scopes[0] != "tools" {
+		t.Errorf("Expected scopes from the second candidate, got %v", discovery.Scopes)
+	}
+}
+
+// TestInsecureEndpoints verifies detection of non-TLS, non-loopback endpoints
+func TestInsecureEndpoints(t *testing.T) {
+	metadata := &AuthorizationServerMetadata{
+		Issuer:                "https://auth.example.com",
+		AuthorizationEndpoint: "https://auth.example.com/authorize",
+		TokenEndpoint:         "http://auth.example.com/token",
+		RegistrationEndpoint:  "http://localhost:8080/register",
+		JWKSUri:               "http://127.0.0.1:8080/jwks",
+	}
+
+	insecure := insecureEndpoints(metadata)
+	if len(insecure) != 1 || insecure[0] != "token_endpoint=http://auth.example.com/token" {
+		t.Errorf("Expected only the non-loopback http token endpoint, got %v", insecure)
+	}
+}
+
+// TestDiscoveryEnforceHTTPS verifies warn-by-default and fail-in-strict-mode behavior
+func TestDiscoveryEnforceHTTPS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-authorization-server":
+			baseURL := "http://" + r.Host
+			_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+				Issuer:                baseURL,
+				AuthorizationEndpoint: baseURL + "/authorize",
+				TokenEndpoint:         "http://auth.example.com/token", // Non-loopback plain HTTP
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	logger := &testLogger{}
+	ctx := WithLogger(context.Background(), logger)
+
+	// Default: warning only
+	if _, err := DiscoverOAuthRequirements(ctx, server.URL+"/mcp"); err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	if !logger.containsWarn("does not use HTTPS: token_endpoint=http://auth.example.com/token") {
+		t.Errorf("Expected warning about non-TLS token endpoint, got %v", logger.warns)
+	}
+
+	// Strict: error
+	_, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithEnforceHTTPS())
+	if err == nil {
+		t.Fatal("Expected error with WithEnforceHTTPS")
+	}
+	if !strings.Contains(err.Error(), "non-TLS endpoint token_endpoint") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// TestResourceMatchesServer verifies comparison of resource identifiers against the MCP server URL
+func TestResourceMatchesServer(t *testing.T) {
+	serverURL, _ := url.Parse("https://api.example.com/mcp")
+
+	tests := []struct {
+		resource string
+		expected bool
+	}{
+		{"https://api.example.com/mcp", true},
+		{"https://API.example.com:443/mcp/", true},
+		{"https://api.example.com", true},
+		{"https://api.example.com/other", false},
+		{"https://api.example.com/mc", false},
+		{"https://evil.example.com/mcp", false},
+		{"http://api.example.com/mcp", false},
+		{"https://api.example.com:8443/mcp", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			if got := resourceMatchesServer(tt.resource, serverURL); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestDiscoveryAudienceMismatch verifies warn-by-default and fail-in-strict-mode behavior
+// when the resource metadata identifies a different resource than the probed server
+func TestDiscoveryAudienceMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		baseURL := "http://" + r.Host
+		switch r.URL.Path {
+		case "/mcp":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-protected-resource":
+			_ = json.NewEncoder(w).Encode(ProtectedResourceMetadata{
+				Resource:            "https://other.example.com/mcp",
+				AuthorizationServer: baseURL,
+			})
+		case "/.well-known/oauth-authorization-server":
+			_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+				Issuer:                baseURL,
+				AuthorizationEndpoint: baseURL + "/authorize",
+				TokenEndpoint:         baseURL + "/token",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	logger := &testLogger{}
+	ctx := WithLogger(context.Background(), logger)
+
+	// Default: warning only
+	if _, err := DiscoverOAuthRequirements(ctx, server.URL+"/mcp"); err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	if !logger.containsWarn("does not match server") {
+		t.Errorf("Expected audience mismatch warning, got %v", logger.warns)
+	}
+
+	// Strict: error
+	_, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithStrictAudience())
+	if !errors.Is(err, ErrAudienceMismatch) {
+		t.Fatalf("Expected ErrAudienceMismatch, got %v", err)
+	}
+
+	// Matching resource passes strict mode
+	matching := newMockOAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	if _, err := DiscoverOAuthRequirements(context.Background(), matching.URL+"/mcp", WithStrictAudience()); err != nil {
+		t.Errorf("Expected matching resource to pass strict mode, got %v", err)
+	}
+}
+
+// TestDiscovery_CompressedMetadata verifies that gzip and deflate encoded metadata documents are decoded
+func TestDiscovery_CompressedMetadata(t *testing.T) {
+	compress := map[string]func(io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+	}
+
+	for encoding, newWriter := range compress {
+		t.Run(encoding, func(t *testing.T) {
+			var acceptEncoding string
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var metadata any
+				switch r.URL.Path {
+				case "/mcp":
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				case "/.well-known/oauth-protected-resource":
+					metadata = ProtectedResourceMetadata{
+						Resource:            server.URL + "/mcp",
+						AuthorizationServer: server.URL,
+					}
+				case "/.well-known/oauth-authorization-server":
+					acceptEncoding = r.Header.Get("Accept-Encoding")
+					metadata = AuthorizationServerMetadata{
+						Issuer:                server.URL,
+						AuthorizationEndpoint: server.URL + "/authorize",
+						TokenEndpoint:         server.URL + "/token",
+					}
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", encoding)
+				zw := newWriter(w)
+				_ = json.NewEncoder(zw).Encode(metadata)
+				_ = zw.Close()
+			}))
+			defer server.Close()
+
+			discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp")
+			if err != nil {
+				t.Fatalf("Discovery failed: %v", err)
+			}
+
+			if discovery.TokenEndpoint != server.URL+"/token" {
+				t.Errorf("Expected TokenEndpoint=%s/token, got %s", server.URL, discovery.TokenEndpoint)
+			}
+			if discovery.ResourceURL != server.URL+"/mcp" {
+				t.Errorf("Expected ResourceURL=%s/mcp, got %s", server.URL, discovery.ResourceURL)
+			}
+			if !strings.Contains(acceptEncoding, "gzip") {
+				t.Errorf("Expected transport to advertise gzip, got Accept-Encoding=%q", acceptEncoding)
+			}
+		})
+	}
+}
+
+// TestDiscovery_NoOAuthRequired verifies a 2xx probe response yields RequiresOAuth=false without error
+func TestDiscovery_NoOAuthRequired(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusAccepted} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var metadataRequests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/mcp" {
+					w.WriteHeader(status)
+					return
+				}
+				metadataRequests++
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if discovery == nil {
+				t.Fatal("Expected non-nil discovery")
+			}
+			if discovery.RequiresOAuth {
+				t.Error("Expected RequiresOAuth=false")
+			}
+			if metadataRequests != 0 {
+				t.Errorf("Expected no metadata requests, got %d", metadataRequests)
+			}
+		})
+	}
+}
+
+// TestDiscoveryLenient_ProblemJSON verifies OAuth hints are taken from a 400 problem+json probe
+// response in lenient mode, and ignored otherwise
+func TestDiscoveryLenient_ProblemJSON(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"type":              "https://example.com/problems/oauth-required",
+				"title":             "OAuth authorization required",
+				"status":            400,
+				"resource_metadata": server.URL + "/metadata/prm",
+				"scope":             "mcp.read",
+			})
+		case "/metadata/prm":
+			_ = json.NewEncoder(w).Encode(ProtectedResourceMetadata{
+				Resource:            server.URL + "/mcp",
+				AuthorizationServer: server.URL + "/tenant",
+			})
+		case "/tenant/.well-known/oauth-authorization-server":
+			_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+				Issuer:                server.URL + "/tenant",
+				AuthorizationEndpoint: server.URL + "/tenant/authorize",
+				TokenEndpoint:         server.URL + "/tenant/token",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithLenientMode())
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	if discovery.AuthorizationServer != server.URL+"/tenant" {
+		t.Errorf("Expected auth server from problem hint metadata, got %s", discovery.AuthorizationServer)
+	}
+	if len(discovery.Scopes) != 1 || discovery.Scopes[0] != "mcp.read" {
+		t.Errorf("Expected scopes [mcp.read] from problem hint, got %v", discovery.Scopes)
+	}
+
+	// Without lenient mode the problem body is ignored and the well-known fallback finds nothing
+	if _, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp"); err == nil {
+		t.Error("Expected discovery to fail without lenient mode")
+	}
+}
+
+// TestParseAuthProblem verifies which problem+json responses are treated as auth challenges
+func TestParseAuthProblem(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		expectFound bool
+	}{
+		{"auth title", http.StatusBadRequest, "application/problem+json", `{"title":"Authorization required"}`, true},
+		{"hint member", http.StatusForbidden, "application/problem+json; charset=utf-8", `{"title":"Forbidden","authorization_server":"https://auth.example.com"}`, true},
+		{"unrelated problem", http.StatusBadRequest, "application/problem+json", `{"title":"Invalid JSON-RPC request"}`, false},
+		{"plain json", http.StatusBadRequest, "application/json", `{"title":"Authorization required"}`, false},
+		{"server error", http.StatusInternalServerError, "application/problem+json", `{"title":"Authorization required"}`, false},
+		{"malformed body", http.StatusBadRequest, "application/problem+json", `{"title":`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{"Content-Type": {tt.contentType}},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if found := parseAuthProblem(resp) != nil; found != tt.expectFound {
+				t.Errorf("Expected found=%v, got %v", tt.expectFound, found)
+			}
+		})
+	}
+}
+
+// TestDiscovery_ResponseHeaders verifies the probe response headers are exposed on the result
+func TestDiscovery_ResponseHeaders(t *testing.T) {
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("DPoP-Nonce", "nonce-abc")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp")
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	if got := discovery.ResponseHeaders.Get("DPoP-Nonce"); got != "nonce-abc" {
+		t.Errorf("Expected DPoP-Nonce=nonce-abc, got %q", got)
+	}
+	if got := discovery.ResponseHeaders.Get("WWW-Authenticate"); got != `Bearer realm="mcp"` {
+		t.Errorf("Expected WWW-Authenticate to be preserved, got %q", got)
+	}
+}
+
+// TestDiscovery_Timings verifies stage durations and attempt counts are recorded on the result
+func TestDiscovery_Timings(t *testing.T) {
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithProbeMethod(http.MethodHead))
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	timings := discovery.Timings
+	if timings.Probe <= 0 || timings.ResourceMetadata <= 0 || timings.AuthServerMetadata <= 0 {
+		t.Errorf("Expected non-zero stage durations, got %+v", timings)
+	}
+	if timings.Total < timings.Probe+timings.ResourceMetadata+timings.AuthServerMetadata {
+		t.Errorf("Expected total to cover all stages, got %+v", timings)
+	}
+	// HEAD is retried with POST; the path-specific well-known URL 404s before the root one
+	if timings.ProbeAttempts != 2 || timings.ResourceMetadataAttempts != 2 || timings.AuthServerMetadataAttempts != 1 {
+		t.Errorf("Unexpected attempt counts: %+v", timings)
+	}
+}
+
+// TestDiscoveryInsecureSkipVerify verifies self-signed TLS servers are rejected by default
+// and accepted (with a warning) when verification is disabled
+func TestDiscoveryInsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		baseURL := "https://" + r.Host
+		switch r.URL.Path {
+		case "/mcp":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-authorization-server":
+			_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+				Issuer:                baseURL,
+				AuthorizationEndpoint: baseURL + "/authorize",
+				TokenEndpoint:         baseURL + "/token",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Default: self-signed certificate is rejected
+	if _, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp"); err == nil {
+		t.Fatal("Expected TLS verification error for self-signed certificate")
+	}
+
+	logger := &testLogger{}
+	ctx := WithLogger(context.Background(), logger)
+
+	discovery, err := DiscoverOAuthRequirements(ctx, server.URL+"/mcp", WithInsecureSkipVerify(true))
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	if discovery.TokenEndpoint != server.URL+"/token" {
+		t.Errorf("Expected TokenEndpoint=%s/token, got %s", server.URL, discovery.TokenEndpoint)
+	}
+	if !logger.containsWarn("TLS certificate verification is DISABLED") {
+		t.Errorf("Expected insecure TLS warning, got %v", logger.warns)
+	}
+}
+
+// TestDiscoveryFallback_ScopesSupported verifies that without any WWW-Authenticate challenge
+// the RFC 9728 scopes_supported list from resource metadata populates Discovery.Scopes
+func TestDiscoveryFallback_ScopesSupported(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.WriteHeader(http.StatusUnauthorized) // No WWW-Authenticate (Neon behavior)
+		case "/.well-known/oauth-protected-resource":
+			fmt.Fprintf(w, `{"resource":%q,"authorization_servers":[%q],"scopes_supported":["read","write"]}`,
+				server.URL+"/mcp", server.URL)
+		case "/.well-known/oauth-authorization-server":
+			_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+				Issuer:                server.URL,
+				AuthorizationEndpoint: server.URL + "/authorize",
+				TokenEndpoint:         server.URL + "/token",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp")
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	if len(discovery.Scopes) != 2 || discovery.Scopes[0] != "read" || discovery.Scopes[1] != "write" {
+		t.Errorf("Expected scopes [read write] from scopes_supported, got %v", discovery.Scopes)
+	}
+}
+
+// TestDiscoveryStrictJSON verifies unknown metadata fields are ignored by default and
+// rejected (with a warning) in strict mode
+func TestDiscoveryStrictJSON(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-authorization-server":
+			// "token_endpiont" is a server-side typo of an optional extra field
+			fmt.Fprintf(w, `{"issuer":%q,"authorization_endpoint":%q,"token_endpoint":%q,"token_endpiont":"oops"}`,
+				server.URL, server.URL+"/authorize", server.URL+"/token")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Default: unknown field is ignored
+	if _, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp"); err != nil {
+		t.Fatalf("Expected lenient decoding to succeed, got %v", err)
+	}
+
+	logger := &testLogger{}
+	ctx := WithLogger(context.Background(), logger)
+
+	_, err := DiscoverOAuthRequirements(ctx, server.URL+"/mcp", WithStrictJSON())
+	if err == nil || !strings.Contains(err.Error(), `unknown field "token_endpiont"`) {
+		t.Fatalf("Expected unknown field error in strict mode, got %v", err)
+	}
+	if !logger.containsWarn("strict JSON decoding") {
+		t.Errorf("Expected strict decoding warning, got %v", logger.warns)
+	}
+}
+
+// TestDiscovery_IssuerQueryOrFragment verifies issuer identifiers with query or fragment
+// components are rejected (RFC 8414 Section 2)
+func TestDiscovery_IssuerQueryOrFragment(t *testing.T) {
+	tests := []struct {
+		name          string
+		authServer    string // Appended to the server URL in the resource metadata
+		issuerSuffix  string // Appended to the server URL in the authorization server metadata
+		expectedError string
+	}{
+		{
+			name:          "authorization server with query string",
+			authServer:    "?tenant=a",
+			expectedError: "must not contain a query component",
+		},
+		{
+			name:          "authorization server with fragment",
+			authServer:    "#tenant",
+			expectedError: "must not contain a fragment component",
+		},
+		{
+			name:          "metadata issuer with query string",
+			issuerSuffix:  "?tenant=a",
+			expectedError: "must not contain a query component",
+		},
+		{
+			name:          "metadata issuer with fragment",
+			issuerSuffix:  "#tenant",
+			expectedError: "must not contain a fragment component",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/mcp":
+					w.WriteHeader(http.StatusUnauthorized)
+				case "/.well-known/oauth-protected-resource":
+					_ = json.NewEncoder(w).Encode(ProtectedResourceMetadata{
+						Resource:            server.URL + "/mcp",
+						AuthorizationServer: server.URL + tt.authServer,
+					})
+				case "/.well-known/oauth-authorization-server":
+					_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+						Issuer:                server.URL + tt.issuerSuffix,
+						AuthorizationEndpoint: server.URL + "/authorize",
+						TokenEndpoint:         server.URL + "/token",
+					})
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			_, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp")
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+// TestDiscovery_ContextCancellation verifies that canceling the context aborts an in-flight
+// probe with context.Canceled instead of waiting for the HTTP client timeout
+func TestDiscovery_ContextCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Slow server: answers after 5 seconds unless the client goes away first
+		// (the body must be consumed for the server to notice the closed connection)
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(http.StatusUnauthorized)
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := DiscoverOAuthRequirements(ctx, server.URL+"/mcp")
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Expected discovery to return promptly after cancellation, took %v", elapsed)
+	}
+}
+
+// TestDiscoveryError_PartialDiscovery verifies the data discovered before a failed
+// authorization server metadata fetch is available on the DiscoveryError
+func TestDiscoveryError_PartialDiscovery(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.Header().Set("WWW-Authenticate", `Bearer scope="read write"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-protected-resource":
+			_ = json.NewEncoder(w).Encode(ProtectedResourceMetadata{
+				Resource:            server.URL + "/mcp",
+				AuthorizationServer: server.URL + "/tenant",
+			})
+		default:
+			w.WriteHeader(http.StatusInternalServerError) // Authorization server metadata is broken
+		}
+	}))
+	defer server.Close()
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp")
+	if discovery != nil {
+		t.Error("Expected nil discovery on error")
+	}
+
+	var discoveryErr *DiscoveryError
+	if !errors.As(err, &discoveryErr) {
+		t.Fatalf("Expected *DiscoveryError, got %T: %v", err, err)
+	}
+	if discoveryErr.Stage != DiscoveryStageAuthServerMetadata {
+		t.Errorf("Expected stage %s, got %s", DiscoveryStageAuthServerMetadata, discoveryErr.Stage)
+	}
+	var fetchErr *MetadataFetchError
+	if !errors.As(err, &fetchErr) || fetchErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected the underlying MetadataFetchError to be preserved, got %v", err)
+	}
+
+	partial := discoveryErr.Partial
+	if !partial.RequiresOAuth {
+		t.Error("Expected RequiresOAuth=true on partial discovery")
+	}
+	if partial.ResourceURL != server.URL+"/mcp" {
+		t.Errorf("Expected ResourceURL=%s/mcp, got %s", server.URL, partial.ResourceURL)
+	}
+	if partial.AuthorizationServer != server.URL+"/tenant" {
+		t.Errorf("Expected AuthorizationServer=%s/tenant, got %s", server.URL, partial.AuthorizationServer)
+	}
+	if len(partial.Scopes) != 2 {
+		t.Errorf("Expected scopes from WWW-Authenticate, got %v", partial.Scopes)
+	}
+	if partial.TokenEndpoint != "" {
+		t.Errorf("Expected no token endpoint, got %s", partial.TokenEndpoint)
+	}
+}
+
+// TestDiscovery_BearerMethodsSupported verifies bearer_methods_supported is surfaced from resource metadata
+func TestDiscovery_BearerMethodsSupported(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer resource_metadata="%s/.well-known/oauth-protected-resource"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-protected-resource":
+			fmt.Fprintf(w, `{"resource":%q,"authorization_servers":[%q],"bearer_methods_supported":["query"]}`, server.URL+"/mcp", server.URL)
+		case "/.well-known/oauth-authorization-server":
+			_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+				Issuer:                server.URL,
+				AuthorizationEndpoint: server.URL + "/authorize",
+				TokenEndpoint:         server.URL + "/token",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp")
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	if !slices.Equal(discovery.BearerMethods, []string{"query"}) {
+		t.Errorf("Expected bearer methods [query], got %v", discovery.BearerMethods)
+	}
+	if discovery.BearerMethod() != BearerMethodQuery {
+		t.Errorf("Expected query bearer method, got %q", discovery.BearerMethod())
+	}
+}
+
+// TestDiscovery_BearerMethod verifies the header default and the body-over-query preference
+func TestDiscovery_BearerMethod(t *testing.T) {
+	tests := []struct {
+		methods  []string
+		expected string
+	}{
+		{nil, BearerMethodHeader},
+		{[]string{"header", "query"}, BearerMethodHeader},
+		{[]string{"query", "body"}, BearerMethodBody},
+		{[]string{"query"}, BearerMethodQuery},
+		{[]string{"dpop"}, BearerMethodHeader},
+	}
+
+	for _, tt := range tests {
+		discovery := &Discovery{BearerMethods: tt.methods}
+		if got := discovery.BearerMethod(); got != tt.expected {
+			t.Errorf("BearerMethod() for %v: expected %q, got %q", tt.methods, tt.expected, got)
+		}
+	}
+}
+
+// TestDiscovery_EndpointOrigin verifies endpoints must share the issuer origin in strict mode
+func TestDiscovery_EndpointOrigin(t *testing.T) {
+	newServer := func(tokenEndpoint string) *httptest.Server {
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/mcp":
+				w.WriteHeader(http.StatusUnauthorized)
+			case "/.well-known/oauth-authorization-server":
+				endpoint := tokenEndpoint
+				if endpoint == "" {
+					endpoint = server.URL + "/token"
+				}
+				_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+					Issuer:                server.URL,
+					AuthorizationEndpoint: server.URL + "/authorize",
+					TokenEndpoint:         endpoint,
+				})
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	t.Run("same origin", func(t *testing.T) {
+		server := newServer("")
+		discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithStrictEndpointOrigin())
+		if err != nil {
+			t.Fatalf("Discovery failed: %v", err)
+		}
+		if discovery.TokenEndpoint != server.URL+"/token" {
+			t.Errorf("Unexpected token endpoint %q", discovery.TokenEndpoint)
+		}
+	})
+
+	t.Run("cross-origin token endpoint rejected in strict mode", func(t *testing.T) {
+		server := newServer("https://attacker.example.com/token")
+		_, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithStrictEndpointOrigin())
+		if !errors.Is(err, ErrEndpointOriginMismatch) {
+			t.Fatalf("Expected ErrEndpointOriginMismatch, got %v", err)
+		}
+		var discoveryErr *DiscoveryError
+		if !errors.As(err, &discoveryErr) || discoveryErr.Stage != DiscoveryStageEndpointOrigin {
+			t.Errorf("Expected DiscoveryError at stage %q, got %v", DiscoveryStageEndpointOrigin, err)
+		}
+		if !strings.Contains(err.Error(), "token_endpoint=https://attacker.example.com/token") {
+			t.Errorf("Expected error to name the endpoint, got %v", err)
+		}
+	})
+
+	t.Run("cross-origin token endpoint warned by default", func(t *testing.T) {
+		server := newServer("https://attacker.example.com/token")
+		logger := &testLogger{}
+		if _, err := DiscoverOAuthRequirements(WithLogger(context.Background(), logger), server.URL+"/mcp"); err != nil {
+			t.Fatalf("Discovery failed: %v", err)
+		}
+		if !logger.containsWarn("not on the issuer origin") {
+			t.Errorf("Expected origin warning, got %v", logger.warns)
+		}
+	})
+
+	t.Run("allowlisted origin", func(t *testing.T) {
+		server := newServer("https://LOGIN.example.com:443/token")
+		_, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp",
+			WithStrictEndpointOrigin(), WithAllowedEndpointOrigins("https://login.example.com"))
+		if err != nil {
+			t.Fatalf("Expected allowlisted origin to pass, got %v", err)
+		}
+	})
+
+	t.Run("invalid allowlist entry", func(t *testing.T) {
+		if _, err := DiscoverOAuthRequirements(context.Background(), "https://mcp.example.com", WithAllowedEndpointOrigins("login.example.com")); err == nil {
+			t.Error("Expected error for allowlist entry without scheme")
+		}
+	})
+}
+
+// TestDiscovery_WithAuthorizationServerURL verifies a caller-supplied authorization server
+// skips protected resource metadata and is used for RFC 8414 discovery
+func TestDiscovery_WithAuthorizationServerURL(t *testing.T) {
+	authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/oauth-authorization-server" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+			Issuer:                "http://" + r.Host,
+			AuthorizationEndpoint: "http://" + r.Host + "/authorize",
+			TokenEndpoint:         "http://" + r.Host + "/token",
+		})
+	}))
+	defer authServer.Close()
+
+	var resourceMetadataFetched bool
+	mcpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.Header().Set("WWW-Authenticate", `Bearer resource_metadata="http://`+r.Host+`/.well-known/oauth-protected-resource", scope="read"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-protected-resource":
+			resourceMetadataFetched = true
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mcpServer.Close()
+
+	discovery, err := DiscoverOAuthRequirements(context.Background(), mcpServer.URL+"/mcp", WithAuthorizationServerURL(authServer.URL))
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	if resourceMetadataFetched {
+		t.Error("Expected protected resource metadata to be skipped")
+	}
+	if discovery.AuthorizationServer != authServer.URL {
+		t.Errorf("Expected authorization server %q, got %q", authServer.URL, discovery.AuthorizationServer)
+	}
+	if discovery.TokenEndpoint != authServer.URL+"/token" {
+		t.Errorf("Expected token endpoint from supplied server, got %q", discovery.TokenEndpoint)
+	}
+	if !slices.Equal(discovery.Scopes, []string{"read"}) {
+		t.Errorf("Expected scopes from WWW-Authenticate, got %v", discovery.Scopes)
+	}
+
+	for _, invalid := range []string{"auth.example.com", "ftp://auth.example.com", "https://auth.example.com?tenant=1"} {
+		if _, err := DiscoverOAuthRequirements(context.Background(), mcpServer.URL+"/mcp", WithAuthorizationServerURL(invalid)); err == nil {
+			t.Errorf("Expected error for authorization server URL %q", invalid)
+		}
+	}
+}
+
+// TestDiscoverFromResponse verifies discovery from a caller's own MCP response, using the
+// request URL when no base URL is given, without probing the MCP server again
+func TestDiscoverFromResponse(t *testing.T) {
+	var probes int
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		probes++
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s/.well-known/oauth-protected-resource>; rel="oauth-protected-resource"`, r.Host))
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+"/mcp", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	discovery, err := DiscoverFromResponse(context.Background(), resp, "")
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	if probes != 1 {
+		t.Errorf("Expected only the caller's request to reach the MCP endpoint, got %d", probes)
+	}
+	if !discovery.RequiresOAuth {
+		t.Error("Expected RequiresOAuth=true")
+	}
+	if discovery.TokenEndpoint != server.URL+"/token" {
+		t.Errorf("Expected TokenEndpoint=%s, got %s", server.URL+"/token", discovery.TokenEndpoint)
+	}
+
+	ok := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}
+	discovery, err = DiscoverFromResponse(context.Background(), ok, server.URL+"/mcp")
+	if err != nil || discovery.RequiresOAuth {
+		t.Errorf("Expected RequiresOAuth=false for a 200 response, got %+v, %v", discovery, err)
+	}
+
+	if _, err := DiscoverFromResponse(context.Background(), nil, server.URL); err == nil {
+		t.Error("Expected error for nil response")
+	}
+	if _, err := DiscoverFromResponse(context.Background(), &http.Response{StatusCode: http.StatusUnauthorized, Body: http.NoBody}, ""); err == nil {
+		t.Error("Expected error when neither a base URL nor the request URL is available")
+	}
+}
@@ -0,0 +1,105 @@
+package oauth
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// dohAnswerA builds a DNS response to query, answering A questions with ip and
+// everything else with an empty NOERROR response
+func dohAnswerA(query []byte, ip [4]byte) []byte {
+	// Question section: name labels up to the zero byte, then QTYPE and QCLASS
+	end := 12
+	for query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	question := query[12 : end+5]
+	qtype := binary.BigEndian.Uint16(query[end+1 : end+3])
+
+	answers := uint16(0)
+	if qtype == 1 {
+		answers = 1
+	}
+
+	resp := make([]byte, 12)
+	copy(resp[:2], query[:2])                      // ID
+	binary.BigEndian.PutUint16(resp[2:4], 0x8180)  // QR, RD, RA, NOERROR
+	binary.BigEndian.PutUint16(resp[4:6], 1)       // QDCOUNT
+	binary.BigEndian.PutUint16(resp[6:8], answers) // ANCOUNT
+	resp = append(resp, question...)
+	if answers == 1 {
+		resp = append(resp, 0xC0, 0x0C, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+		resp = append(resp, ip[:]...)
+	}
+	return resp
+}
+
+// TestDoHResolver verifies that lookups are sent to the DoH provider as RFC 8484 POST requests
+func TestDoHResolver(t *testing.T) {
+	var queries int
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != dohContentType {
+			t.Errorf("Expected Content-Type %s, got %s", dohContentType, ct)
+		}
+		query, _ := io.ReadAll(r.Body)
+		queries++
+
+		w.Header().Set("Content-Type", dohContentType)
+		_, _ = w.Write(dohAnswerA(query, [4]byte{10, 1, 2, 3}))
+	}))
+	defer provider.Close()
+
+	resolver := newDoHResolver(provider.URL, provider.Client())
+	addrs, err := resolver.LookupHost(context.Background(), "auth.corp.example.")
+	if err != nil {
+		t.Fatalf("LookupHost failed: %v", err)
+	}
+
+	if len(addrs) != 1 || addrs[0] != "10.1.2.3" {
+		t.Errorf("Expected [10.1.2.3], got %v", addrs)
+	}
+	if queries == 0 {
+		t.Error("Expected queries to reach the DoH provider")
+	}
+}
+
+// TestDoHResolver_ProviderError verifies that provider failures surface as lookup errors
+func TestDoHResolver_ProviderError(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer provider.Close()
+
+	resolver := newDoHResolver(provider.URL, provider.Client())
+	if _, err := resolver.LookupHost(context.Background(), "auth.corp.example."); err == nil {
+		t.Error("Expected lookup to fail when the DoH provider errors")
+	}
+}
+
+// TestWithDNSOverHTTPS_Validation verifies that only absolute https provider URLs are accepted
+func TestWithDNSOverHTTPS_Validation(t *testing.T) {
+	tests := []struct {
+		provider    string
+		expectError bool
+	}{
+		{"https://cloudflare-dns.com/dns-query", false},
+		{"http://cloudflare-dns.com/dns-query", true},
+		{"cloudflare-dns.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			_, err := newDiscoveryConfig([]DiscoveryOption{WithDNSOverHTTPS(tt.provider)})
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error=%v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
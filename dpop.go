@@ -0,0 +1,174 @@
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// dpopNonceHeader carries server-provided DPoP nonces (RFC 9449 Section 8)
+const dpopNonceHeader = "DPoP-Nonce"
+
+// DPoPProver creates DPoP proof JWTs (RFC 9449) bound to a P-256 key pair
+//
+// RFC 9449 COMPLIANCE:
+// - Section 4.2: proofs are ES256 JWTs with typ "dpop+jwt" and the public key as jwk header
+// - Section 4.2: claims jti, htm, htu (without query and fragment), iat, and ath for resource requests
+// - Section 8: server-provided nonces are remembered per origin and included in later proofs
+//
+// A DPoPProver is safe for concurrent use. Reuse one per client so that its key (and
+// therefore the key binding of issued tokens) stays stable.
+type DPoPProver struct {
+	key    *ecdsa.PrivateKey
+	jwk    map[string]string // Public key as JWK (RFC 7518 Section 6.2)
+	clock  Clock
+	mu     sync.Mutex
+	nonces map[string]string // Latest DPoP-Nonce per origin (scheme://host)
+}
+
+// NewDPoPProver creates a prover for key, generating a new P-256 key if key is nil
+func NewDPoPProver(key *ecdsa.PrivateKey) (*DPoPProver, error) {
+	if key == nil {
+		generated, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate DPoP key: %w", err)
+		}
+		key = generated
+	}
+	if key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("DPoP key must use the P-256 curve (ES256)")
+	}
+
+	return &DPoPProver{
+		key: key,
+		jwk: map[string]string{
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		},
+		clock:  realClock{},
+		nonces: make(map[string]string),
+	}, nil
+}
+
+// Proof returns a DPoP proof for an HTTP request to targetURL
+//
+// accessToken is hashed into the ath claim when the proof accompanies a DPoP-bound access
+// token at a resource server; pass "" for token endpoint requests. The latest nonce stored
+// for the target's origin is included automatically.
+func (p *DPoPProver) Proof(method, targetURL, accessToken string) (string, error) {
+	parsed, err := url.Parse(targetURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid DPoP target URL: %w", err)
+	}
+	htu := *parsed
+	htu.RawQuery = ""
+	htu.Fragment = ""
+
+	jti, err := randomURLSafeString(16)
+	if err != nil {
+		return "", err
+	}
+
+	claims := map[string]any{
+		"jti": jti,
+		"htm": strings.ToUpper(method),
+		"htu": htu.String(),
+		"iat": p.clock.Now().Unix(),
+	}
+	if nonce := p.Nonce(targetURL); nonce != "" {
+		claims["nonce"] = nonce
+	}
+	if accessToken != "" {
+		hash := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(hash[:])
+	}
+
+	header := map[string]any{
+		"typ": "dpop+jwt",
+		"alg": "ES256",
+		"jwk": p.jwk,
+	}
+	return signES256(p.key, header, claims)
+}
+
+// signES256 serializes header and claims as an ES256 JWS (RFC 7515 compact serialization)
+func signES256(key *ecdsa.PrivateKey, header, claims map[string]any) (string, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWT header: %w", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWT claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+
+	// RFC 7518 Section 3.4: the ES256 signature is R || S, each left-padded to 32 bytes
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Nonce returns the latest DPoP nonce stored for the origin of targetURL
+func (p *DPoPProver) Nonce(targetURL string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.nonces[dpopOrigin(targetURL)]
+}
+
+// SetNonce stores a DPoP nonce for the origin of targetURL (empty nonces are ignored)
+func (p *DPoPProver) SetNonce(targetURL, nonce string) {
+	if nonce == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nonces[dpopOrigin(targetURL)] = nonce
+}
+
+// UpdateNonce stores the DPoP-Nonce header of a response from targetURL, if present
+//
+// RFC 9449 Section 8.2: servers may rotate the nonce in any response, including successful ones
+func (p *DPoPProver) UpdateNonce(targetURL string, header http.Header) {
+	p.SetNonce(targetURL, header.Get(dpopNonceHeader))
+}
+
+// AuthorizeRequest attaches a DPoP-bound access token to a resource server request
+//
+// RFC 9449 Section 7.1: sets "Authorization: DPoP <token>" and a DPoP proof with the ath claim
+func (p *DPoPProver) AuthorizeRequest(req *http.Request, accessToken string) error {
+	proof, err := p.Proof(req.Method, req.URL.String(), accessToken)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", TokenTypeDPoP+" "+accessToken)
+	req.Header.Set("DPoP", proof)
+	return nil
+}
+
+// dpopOrigin returns the lowercase scheme://host of targetURL, the scope of a DPoP nonce
+func dpopOrigin(targetURL string) string {
+	parsed, err := url.Parse(targetURL)
+	if err != nil {
+		return targetURL
+	}
+	return strings.ToLower(parsed.Scheme + "://" + parsed.Host)
+}
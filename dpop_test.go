@@ -0,0 +1,159 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// decodeES256JWT splits a JWS into its header and claims and verifies the ES256 signature against key
+func decodeES256JWT(t *testing.T, key *ecdsa.PublicKey, proof string) (map[string]any, map[string]any) {
+	t.Helper()
+
+	parts := strings.Split(proof, ".")
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 JWS parts, got %d", len(parts))
+	}
+
+	decode := func(part string, v any) {
+		data, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			t.Fatalf("Invalid base64url: %v", err)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatalf("Invalid JSON: %v", err)
+			}
+		}
+	}
+	var header, claims map[string]any
+	decode(parts[0], &header)
+	decode(parts[1], &claims)
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		t.Fatalf("Invalid ES256 signature encoding")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(key, digest[:], r, s) {
+		t.Error("JWS signature does not verify")
+	}
+	return header, claims
+}
+
+// TestDPoPProver_Proof verifies the proof header, claims and signature
+func TestDPoPProver_Proof(t *testing.T) {
+	prover, err := NewDPoPProver(nil)
+	if err != nil {
+		t.Fatalf("NewDPoPProver failed: %v", err)
+	}
+	prover.SetNonce("https://rs.example.com/other", "nonce-1")
+
+	proof, err := prover.Proof("get", "https://RS.example.com/mcp?session=1#frag", "access-token")
+	if err != nil {
+		t.Fatalf("Proof failed: %v", err)
+	}
+	header, claims := decodeES256JWT(t, &prover.key.PublicKey, proof)
+
+	if header["typ"] != "dpop+jwt" || header["alg"] != "ES256" {
+		t.Errorf("Unexpected header: %v", header)
+	}
+	if jwk, ok := header["jwk"].(map[string]any); !ok || jwk["kty"] != "EC" || jwk["crv"] != "P-256" {
+		t.Errorf("Expected EC P-256 jwk, got %v", header["jwk"])
+	}
+	if claims["htm"] != "GET" || claims["htu"] != "https://RS.example.com/mcp" {
+		t.Errorf("Expected htm=GET and htu without query/fragment, got %v %v", claims["htm"], claims["htu"])
+	}
+	if claims["nonce"] != "nonce-1" {
+		t.Errorf("Expected nonce stored for the origin, got %v", claims["nonce"])
+	}
+	hash := sha256.Sum256([]byte("access-token"))
+	if claims["ath"] != base64.RawURLEncoding.EncodeToString(hash[:]) {
+		t.Errorf("Unexpected ath claim %v", claims["ath"])
+	}
+	if claims["jti"] == "" || claims["iat"] == nil {
+		t.Errorf("Expected jti and iat claims, got %v", claims)
+	}
+}
+
+// TestExchangeAuthorizationCode_DPoPNonceRetry verifies a use_dpop_nonce error is retried
+// once with the nonce from the DPoP-Nonce header
+func TestExchangeAuthorizationCode_DPoPNonceRetry(t *testing.T) {
+	prover, err := NewDPoPProver(nil)
+	if err != nil {
+		t.Fatalf("NewDPoPProver failed: %v", err)
+	}
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, claims := decodeES256JWT(t, &prover.key.PublicKey, r.Header.Get("DPoP"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if claims["nonce"] != "server-nonce" {
+			w.Header().Set("DPoP-Nonce", "server-nonce")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"use_dpop_nonce","error_description":"Authorization server requires nonce in DPoP proof"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: "dpop-bound", TokenType: TokenTypeDPoP})
+	}))
+	defer server.Close()
+
+	discovery := &Discovery{TokenEndpoint: server.URL + "/token"}
+	creds := &ClientCredentials{ClientID: "client-123", IsPublic: true}
+
+	token, err := ExchangeAuthorizationCode(context.Background(), discovery, creds, "code", "verifier", DefaultRedirectURI, WithDPoP(prover))
+	if err != nil {
+		t.Fatalf("ExchangeAuthorizationCode failed: %v", err)
+	}
+	if token.AccessToken != "dpop-bound" {
+		t.Errorf("Expected dpop-bound token, got %s", token.AccessToken)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 token requests (initial + nonce retry), got %d", calls.Load())
+	}
+	if prover.Nonce(server.URL) != "server-nonce" {
+		t.Errorf("Expected nonce to be stored, got %q", prover.Nonce(server.URL))
+	}
+}
+
+// TestExchangeAuthorizationCode_DPoPNonceRetryOnce verifies the nonce retry is not repeated
+func TestExchangeAuthorizationCode_DPoPNonceRetryOnce(t *testing.T) {
+	prover, err := NewDPoPProver(nil)
+	if err != nil {
+		t.Fatalf("NewDPoPProver failed: %v", err)
+	}
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("DPoP-Nonce", fmt.Sprint("nonce-", n))
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"use_dpop_nonce"}`))
+	}))
+	defer server.Close()
+
+	discovery := &Discovery{TokenEndpoint: server.URL}
+	creds := &ClientCredentials{ClientID: "client-123", IsPublic: true}
+
+	_, err = ExchangeAuthorizationCode(context.Background(), discovery, creds, "code", "verifier", DefaultRedirectURI, WithDPoP(prover))
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) || tokenErr.Code != "use_dpop_nonce" {
+		t.Errorf("Expected use_dpop_nonce TokenError, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected exactly one retry, got %d requests", calls.Load())
+	}
+}
@@ -0,0 +1,199 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrMethodNotAllowed is matched (via errors.Is) by a MetadataFetchError for a 405 response
+var ErrMethodNotAllowed = errors.New("method not allowed")
+
+// ErrAudienceMismatch is returned in strict mode when the protected resource metadata
+// declares a resource identifier that does not match the MCP server
+var ErrAudienceMismatch = errors.New("protected resource does not match MCP server")
+
+// ErrEndpointOriginMismatch is returned in strict mode when an authorization server endpoint
+// is not on the issuer's origin and not allowlisted
+var ErrEndpointOriginMismatch = errors.New("authorization server endpoint origin does not match issuer")
+
+// maxErrorBodySize caps how much of an error response body is captured for RawBody
+const maxErrorBodySize = 64 * 1024
+
+// capturedResponse holds an error response whose body has been drained and closed
+//
+// The stored *http.Response keeps the status, headers and request but its Body is
+// http.NoBody, so errors never carry a live connection; the bytes read are kept separately.
+type capturedResponse struct {
+	resp *http.Response
+	body []byte
+}
+
+// captureResponse reads up to maxErrorBodySize of resp's body, drains the rest and closes it
+func captureResponse(resp *http.Response) capturedResponse {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	captured := *resp
+	captured.Body = http.NoBody
+	return capturedResponse{resp: &captured, body: body}
+}
+
+// Response returns the HTTP response that caused the error (body already closed), or nil
+func (c capturedResponse) Response() *http.Response {
+	return c.resp
+}
+
+// RawBody returns the captured response body (at most 64 KiB), or nil
+func (c capturedResponse) RawBody() []byte {
+	return c.body
+}
+
+// responseError is implemented by errors that carry the HTTP response that caused them
+type responseError interface {
+	Response() *http.Response
+	RawBody() []byte
+}
+
+// MetadataFetchError is returned when a metadata endpoint responds with a non-200 status
+//
+// A 405 Method Not Allowed means the endpoint exists but rejects GET (e.g. a server that
+// only answers the well-known path with POST) - a server misconfiguration rather than a
+// missing document. Use errors.Is(err, ErrMethodNotAllowed) to detect it.
+//
+// Response and RawBody expose the failed response (with its body drained and closed) for debugging.
+type MetadataFetchError struct {
+	URL        string // Metadata URL that was fetched
+	StatusCode int    // HTTP status returned by the endpoint
+	Allow      string // Allow header of a 405 response (methods the endpoint accepts)
+
+	capturedResponse
+}
+
+func (e *MetadataFetchError) Error() string {
+	if e.StatusCode == http.StatusMethodNotAllowed {
+		msg := fmt.Sprintf("metadata endpoint %s returned 405 Method Not Allowed: the endpoint exists but rejects GET", e.URL)
+		if e.Allow != "" {
+			msg += fmt.Sprintf(" (allowed: %s)", e.Allow)
+		}
+		return msg
+	}
+	return fmt.Sprintf("metadata endpoint %s returned status %d", e.URL, e.StatusCode)
+}
+
+// Unwrap returns ErrMethodNotAllowed for 405 responses
+func (e *MetadataFetchError) Unwrap() error {
+	if e.StatusCode == http.StatusMethodNotAllowed {
+		return ErrMethodNotAllowed
+	}
+	return nil
+}
+
+// newMetadataFetchError builds a MetadataFetchError from a non-200 metadata response,
+// draining and closing its body
+func newMetadataFetchError(metadataURL string, resp *http.Response) *MetadataFetchError {
+	return &MetadataFetchError{
+		URL:              metadataURL,
+		StatusCode:       resp.StatusCode,
+		Allow:            resp.Header.Get("Allow"),
+		capturedResponse: captureResponse(resp),
+	}
+}
+
+// DCRError is returned when the registration endpoint rejects a dynamic client registration
+//
+// RFC 7591 COMPLIANCE:
+// - Section 3.2.2: Client Registration Error Response (error, error_description)
+//
+// Response and RawBody expose the failed response (with its body drained and closed) for debugging.
+type DCRError struct {
+	Label       string // Label of the server being registered with
+	StatusCode  int    // HTTP status returned by the registration endpoint
+	Code        string // RFC 7591 error code (e.g. "invalid_redirect_uri"), if any
+	Description string // Human-readable reason from the response body
+
+	capturedResponse
+}
+
+func (e *DCRError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("DCR failed with status %d for %s", e.StatusCode, e.Label)
+	}
+	return fmt.Sprintf("DCR failed with status %d for %s: %s", e.StatusCode, e.Label, e.Description)
+}
+
+// TokenError represents an error response from the token endpoint
+//
+// RFC 6749 COMPLIANCE:
+// - Section 5.2: Error Response (error, error_description, error_uri)
+type TokenError struct {
+	Code        string `json:"error"`                       // Error code (e.g. "invalid_grant")
+	Description string `json:"error_description,omitempty"` // Human-readable error description
+	URI         string `json:"error_uri,omitempty"`         // URI of a page with error information
+	StatusCode  int    `json:"-"`                           // HTTP status of the token response
+
+	// Delay requested by the server's Retry-After header (e.g. with 429 or 503), 0 if absent
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *TokenError) Error() string {
+	msg := fmt.Sprintf("token request failed: %s", e.Code)
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	if e.URI != "" {
+		msg += " (see " + e.URI + ")"
+	}
+	return msg
+}
+
+// Discovery stages reported in DiscoveryError.Stage
+const (
+	DiscoveryStageAudience           = "audience"             // RFC 9728 Section 3.3 resource check
+	DiscoveryStageAuthServerMetadata = "auth_server_metadata" // RFC 8414 metadata fetch and validation
+	DiscoveryStageEndpointTLS        = "endpoint_tls"         // WithEnforceHTTPS endpoint check
+	DiscoveryStageEndpointOrigin     = "endpoint_origin"      // WithStrictEndpointOrigin endpoint check
+)
+
+// DiscoveryError is returned when discovery fails after the MCP server was found to require OAuth
+//
+// Partial holds what was discovered before the failing stage (RequiresOAuth, resource and
+// authorization server URLs, scopes, probe response headers), so callers can still report
+// which authorization server is broken or fall back to manual configuration. Use errors.As
+// to retrieve it; the error message is that of the underlying error.
+type DiscoveryError struct {
+	Stage   string     // Stage that failed (DiscoveryStage* constants)
+	Partial *Discovery // Partially populated discovery result (never nil)
+	Err     error      // Underlying error
+}
+
+func (e *DiscoveryError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error (e.g. ErrAudienceMismatch or a *MetadataFetchError)
+func (e *DiscoveryError) Unwrap() error {
+	return e.Err
+}
+
+// Response returns the HTTP response that caused the failure, if the underlying error carries one
+// (its body is already drained and closed)
+func (e *DiscoveryError) Response() *http.Response {
+	var respErr responseError
+	if errors.As(e.Err, &respErr) {
+		return respErr.Response()
+	}
+	return nil
+}
+
+// RawBody returns the captured body of the response that caused the failure, or nil
+func (e *DiscoveryError) RawBody() []byte {
+	var respErr responseError
+	if errors.As(e.Err, &respErr) {
+		return respErr.RawBody()
+	}
+	return nil
+}
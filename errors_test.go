@@ -0,0 +1,155 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMetadataFetchError_MethodNotAllowed verifies that a 405 on the authorization server
+// well-known endpoint surfaces a typed error explaining the method was rejected
+func TestMetadataFetchError_MethodNotAllowed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-authorization-server":
+			w.Header().Set("Allow", "POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	_, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp")
+	if err == nil {
+		t.Fatal("Expected error when metadata endpoint returns 405")
+	}
+
+	if !errors.Is(err, ErrMethodNotAllowed) {
+		t.Errorf("Expected errors.Is(err, ErrMethodNotAllowed), got %v", err)
+	}
+
+	var fetchErr *MetadataFetchError
+	if !errors.As(err, &fetchErr) {
+		t.Fatalf("Expected *MetadataFetchError, got %T", err)
+	}
+	if fetchErr.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected StatusCode=405, got %d", fetchErr.StatusCode)
+	}
+	if fetchErr.Allow != "POST" {
+		t.Errorf("Expected Allow=POST, got %q", fetchErr.Allow)
+	}
+	if !strings.Contains(err.Error(), "rejects GET") {
+		t.Errorf("Expected error message to explain the rejected method, got: %v", err)
+	}
+}
+
+// TestMetadataFetchError_OtherStatus verifies non-405 statuses are not reported as method errors
+func TestMetadataFetchError_OtherStatus(t *testing.T) {
+	err := &MetadataFetchError{URL: "https://example.com/.well-known/oauth-authorization-server", StatusCode: http.StatusNotFound}
+
+	if errors.Is(err, ErrMethodNotAllowed) {
+		t.Error("404 should not match ErrMethodNotAllowed")
+	}
+	if !strings.Contains(err.Error(), "returned status 404") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+}
+
+// TestDiscoveryError_Response verifies the failed metadata response is exposed with its body
+// captured and the live body closed
+func TestDiscoveryError_Response(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-authorization-server":
+			w.Header().Set("X-Request-Id", "req-42")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("upstream unavailable"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	_, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp")
+	var discoveryErr *DiscoveryError
+	if !errors.As(err, &discoveryErr) {
+		t.Fatalf("Expected *DiscoveryError, got %T: %v", err, err)
+	}
+
+	resp := discoveryErr.Response()
+	if resp == nil {
+		t.Fatal("Expected Response() to return the failed response")
+	}
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("X-Request-Id"); got != "req-42" {
+		t.Errorf("Expected X-Request-Id header, got %q", got)
+	}
+	if resp.Body != http.NoBody {
+		t.Errorf("Expected the live body to be replaced by http.NoBody, got %T", resp.Body)
+	}
+	if got := string(discoveryErr.RawBody()); got != "upstream unavailable" {
+		t.Errorf("Expected RawBody %q, got %q", "upstream unavailable", got)
+	}
+
+	var fetchErr *MetadataFetchError
+	if !errors.As(err, &fetchErr) || fetchErr.Response() != resp {
+		t.Error("Expected DiscoveryError to expose the MetadataFetchError response")
+	}
+}
+
+// TestDiscoveryError_ResponseNil verifies failures without an HTTP response report none
+func TestDiscoveryError_ResponseNil(t *testing.T) {
+	err := &DiscoveryError{Stage: DiscoveryStageAudience, Partial: &Discovery{}, Err: ErrAudienceMismatch}
+
+	if err.Response() != nil {
+		t.Error("Expected nil Response for an error without HTTP response")
+	}
+	if err.RawBody() != nil {
+		t.Error("Expected nil RawBody for an error without HTTP response")
+	}
+}
+
+// TestDCRError_Response verifies a rejected registration returns a DCRError with the
+// RFC 7591 error fields and the raw response
+func TestDCRError_Response(t *testing.T) {
+	const body = `{"error":"invalid_redirect_uri","error_description":"redirect_uri must use https"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	discovery := &Discovery{RegistrationEndpoint: server.URL + "/register"}
+	_, err := PerformDCR(context.Background(), discovery, "test-server", "http://localhost/callback")
+
+	var dcrErr *DCRError
+	if !errors.As(err, &dcrErr) {
+		t.Fatalf("Expected *DCRError, got %T: %v", err, err)
+	}
+	if dcrErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected StatusCode=400, got %d", dcrErr.StatusCode)
+	}
+	if dcrErr.Code != "invalid_redirect_uri" {
+		t.Errorf("Expected Code=invalid_redirect_uri, got %q", dcrErr.Code)
+	}
+	if !strings.Contains(err.Error(), "redirect_uri must use https") {
+		t.Errorf("Expected error message to include the description, got: %v", err)
+	}
+	if resp := dcrErr.Response(); resp == nil || resp.StatusCode != http.StatusBadRequest || resp.Body != http.NoBody {
+		t.Errorf("Expected closed 400 response, got %+v", resp)
+	}
+	if got := string(dcrErr.RawBody()); got != body {
+		t.Errorf("Expected RawBody %q, got %q", body, got)
+	}
+}
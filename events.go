@@ -0,0 +1,118 @@
+package oauth
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// DiscoveryEventEmitter receives telemetry events from DiscoverOAuthRequirements
+//
+// Implementations can record metrics or traces without touching the discovery logic.
+// Methods are called synchronously on the discovery goroutine, so they should return quickly.
+type DiscoveryEventEmitter interface {
+	OnProbeComplete(url string, statusCode int, elapsed time.Duration) // MCP server probe answered
+	OnResourceMetadataFetched(url string, elapsed time.Duration)       // RFC 9728 metadata retrieved
+	OnAuthServerMetadataFetched(url string, elapsed time.Duration)     // RFC 8414 metadata retrieved
+	OnDiscoveryComplete(discovery *Discovery, elapsed time.Duration)   // Discovery succeeded
+	OnDiscoveryFailed(url string, err error)                           // Discovery returned an error
+}
+
+// noopEventEmitter discards all events (used when no emitter is configured)
+type noopEventEmitter struct{}
+
+func (noopEventEmitter) OnProbeComplete(_ string, _ int, _ time.Duration)      {}
+func (noopEventEmitter) OnResourceMetadataFetched(_ string, _ time.Duration)   {}
+func (noopEventEmitter) OnAuthServerMetadataFetched(_ string, _ time.Duration) {}
+func (noopEventEmitter) OnDiscoveryComplete(_ *Discovery, _ time.Duration)     {}
+func (noopEventEmitter) OnDiscoveryFailed(_ string, _ error)                   {}
+
+// DiscoveryEvent is a structured discovery telemetry event sent by DiscoverWithEvents
+//
+// Concrete types: *ProbeEvent, *MetadataFetchEvent, *ErrorEvent and *CompleteEvent
+type DiscoveryEvent interface {
+	discoveryEvent()
+}
+
+// Metadata kinds reported in MetadataFetchEvent
+const (
+	MetadataKindProtectedResource   = "protected_resource"   // RFC 9728 protected resource metadata
+	MetadataKindAuthorizationServer = "authorization_server" // RFC 8414 authorization server metadata
+)
+
+// ProbeEvent reports the MCP server's answer to the unauthenticated probe
+type ProbeEvent struct {
+	URL        string        // Probed MCP server URL
+	StatusCode int           // HTTP status of the probe response
+	Elapsed    time.Duration // Probe duration
+}
+
+// MetadataFetchEvent reports a successfully fetched metadata document
+type MetadataFetchEvent struct {
+	URL     string        // Metadata URL (resource metadata) or authorization server URL
+	Kind    string        // MetadataKindProtectedResource or MetadataKindAuthorizationServer
+	Elapsed time.Duration // Fetch duration
+}
+
+// ErrorEvent reports that discovery failed
+type ErrorEvent struct {
+	URL string // MCP server URL
+	Err error  // Error returned by discovery
+}
+
+// CompleteEvent reports that discovery succeeded
+type CompleteEvent struct {
+	Discovery *Discovery    // Discovery result
+	Elapsed   time.Duration // Total discovery duration
+}
+
+func (*ProbeEvent) discoveryEvent()         {}
+func (*MetadataFetchEvent) discoveryEvent() {}
+func (*ErrorEvent) discoveryEvent()         {}
+func (*CompleteEvent) discoveryEvent()      {}
+
+// DiscoverWithEvents runs DiscoverOAuthRequirements and sends its telemetry as DiscoveryEvent values
+//
+// This is a channel-based alternative to WithEventEmitter (which it replaces if also passed).
+// Callers typically range over events in a separate goroutine; the channel is closed when
+// discovery returns. Sends block until received, so events must be drained or buffered.
+// Once ctx is canceled, events that cannot be delivered immediately are dropped.
+func DiscoverWithEvents(ctx context.Context, mcpURL string, events chan<- DiscoveryEvent, opts ...DiscoveryOption) (*Discovery, error) {
+	defer close(events)
+
+	emitter := &channelEventEmitter{done: ctx.Done(), events: events}
+	return DiscoverOAuthRequirements(ctx, mcpURL, append(slices.Clip(opts), WithEventEmitter(emitter))...)
+}
+
+// channelEventEmitter adapts DiscoveryEventEmitter callbacks to DiscoveryEvent values on a channel
+type channelEventEmitter struct {
+	done   <-chan struct{}
+	events chan<- DiscoveryEvent
+}
+
+func (e *channelEventEmitter) send(event DiscoveryEvent) {
+	select {
+	case e.events <- event:
+	case <-e.done:
+	}
+}
+
+func (e *channelEventEmitter) OnProbeComplete(url string, statusCode int, elapsed time.Duration) {
+	e.send(&ProbeEvent{URL: url, StatusCode: statusCode, Elapsed: elapsed})
+}
+
+func (e *channelEventEmitter) OnResourceMetadataFetched(url string, elapsed time.Duration) {
+	e.send(&MetadataFetchEvent{URL: url, Kind: MetadataKindProtectedResource, Elapsed: elapsed})
+}
+
+func (e *channelEventEmitter) OnAuthServerMetadataFetched(url string, elapsed time.Duration) {
+	e.send(&MetadataFetchEvent{URL: url, Kind: MetadataKindAuthorizationServer, Elapsed: elapsed})
+}
+
+func (e *channelEventEmitter) OnDiscoveryComplete(discovery *Discovery, elapsed time.Duration) {
+	e.send(&CompleteEvent{Discovery: discovery, Elapsed: elapsed})
+}
+
+func (e *channelEventEmitter) OnDiscoveryFailed(url string, err error) {
+	e.send(&ErrorEvent{URL: url, Err: err})
+}
@@ -0,0 +1,135 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// recordingEmitter records the names of the events it receives
+type recordingEmitter struct {
+	events    []string
+	discovery *Discovery
+	err       error
+}
+
+func (e *recordingEmitter) OnProbeComplete(_ string, _ int, _ time.Duration) {
+	e.events = append(e.events, "probe")
+}
+
+func (e *recordingEmitter) OnResourceMetadataFetched(_ string, _ time.Duration) {
+	e.events = append(e.events, "resource_metadata")
+}
+
+func (e *recordingEmitter) OnAuthServerMetadataFetched(_ string, _ time.Duration) {
+	e.events = append(e.events, "auth_server_metadata")
+}
+
+func (e *recordingEmitter) OnDiscoveryComplete(discovery *Discovery, _ time.Duration) {
+	e.events = append(e.events, "complete")
+	e.discovery = discovery
+}
+
+func (e *recordingEmitter) OnDiscoveryFailed(_ string, err error) {
+	e.events = append(e.events, "failed")
+	e.err = err
+}
+
+// TestDiscoveryEventEmitter_Success verifies the events emitted by a successful discovery, in order
+func TestDiscoveryEventEmitter_Success(t *testing.T) {
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	emitter := &recordingEmitter{}
+	discovery, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithEventEmitter(emitter))
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	expected := []string{"probe", "resource_metadata", "auth_server_metadata", "complete"}
+	if !slices.Equal(emitter.events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, emitter.events)
+	}
+	if emitter.discovery != discovery {
+		t.Error("Expected OnDiscoveryComplete to receive the returned discovery")
+	}
+}
+
+// TestDiscoveryEventEmitter_Failure verifies OnDiscoveryFailed receives the discovery error
+func TestDiscoveryEventEmitter_Failure(t *testing.T) {
+	emitter := &recordingEmitter{}
+	_, err := DiscoverOAuthRequirements(context.Background(), "http://127.0.0.1:1/mcp", WithEventEmitter(emitter))
+	if err == nil {
+		t.Fatal("Expected discovery to fail")
+	}
+
+	if !slices.Equal(emitter.events, []string{"failed"}) {
+		t.Errorf("Expected only a failed event, got %v", emitter.events)
+	}
+	if emitter.err != err {
+		t.Errorf("Expected OnDiscoveryFailed to receive %v, got %v", err, emitter.err)
+	}
+}
+
+// TestDiscoverWithEvents verifies structured events are delivered in order and the channel is closed
+func TestDiscoverWithEvents(t *testing.T) {
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	events := make(chan DiscoveryEvent)
+	collected := make(chan []DiscoveryEvent)
+	go func() {
+		var received []DiscoveryEvent
+		for event := range events {
+			received = append(received, event)
+		}
+		collected <- received
+	}()
+
+	discovery, err := DiscoverWithEvents(context.Background(), server.URL+"/mcp", events)
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	received := <-collected
+
+	if len(received) != 4 {
+		t.Fatalf("Expected 4 events, got %d: %v", len(received), received)
+	}
+	if probe, ok := received[0].(*ProbeEvent); !ok || probe.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected ProbeEvent with status 401, got %#v", received[0])
+	}
+	if fetch, ok := received[1].(*MetadataFetchEvent); !ok || fetch.Kind != MetadataKindProtectedResource {
+		t.Errorf("Expected protected resource MetadataFetchEvent, got %#v", received[1])
+	}
+	if fetch, ok := received[2].(*MetadataFetchEvent); !ok || fetch.Kind != MetadataKindAuthorizationServer || fetch.URL != server.URL {
+		t.Errorf("Expected authorization server MetadataFetchEvent, got %#v", received[2])
+	}
+	if complete, ok := received[3].(*CompleteEvent); !ok || complete.Discovery != discovery {
+		t.Errorf("Expected CompleteEvent with the returned discovery, got %#v", received[3])
+	}
+}
+
+// TestDiscoverWithEvents_Error verifies a failed discovery ends with an ErrorEvent
+func TestDiscoverWithEvents_Error(t *testing.T) {
+	events := make(chan DiscoveryEvent, 10)
+
+	_, err := DiscoverWithEvents(context.Background(), "http://127.0.0.1:1/mcp", events)
+	if err == nil {
+		t.Fatal("Expected discovery to fail")
+	}
+
+	var received []DiscoveryEvent
+	for event := range events {
+		received = append(received, event)
+	}
+	if len(received) != 1 {
+		t.Fatalf("Expected a single event, got %v", received)
+	}
+	if errorEvent, ok := received[0].(*ErrorEvent); !ok || errorEvent.Err != err {
+		t.Errorf("Expected ErrorEvent with the returned error, got %#v", received[0])
+	}
+}
@@ -0,0 +1,213 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+)
+
+// FakeTokenServer is an in-process token endpoint for testing code exchange and refresh
+//
+// It serves RFC 6749 token requests at /token (authorization_code and refresh_token grants)
+// and, with WithIntrospectionEnabled, RFC 7662 introspection at /introspect. Authorization
+// codes are single-use. Client authentication is not checked. Point a Discovery at it with
+//
+//	server := oauth.NewFakeTokenServer(oauth.WithPKCERequired())
+//	defer server.Close()
+//	discovery := &oauth.Discovery{TokenEndpoint: server.URL() + "/token"}
+type FakeTokenServer struct {
+	server *httptest.Server
+
+	mu            sync.Mutex
+	codes         map[string]TokenResponse // Responses for specific codes (nil = accept any code)
+	usedCodes     map[string]bool          // Codes already redeemed
+	refreshTokens map[string]bool          // Refresh tokens that can be redeemed
+	activeTokens  map[string]bool          // Access tokens reported active by introspection
+	issued        []string                 // Access tokens in issue order
+	rotateRefresh bool
+	requirePKCE   bool
+	introspection bool
+	tokenSequence int
+}
+
+// FakeTokenOption configures a FakeTokenServer
+type FakeTokenOption func(*FakeTokenServer)
+
+// WithIssuedTokens makes the server accept only the given authorization codes and answer
+// each with its TokenResponse (empty access_token, refresh_token, token_type and expires_in are filled in)
+//
+// Without this option every code is accepted and answered with generated tokens.
+func WithIssuedTokens(tokens map[string]TokenResponse) FakeTokenOption {
+	return func(s *FakeTokenServer) {
+		s.codes = maps.Clone(tokens)
+	}
+}
+
+// WithRefreshRotation issues a new refresh token on every refresh and invalidates the old one
+// (RFC 6749 Section 6 allows rotation; OAuth 2.1 requires it for public clients)
+func WithRefreshRotation() FakeTokenOption {
+	return func(s *FakeTokenServer) {
+		s.rotateRefresh = true
+	}
+}
+
+// WithPKCERequired rejects authorization code requests without a code_verifier (RFC 7636 Section 4.5)
+// The verifier is not checked against a challenge, since the server never sees the authorization request
+func WithPKCERequired() FakeTokenOption {
+	return func(s *FakeTokenServer) {
+		s.requirePKCE = true
+	}
+}
+
+// WithIntrospectionEnabled serves RFC 7662 token introspection at /introspect
+// Every access token the server issued is reported active; other tokens are inactive
+func WithIntrospectionEnabled() FakeTokenOption {
+	return func(s *FakeTokenServer) {
+		s.introspection = true
+	}
+}
+
+// NewFakeTokenServer starts a FakeTokenServer; call Close when done
+func NewFakeTokenServer(opts ...FakeTokenOption) *FakeTokenServer {
+	s := &FakeTokenServer{
+		usedCodes:     map[string]bool{},
+		refreshTokens: map[string]bool{},
+		activeTokens:  map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", s.handleToken)
+	if s.introspection {
+		mux.HandleFunc("/introspect", s.handleIntrospect)
+	}
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// URL returns the base URL of the server (the token endpoint is URL() + "/token")
+func (s *FakeTokenServer) URL() string {
+	return s.server.URL
+}
+
+// Close shuts down the server
+func (s *FakeTokenServer) Close() {
+	s.server.Close()
+}
+
+// IssuedTokens returns the access tokens issued so far, in order
+func (s *FakeTokenServer) IssuedTokens() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.issued)
+}
+
+func (s *FakeTokenServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeFakeTokenError(w, "invalid_request", "malformed form body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var token TokenResponse
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		if code == "" {
+			writeFakeTokenError(w, "invalid_request", "code is required")
+			return
+		}
+		if s.requirePKCE && r.PostForm.Get("code_verifier") == "" {
+			writeFakeTokenError(w, "invalid_request", "code_verifier is required")
+			return
+		}
+		if s.usedCodes[code] {
+			writeFakeTokenError(w, "invalid_grant", "authorization code already used")
+			return
+		}
+		if s.codes != nil {
+			configured, ok := s.codes[code]
+			if !ok {
+				writeFakeTokenError(w, "invalid_grant", "unknown authorization code")
+				return
+			}
+			token = configured
+		}
+		s.usedCodes[code] = true
+		if token.RefreshToken == "" {
+			token.RefreshToken = s.nextToken("refresh")
+		}
+
+	case "refresh_token":
+		refreshToken := r.PostForm.Get("refresh_token")
+		if !s.refreshTokens[refreshToken] {
+			writeFakeTokenError(w, "invalid_grant", "unknown or revoked refresh token")
+			return
+		}
+		token.RefreshToken = refreshToken
+		if s.rotateRefresh {
+			delete(s.refreshTokens, refreshToken)
+			token.RefreshToken = s.nextToken("refresh")
+		}
+
+	default:
+		writeFakeTokenError(w, "unsupported_grant_type", "")
+		return
+	}
+
+	if token.AccessToken == "" {
+		token.AccessToken = s.nextToken("access")
+	}
+	if token.TokenType == "" {
+		token.TokenType = TokenTypeBearer
+	}
+	if token.ExpiresIn == 0 {
+		token.ExpiresIn = 3600
+	}
+	s.refreshTokens[token.RefreshToken] = true
+	s.activeTokens[token.AccessToken] = true
+	s.issued = append(s.issued, token.AccessToken)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(token)
+}
+
+func (s *FakeTokenServer) handleIntrospect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeFakeTokenError(w, "invalid_request", "malformed form body")
+		return
+	}
+
+	s.mu.Lock()
+	active := s.activeTokens[r.PostForm.Get("token")]
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]bool{"active": active})
+}
+
+// nextToken returns a unique token value with the given prefix (callers hold s.mu)
+func (s *FakeTokenServer) nextToken(prefix string) string {
+	s.tokenSequence++
+	return fmt.Sprintf("%s-token-%d", prefix, s.tokenSequence)
+}
+
+// writeFakeTokenError writes an RFC 6749 Section 5.2 error response
+func writeFakeTokenError(w http.ResponseWriter, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(TokenError{Code: code, Description: description})
+}
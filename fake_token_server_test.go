@@ -0,0 +1,109 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestFakeTokenServer_Exchange verifies configured codes are exchanged once and unknown codes rejected
+func TestFakeTokenServer_Exchange(t *testing.T) {
+	server := NewFakeTokenServer(WithIssuedTokens(map[string]TokenResponse{
+		"code-1": {AccessToken: "access-1", Scope: "read"},
+	}), WithPKCERequired())
+	defer server.Close()
+
+	discovery := &Discovery{TokenEndpoint: server.URL() + "/token"}
+	creds := &ClientCredentials{ClientID: "client-123", IsPublic: true}
+
+	token, err := ExchangeAuthorizationCode(context.Background(), discovery, creds, "code-1", "verifier", DefaultRedirectURI)
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if token.AccessToken != "access-1" || token.Scope != "read" || token.TokenType != TokenTypeBearer || token.RefreshToken == "" {
+		t.Errorf("Unexpected token response: %+v", token)
+	}
+
+	var tokenErr *TokenError
+	for name, code := range map[string]string{"reused code": "code-1", "unknown code": "code-2"} {
+		_, err := ExchangeAuthorizationCode(context.Background(), discovery, creds, code, "verifier", DefaultRedirectURI)
+		if !errors.As(err, &tokenErr) || tokenErr.Code != "invalid_grant" {
+			t.Errorf("%s: expected invalid_grant, got %v", name, err)
+		}
+	}
+
+	if _, err := ExchangeAuthorizationCode(context.Background(), discovery, creds, "code-1", "", DefaultRedirectURI); !errors.As(err, &tokenErr) || tokenErr.Code != "invalid_request" {
+		t.Errorf("Expected invalid_request without code_verifier, got %v", err)
+	}
+
+	if got := server.IssuedTokens(); !slices.Equal(got, []string{"access-1"}) {
+		t.Errorf("Expected issued tokens [access-1], got %v", got)
+	}
+}
+
+// TestFakeTokenServer_RefreshRotation verifies rotated refresh tokens cannot be reused
+func TestFakeTokenServer_RefreshRotation(t *testing.T) {
+	server := NewFakeTokenServer(WithRefreshRotation())
+	defer server.Close()
+
+	discovery := &Discovery{TokenEndpoint: server.URL() + "/token"}
+	creds := &ClientCredentials{ClientID: "client-123", IsPublic: true}
+
+	token, err := ExchangeAuthorizationCode(context.Background(), discovery, creds, "any-code", "", DefaultRedirectURI)
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	refreshed, err := RefreshAccessToken(context.Background(), discovery, creds, token.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if refreshed.RefreshToken == token.RefreshToken || refreshed.AccessToken == token.AccessToken {
+		t.Errorf("Expected new access and refresh tokens, got %+v", refreshed)
+	}
+
+	var tokenErr *TokenError
+	if _, err := RefreshAccessToken(context.Background(), discovery, creds, token.RefreshToken); !errors.As(err, &tokenErr) || tokenErr.Code != "invalid_grant" {
+		t.Errorf("Expected invalid_grant for the rotated refresh token, got %v", err)
+	}
+	if got := server.IssuedTokens(); len(got) != 2 {
+		t.Errorf("Expected 2 issued tokens, got %v", got)
+	}
+}
+
+// TestFakeTokenServer_Introspection verifies issued tokens are reported active
+func TestFakeTokenServer_Introspection(t *testing.T) {
+	server := NewFakeTokenServer(WithIntrospectionEnabled())
+	defer server.Close()
+
+	discovery := &Discovery{TokenEndpoint: server.URL() + "/token"}
+	token, err := ExchangeAuthorizationCode(context.Background(), discovery, &ClientCredentials{ClientID: "client-123"}, "any-code", "", "")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+
+	for value, expected := range map[string]bool{token.AccessToken: true, "forged": false} {
+		form := url.Values{"token": {value}}
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL()+"/introspect", strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Introspection failed: %v", err)
+		}
+		var result struct {
+			Active bool `json:"active"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil || result.Active != expected {
+			t.Errorf("Expected active=%v for %q, got %v (err %v)", expected, value, result.Active, err)
+		}
+	}
+}
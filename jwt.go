@@ -0,0 +1,88 @@
+package oauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// DefaultClockSkew is the tolerance used by WithClockSkewCheck when none is given
+const DefaultClockSkew = 5 * time.Minute
+
+// ErrClockSkew is returned by CheckClockSkew when JWT timestamps disagree with the local clock
+var ErrClockSkew = errors.New("token timestamps indicate clock skew")
+
+// jwtTimeClaims are the RFC 7519 Section 4.1 registered time claims (NumericDate seconds)
+type jwtTimeClaims struct {
+	IssuedAt  *float64 `json:"iat,omitempty"`
+	NotBefore *float64 `json:"nbf,omitempty"`
+	ExpiresAt *float64 `json:"exp,omitempty"`
+}
+
+// parseJWTTimeClaims decodes the payload of a JWT without verifying its signature
+// Returns false for opaque (non-JWT) tokens or undecodable payloads
+func parseJWTTimeClaims(token string) (*jwtTimeClaims, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, false
+	}
+	var claims jwtTimeClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, false
+	}
+	return &claims, true
+}
+
+// CheckClockSkew compares the iat, nbf and exp claims of a JWT access token with now
+//
+// INFORMATIONAL ONLY: the signature is not verified, and access tokens are opaque to clients
+// per RFC 6749, so this must never be used for authorization decisions. Its purpose is to
+// catch a wrong local clock early: a freshly issued token that appears to be issued in the
+// future, not yet valid or already expired means one of the clocks is off.
+//
+// RFC 7519 COMPLIANCE:
+// - Section 4.1.4: exp - token must not be accepted on or after this time
+// - Section 4.1.5: nbf - token must not be accepted before this time
+// - Section 4.1.6: iat - time at which the token was issued
+//
+// Returns nil for opaque tokens and tokens within skew; otherwise an error wrapping ErrClockSkew
+func CheckClockSkew(token string, now time.Time, skew time.Duration) error {
+	claims, ok := parseJWTTimeClaims(token)
+	if !ok {
+		return nil
+	}
+
+	if claims.IssuedAt != nil {
+		if issuedAt := numericDate(*claims.IssuedAt); issuedAt.After(now.Add(skew)) {
+			return fmt.Errorf("%w: token issued at %s, %s ahead of the local clock",
+				ErrClockSkew, issuedAt.UTC().Format(time.RFC3339), issuedAt.Sub(now).Round(time.Second))
+		}
+	}
+	if claims.NotBefore != nil {
+		if notBefore := numericDate(*claims.NotBefore); notBefore.After(now.Add(skew)) {
+			return fmt.Errorf("%w: token not valid before %s, %s ahead of the local clock",
+				ErrClockSkew, notBefore.UTC().Format(time.RFC3339), notBefore.Sub(now).Round(time.Second))
+		}
+	}
+	if claims.ExpiresAt != nil {
+		if expiresAt := numericDate(*claims.ExpiresAt); expiresAt.Before(now.Add(-skew)) {
+			return fmt.Errorf("%w: token expired at %s, %s behind the local clock",
+				ErrClockSkew, expiresAt.UTC().Format(time.RFC3339), now.Sub(expiresAt).Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// numericDate converts an RFC 7519 NumericDate (seconds, possibly fractional) to a time
+func numericDate(seconds float64) time.Time {
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*1e9))
+}
@@ -0,0 +1,105 @@
+package oauth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newTestJWT builds an unsigned JWT with the given payload
+func newTestJWT(payload string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + encode([]byte(payload)) + "." + encode([]byte("signature"))
+}
+
+// TestCheckClockSkew verifies iat, nbf and exp are compared against the local clock with tolerance
+func TestCheckClockSkew(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) int64 { return now.Add(offset).Unix() }
+
+	tests := []struct {
+		name      string
+		token     string
+		expectErr bool
+	}{
+		{
+			name:  "opaque token",
+			token: "opaque-access-token",
+		},
+		{
+			name:  "fresh token",
+			token: newTestJWT(fmt.Sprintf(`{"iat":%d,"nbf":%d,"exp":%d}`, at(0), at(0), at(time.Hour))),
+		},
+		{
+			name:  "issued in the future within skew",
+			token: newTestJWT(fmt.Sprintf(`{"iat":%d,"exp":%d}`, at(time.Minute), at(time.Hour))),
+		},
+		{
+			name:      "issued in the future beyond skew",
+			token:     newTestJWT(fmt.Sprintf(`{"iat":%d,"exp":%d}`, at(10*time.Minute), at(time.Hour))),
+			expectErr: true,
+		},
+		{
+			name:      "not yet valid beyond skew",
+			token:     newTestJWT(fmt.Sprintf(`{"nbf":%d}`, at(time.Hour))),
+			expectErr: true,
+		},
+		{
+			name:      "already expired beyond skew",
+			token:     newTestJWT(fmt.Sprintf(`{"iat":%d,"exp":%d}`, at(-2*time.Hour), at(-time.Hour))),
+			expectErr: true,
+		},
+		{
+			name:  "fractional NumericDate",
+			token: newTestJWT(fmt.Sprintf(`{"iat":%d.5}`, at(0))),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckClockSkew(tt.token, now, 2*time.Minute)
+			if tt.expectErr && !errors.Is(err, ErrClockSkew) {
+				t.Errorf("Expected ErrClockSkew, got %v", err)
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestRefreshAccessToken_ClockSkewWarning verifies a token issued in the future beyond the
+// skew is still returned but logged as a warning
+func TestRefreshAccessToken_ClockSkewWarning(t *testing.T) {
+	clock := newFakeClock()
+	accessToken := newTestJWT(fmt.Sprintf(`{"iat":%d}`, clock.Now().Add(time.Hour).Unix()))
+	server := newMockTokenServer(t, http.StatusOK, TokenResponse{AccessToken: accessToken, TokenType: "Bearer"}, nil)
+
+	logger := &testLogger{}
+	ctx := WithLogger(context.Background(), logger)
+	discovery := &Discovery{TokenEndpoint: server.URL + "/token"}
+	creds := &ClientCredentials{ClientID: "client-123"}
+
+	// Check disabled by default
+	if _, err := RefreshAccessToken(ctx, discovery, creds, "refresh", WithTokenClock(clock)); err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	if logger.containsWarn("clock skew") {
+		t.Errorf("Expected no clock skew warning without WithClockSkewCheck, got %v", logger.warns)
+	}
+
+	token, err := RefreshAccessToken(ctx, discovery, creds, "refresh", WithTokenClock(clock), WithClockSkewCheck(5*time.Minute))
+	if err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	if token.AccessToken != accessToken {
+		t.Error("Expected the token to be returned despite the skew")
+	}
+	if !logger.containsWarn("clock skew") {
+		t.Errorf("Expected clock skew warning, got %v", logger.warns)
+	}
+}
@@ -0,0 +1,97 @@
+package oauth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// DefaultMaxConcurrentPerHost is the per-host concurrency used when NewHostLimiter gets a non-positive limit
+const DefaultMaxConcurrentPerHost = 4
+
+// HostLimiter bounds the number of in-flight requests to each host
+//
+// Share one HostLimiter across discoveries (see WithHostLimiter) so that many concurrent
+// flows targeting the same authorization server don't trip its rate limits.
+// A HostLimiter is safe for concurrent use.
+type HostLimiter struct {
+	maxPerHost int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// NewHostLimiter creates a limiter allowing maxPerHost concurrent requests per host
+// (maxPerHost <= 0 uses DefaultMaxConcurrentPerHost)
+func NewHostLimiter(maxPerHost int) *HostLimiter {
+	if maxPerHost <= 0 {
+		maxPerHost = DefaultMaxConcurrentPerHost
+	}
+	return &HostLimiter{
+		maxPerHost: maxPerHost,
+		slots:      make(map[string]chan struct{}),
+	}
+}
+
+// Acquire blocks until a slot for host is free or ctx is done
+// On success the returned function must be called to release the slot
+func (l *HostLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	l.mu.Lock()
+	slots, ok := l.slots[host]
+	if !ok {
+		slots = make(chan struct{}, l.maxPerHost)
+		l.slots[host] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-slots }) }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Transport wraps base so that each request holds a slot for its host until the
+// response body is closed (nil base uses http.DefaultTransport)
+func (l *HostLimiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &hostLimitedTransport{base: base, limiter: l}
+}
+
+// hostLimitedTransport is the http.RoundTripper returned by HostLimiter.Transport
+type hostLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *HostLimiter
+}
+
+func (t *hostLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.Acquire(req.Context(), req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseOnClose releases a host slot when the response body is closed
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
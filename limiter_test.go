@@ -0,0 +1,116 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestHostLimiter_ConcurrentDiscoveries verifies in-flight requests to one host never exceed the limit
+func TestHostLimiter_ConcurrentDiscoveries(t *testing.T) {
+	const limit = 2
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			observed := maxInFlight.Load()
+			if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK) // No OAuth required - one request per discovery
+	}))
+	defer server.Close()
+
+	limiter := NewHostLimiter(limit)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := DiscoverOAuthRequirements(context.Background(), server.URL+"/mcp", WithHostLimiter(limiter)); err != nil {
+				t.Errorf("Discovery failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got > limit {
+		t.Errorf("Expected at most %d in-flight requests, got %d", limit, got)
+	}
+}
+
+// TestHostLimiter_OAuthDiscoveries verifies the probe releases its slot before the metadata
+// requests to the same host, so full discoveries complete even with a single slot
+func TestHostLimiter_OAuthDiscoveries(t *testing.T) {
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("unauthorized"))
+	})
+
+	for _, limit := range []int{1, DefaultMaxConcurrentPerHost} {
+		limiter := NewHostLimiter(limit)
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+
+		var wg sync.WaitGroup
+		for range DefaultMaxConcurrentPerHost {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				discovery, err := DiscoverOAuthRequirements(ctx, server.URL+"/mcp", WithHostLimiter(limiter))
+				if err != nil {
+					t.Errorf("Limit %d: discovery failed: %v", limit, err)
+					return
+				}
+				if discovery.TokenEndpoint != server.URL+"/token" {
+					t.Errorf("Limit %d: expected TokenEndpoint=%s, got %s", limit, server.URL+"/token", discovery.TokenEndpoint)
+				}
+			}()
+		}
+		wg.Wait()
+		cancel()
+	}
+}
+
+// TestHostLimiter_Acquire verifies per-host slots and cancellation while waiting
+func TestHostLimiter_Acquire(t *testing.T) {
+	limiter := NewHostLimiter(1)
+
+	release, err := limiter.Acquire(context.Background(), "auth.example.com")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	// Other hosts are independent
+	releaseOther, err := limiter.Acquire(context.Background(), "other.example.com")
+	if err != nil {
+		t.Fatalf("Acquire for other host failed: %v", err)
+	}
+	releaseOther()
+
+	// Same host blocks until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(ctx, "auth.example.com"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded while host is saturated, got %v", err)
+	}
+
+	// Releasing frees the slot (double release is harmless)
+	release()
+	release()
+	releaseAgain, err := limiter.Acquire(context.Background(), "auth.example.com")
+	if err != nil {
+		t.Fatalf("Acquire after release failed: %v", err)
+	}
+	releaseAgain()
+}
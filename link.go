@@ -0,0 +1,82 @@
+package oauth
+
+import (
+	"strings"
+)
+
+// ResourceMetadataLinkRelation is the Link relation type identifying a protected resource metadata URL
+const ResourceMetadataLinkRelation = "https://datatracker.ietf.org/doc/html/rfc9728"
+
+// FindResourceMetadataLink returns the protected resource metadata URL advertised in Link headers
+//
+// RFC 9728 COMPLIANCE:
+// - Section 4: The resource server may advertise its metadata URL with an HTTP Link header
+//
+// RFC 8288 COMPLIANCE - Web Linking:
+// - Section 3: Link: <target>; rel="relation" (multiple links separated by commas)
+// - Section 3.3: rel may hold several space-separated relation types
+//
+// Example input:
+//
+//	<https://example.com/.well-known/oauth-protected-resource>; rel="https://datatracker.ietf.org/doc/html/rfc9728"
+func FindResourceMetadataLink(linkHeaders []string) string {
+	for _, header := range linkHeaders {
+		for _, link := range splitLinks(header) {
+			target, params, ok := parseLink(link)
+			if !ok {
+				continue
+			}
+			for _, relation := range strings.Fields(params["rel"]) {
+				if relation == ResourceMetadataLinkRelation {
+					return target
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// splitLinks splits a Link header value on commas that are outside <...> and quoted strings
+func splitLinks(header string) []string {
+	var links []string
+	var inTarget, inQuotes bool
+	start := 0
+
+	for i, ch := range header {
+		switch {
+		case ch == '<' && !inQuotes:
+			inTarget = true
+		case ch == '>' && !inQuotes:
+			inTarget = false
+		case ch == '"' && !inTarget:
+			inQuotes = !inQuotes
+		case ch == ',' && !inTarget && !inQuotes:
+			links = append(links, header[start:i])
+			start = i + 1
+		}
+	}
+	return append(links, header[start:])
+}
+
+// parseLink parses a single link-value into its target URI and lowercase-keyed parameters
+func parseLink(link string) (string, map[string]string, bool) {
+	link = strings.TrimSpace(link)
+	if !strings.HasPrefix(link, "<") {
+		return "", nil, false
+	}
+	end := strings.Index(link, ">")
+	if end < 0 {
+		return "", nil, false
+	}
+
+	target := link[1:end]
+	params := make(map[string]string)
+	for _, param := range strings.Split(link[end+1:], ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found {
+			continue
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return target, params, true
+}
@@ -0,0 +1,89 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestFindResourceMetadataLink verifies Link header parsing for the RFC 9728 relation
+func TestFindResourceMetadataLink(t *testing.T) {
+	tests := []struct {
+		name      string
+		headers   []string
+		expectURL string
+	}{
+		{
+			name:      "single link",
+			headers:   []string{`<https://example.com/.well-known/oauth-protected-resource>; rel="https://datatracker.ietf.org/doc/html/rfc9728"`},
+			expectURL: "https://example.com/.well-known/oauth-protected-resource",
+		},
+		{
+			name:      "multiple links in one header",
+			headers:   []string{`<https://example.com/docs>; rel="help", <https://example.com/rm?a=1,2>; rel="https://datatracker.ietf.org/doc/html/rfc9728"`},
+			expectURL: "https://example.com/rm?a=1,2",
+		},
+		{
+			name:      "multiple relation types",
+			headers:   []string{`<https://example.com/rm>; rel="alternate https://datatracker.ietf.org/doc/html/rfc9728"`},
+			expectURL: "https://example.com/rm",
+		},
+		{
+			name:      "unrelated link only",
+			headers:   []string{`<https://example.com/next>; rel="next"`},
+			expectURL: "",
+		},
+		{
+			name:      "malformed link",
+			headers:   []string{`https://example.com/rm; rel="https://datatracker.ietf.org/doc/html/rfc9728"`},
+			expectURL: "",
+		},
+		{
+			name:      "no headers",
+			headers:   nil,
+			expectURL: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindResourceMetadataLink(tt.headers); got != tt.expectURL {
+				t.Errorf("Expected %q, got %q", tt.expectURL, got)
+			}
+		})
+	}
+}
+
+// TestDiscovery_LinkHeader verifies discovery uses the Link header when WWW-Authenticate has no resource_metadata
+func TestDiscovery_LinkHeader(t *testing.T) {
+	var serverURL string
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("rm") != "" {
+			fmt.Fprintf(w, `{"resource":"%s/mcp","authorization_server":"%s","scopes":["linked"]}`, serverURL, serverURL)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/mcp?rm=1>; rel="%s"`, serverURL, ResourceMetadataLinkRelation))
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	serverURL = server.URL
+
+	logger := &testLogger{}
+	ctx := WithLogger(context.Background(), logger)
+
+	discovery, err := DiscoverOAuthRequirements(ctx, server.URL+"/mcp")
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	if !logger.containsInfo("discovered via Link header") {
+		t.Error("Expected Link header discovery path to be logged")
+	}
+	if logger.containsInfo("fallback: trying well-known") {
+		t.Error("Expected no well-known fallback when Link header is present")
+	}
+	if len(discovery.Scopes) != 1 || discovery.Scopes[0] != "linked" {
+		t.Errorf("Expected scopes from linked metadata, got %v", discovery.Scopes)
+	}
+}
@@ -1,6 +1,12 @@
 package oauth
 
-import "context"
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
 
 // Logger is an interface for logging during OAuth discovery
 // Implementations should log with appropriate formatting and destination
@@ -10,22 +16,91 @@ type Logger interface {
 	Debugf(format string, args ...any) // Debug/verbose details
 }
 
-type contextKey struct{}
+type (
+	contextKey              struct{}
+	correlationIDContextKey struct{}
+)
 
-var loggerKey = contextKey{}
+var (
+	loggerKey        = contextKey{}
+	correlationIDKey = correlationIDContextKey{}
+)
 
 // WithLogger attaches a logger to the context
 func WithLogger(ctx context.Context, logger Logger) context.Context {
 	return context.WithValue(ctx, loggerKey, logger)
 }
 
+// WithCorrelationID attaches a correlation ID to the context
+// Every log line emitted with the context is prefixed with "[<id>] " so the lines of one
+// discovery (or token request) can be grouped in shared logs
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID attached to ctx, or ""
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// ensureCorrelationID returns ctx unchanged if it carries a correlation ID, otherwise a
+// context with a newly generated one (8 hex characters)
+func ensureCorrelationID(ctx context.Context) context.Context {
+	if CorrelationIDFromContext(ctx) != "" {
+		return ctx
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return WithCorrelationID(ctx, hex.EncodeToString(b))
+}
+
+// defaultLogger is used when the context carries no logger (see SetDefaultLogger)
+var (
+	defaultLoggerMu sync.RWMutex
+	defaultLogger   Logger = noopLogger{}
+)
+
+// SetDefaultLogger sets the logger used by operations whose context has no logger (WithLogger)
+//
+// The default discards everything; nil restores that. Safe for concurrent use, but intended
+// to be called once during application startup.
+func SetDefaultLogger(logger Logger) {
+	if logger == nil {
+		logger = noopLogger{}
+	}
+	defaultLoggerMu.Lock()
+	defer defaultLoggerMu.Unlock()
+	defaultLogger = logger
+}
+
 // loggerFromContext extracts the logger from context
-// Returns a noop logger if none is set (for backward compatibility)
+// Returns the default logger if none is set (a noop logger unless SetDefaultLogger was called)
+// The logger prefixes every line with the context's correlation ID, if any
 func loggerFromContext(ctx context.Context) Logger {
-	if logger, ok := ctx.Value(loggerKey).(Logger); ok {
-		return logger
+	logger, ok := ctx.Value(loggerKey).(Logger)
+	if !ok {
+		defaultLoggerMu.RLock()
+		logger = defaultLogger
+		defaultLoggerMu.RUnlock()
+	}
+	if id := CorrelationIDFromContext(ctx); id != "" {
+		return correlationLogger{logger: logger, id: id}
+	}
+	return logger
+}
+
+// TimedOperation starts timing an operation and returns a function that logs
+// "<name> completed in <N>ms" at Info level with the context logger when called
+//
+//	done := TimedOperation(ctx, "token refresh")
+//	// ...
+//	done()
+func TimedOperation(ctx context.Context, name string) func() {
+	start := time.Now()
+	return func() {
+		loggerFromContext(ctx).Infof("%s completed in %dms", name, time.Since(start).Milliseconds())
 	}
-	return noopLogger{}
 }
 
 // noopLogger does nothing (used when no logger is provided)
@@ -34,3 +109,111 @@ type noopLogger struct{}
 func (noopLogger) Infof(_ string, _ ...any)  {}
 func (noopLogger) Warnf(_ string, _ ...any)  {}
 func (noopLogger) Debugf(_ string, _ ...any) {}
+
+// correlationLogger prefixes every message with a correlation ID
+type correlationLogger struct {
+	logger Logger
+	id     string
+}
+
+func (l correlationLogger) Infof(format string, args ...any) {
+	l.logger.Infof("[%s] "+format, append([]any{l.id}, args...)...)
+}
+
+func (l correlationLogger) Warnf(format string, args ...any) {
+	l.logger.Warnf("[%s] "+format, append([]any{l.id}, args...)...)
+}
+
+func (l correlationLogger) Debugf(format string, args ...any) {
+	l.logger.Debugf("[%s] "+format, append([]any{l.id}, args...)...)
+}
+
+// NewMultiLogger returns a Logger that forwards every call to each of loggers in order
+// (e.g. a human-readable stderr logger and a structured one)
+//
+// nil loggers are skipped. A logger that panics is skipped for that call; the remaining
+// loggers still receive the message and the panic does not reach the caller.
+func NewMultiLogger(loggers ...Logger) Logger {
+	multi := make(multiLogger, 0, len(loggers))
+	for _, logger := range loggers {
+		if logger != nil {
+			multi = append(multi, logger)
+		}
+	}
+	return multi
+}
+
+// multiLogger fans out log calls to several loggers
+type multiLogger []Logger
+
+func (m multiLogger) Infof(format string, args ...any) {
+	for _, logger := range m {
+		callLogger(func() { logger.Infof(format, args...) })
+	}
+}
+
+func (m multiLogger) Warnf(format string, args ...any) {
+	for _, logger := range m {
+		callLogger(func() { logger.Warnf(format, args...) })
+	}
+}
+
+func (m multiLogger) Debugf(format string, args ...any) {
+	for _, logger := range m {
+		callLogger(func() { logger.Debugf(format, args...) })
+	}
+}
+
+// callLogger runs one logger call, recovering from a panic so that a faulty logger
+// cannot break the others or the OAuth flow being logged
+func callLogger(log func()) {
+	defer func() { _ = recover() }()
+	log()
+}
+
+// LogLevel is the severity of a log call, used by NewLeveledLogger
+type LogLevel int
+
+// Log levels in increasing severity
+const (
+	LevelDebug LogLevel = iota // Debugf and above
+	LevelInfo                  // Infof and above
+	LevelWarn                  // Warnf only
+	LevelError                 // Nothing: drops Debugf, Infof and Warnf (discovery failures are returned as errors)
+)
+
+// NewLeveledLogger returns a Logger that forwards calls at or above minLevel to l and drops the rest
+//
+// For example NewLeveledLogger(l, LevelWarn) keeps the verbose discovery logging out of
+// production logs while still surfacing warnings. LevelError drops all Debugf, Infof and
+// Warnf output, since Logger has no error method. A nil l discards everything.
+func NewLeveledLogger(l Logger, minLevel LogLevel) Logger {
+	if l == nil {
+		l = noopLogger{}
+	}
+	return leveledLogger{logger: l, minLevel: minLevel}
+}
+
+// leveledLogger drops log calls below a minimum level
+type leveledLogger struct {
+	logger   Logger
+	minLevel LogLevel
+}
+
+func (l leveledLogger) Infof(format string, args ...any) {
+	if l.minLevel <= LevelInfo {
+		l.logger.Infof(format, args...)
+	}
+}
+
+func (l leveledLogger) Warnf(format string, args ...any) {
+	if l.minLevel <= LevelWarn {
+		l.logger.Warnf(format, args...)
+	}
+}
+
+func (l leveledLogger) Debugf(format string, args ...any) {
+	if l.minLevel <= LevelDebug {
+		l.logger.Debugf(format, args...)
+	}
+}
@@ -0,0 +1,185 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDiscovery_CorrelationID verifies every discovery log line carries the caller's correlation ID
+func TestDiscovery_CorrelationID(t *testing.T) {
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	logger := &testLogger{}
+	ctx := WithCorrelationID(WithLogger(context.Background(), logger), "req-1234")
+	if _, err := DiscoverOAuthRequirements(ctx, server.URL+"/mcp"); err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	lines := append(append(append([]string{}, logger.infos...), logger.warns...), logger.debugs...)
+	if len(lines) == 0 {
+		t.Fatal("Expected discovery to log")
+	}
+	prefix := regexp.MustCompile(`^\[req-1234\] `)
+	for _, line := range lines {
+		if !prefix.MatchString(line) {
+			t.Errorf("Expected correlation ID prefix, got %q", line)
+		}
+	}
+}
+
+// TestDiscovery_GeneratedCorrelationID verifies a short random ID is generated per discovery
+// when the context has none
+func TestDiscovery_GeneratedCorrelationID(t *testing.T) {
+	server := newMockOAuthServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	generated := regexp.MustCompile(`^\[([0-9a-f]{8})\] `)
+	var ids []string
+	for range 2 {
+		logger := &testLogger{}
+		if _, err := DiscoverOAuthRequirements(WithLogger(context.Background(), logger), server.URL+"/mcp"); err != nil {
+			t.Fatalf("Discovery failed: %v", err)
+		}
+		match := generated.FindStringSubmatch(logger.infos[0])
+		if match == nil {
+			t.Fatalf("Expected generated 8 hex character ID, got %q", logger.infos[0])
+		}
+		for _, line := range logger.infos {
+			if !generated.MatchString(line) || generated.FindStringSubmatch(line)[1] != match[1] {
+				t.Errorf("Expected every line to carry ID %s, got %q", match[1], line)
+			}
+		}
+		ids = append(ids, match[1])
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("Expected a new ID per discovery, got %s twice", ids[0])
+	}
+}
+
+// TestCorrelationIDFromContext verifies the ID round-trips and is empty when unset
+func TestCorrelationIDFromContext(t *testing.T) {
+	if id := CorrelationIDFromContext(context.Background()); id != "" {
+		t.Errorf("Expected no ID, got %q", id)
+	}
+	if id := CorrelationIDFromContext(WithCorrelationID(context.Background(), "abc")); id != "abc" {
+		t.Errorf("Expected abc, got %q", id)
+	}
+}
+
+// TestNewTestLogger verifies the exported TestLogger records lines with their level
+func TestNewTestLogger(t *testing.T) {
+	logger, lines := NewTestLogger()
+	ctx := WithCorrelationID(WithLogger(context.Background(), logger), "abc")
+
+	loggerFromContext(ctx).Infof("fetching %s", "metadata")
+	logger.Warnf("slow response")
+	logger.Debugf("status %d", 401)
+
+	expected := []string{"INFO: [abc] fetching metadata", "WARN: slow response", "DEBUG: status 401"}
+	if got := lines(); !slices.Equal(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+// panickingLogger panics on every call
+type panickingLogger struct{}
+
+func (panickingLogger) Infof(string, ...any)  { panic("info") }
+func (panickingLogger) Warnf(string, ...any)  { panic("warn") }
+func (panickingLogger) Debugf(string, ...any) { panic("debug") }
+
+// TestNewMultiLogger verifies every logger receives each call in order, even when one panics
+func TestNewMultiLogger(t *testing.T) {
+	first, firstLines := NewTestLogger()
+	second, secondLines := NewTestLogger()
+	logger := NewMultiLogger(first, panickingLogger{}, nil, second)
+
+	logger.Infof("discovered %s", "issuer")
+	logger.Warnf("retrying")
+	logger.Debugf("status %d", 401)
+
+	expected := []string{"INFO: discovered issuer", "WARN: retrying", "DEBUG: status 401"}
+	if got := firstLines(); !slices.Equal(got, expected) {
+		t.Errorf("First logger: expected %q, got %q", expected, got)
+	}
+	if got := secondLines(); !slices.Equal(got, expected) {
+		t.Errorf("Second logger: expected %q, got %q", expected, got)
+	}
+}
+
+// TestNewLeveledLogger verifies calls below the minimum level are dropped
+func TestNewLeveledLogger(t *testing.T) {
+	tests := []struct {
+		minLevel LogLevel
+		expected []string
+	}{
+		{LevelDebug, []string{"DEBUG: d", "INFO: i", "WARN: w"}},
+		{LevelInfo, []string{"INFO: i", "WARN: w"}},
+		{LevelWarn, []string{"WARN: w"}},
+		{LevelError, nil},
+	}
+
+	for _, tt := range tests {
+		inner, lines := NewTestLogger()
+		logger := NewLeveledLogger(inner, tt.minLevel)
+		logger.Debugf("d")
+		logger.Infof("i")
+		logger.Warnf("w")
+
+		if got := lines(); !slices.Equal(got, tt.expected) {
+			t.Errorf("Level %d: expected %q, got %q", tt.minLevel, tt.expected, got)
+		}
+	}
+
+	// A nil logger discards instead of panicking
+	NewLeveledLogger(nil, LevelDebug).Warnf("dropped")
+}
+
+// TestTimedOperation verifies the returned function logs the elapsed time of the operation
+func TestTimedOperation(t *testing.T) {
+	logger, lines := NewTestLogger()
+	done := TimedOperation(WithLogger(context.Background(), logger), "token refresh")
+	time.Sleep(5 * time.Millisecond)
+	done()
+
+	got := lines()
+	if len(got) != 1 || !regexp.MustCompile(`^INFO: token refresh completed in \d+ms$`).MatchString(got[0]) {
+		t.Fatalf("Expected one timing line, got %q", got)
+	}
+	if strings.HasSuffix(got[0], " 0ms") {
+		t.Errorf("Expected a non-zero duration, got %q", got[0])
+	}
+}
+
+// TestSetDefaultLogger verifies logs without a context logger go to the package default
+func TestSetDefaultLogger(t *testing.T) {
+	logger, lines := NewTestLogger()
+	SetDefaultLogger(logger)
+	t.Cleanup(func() { SetDefaultLogger(nil) })
+
+	loggerFromContext(context.Background()).Warnf("no context logger")
+
+	// A context logger still takes precedence
+	contextLogger, contextLines := NewTestLogger()
+	loggerFromContext(WithLogger(context.Background(), contextLogger)).Infof("context logger")
+
+	if got := lines(); !slices.Contains(got, "WARN: no context logger") || slices.Contains(got, "INFO: context logger") {
+		t.Errorf("Expected only the context-free line in the default logger, got %q", got)
+	}
+	if got := contextLines(); !slices.Equal(got, []string{"INFO: context logger"}) {
+		t.Errorf("Expected the context logger to receive its line, got %q", got)
+	}
+
+	SetDefaultLogger(nil)
+	if _, ok := loggerFromContext(context.Background()).(noopLogger); !ok {
+		t.Error("Expected SetDefaultLogger(nil) to restore the noop logger")
+	}
+}
@@ -0,0 +1,53 @@
+package oauth
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// authorizationServerMetadataFields has the fields of AuthorizationServerMetadata without its methods
+type authorizationServerMetadataFields AuthorizationServerMetadata
+
+// UnmarshalJSON parses authorization server metadata, accepting PKCE methods from either field
+//
+// RFC 8414 Section 2 names the field code_challenge_methods_supported; some OIDC providers
+// send the non-standard pkce_methods_supported instead. code_challenge_methods_supported is
+// preferred when both are present.
+func (m *AuthorizationServerMetadata) UnmarshalJSON(data []byte) error {
+	return m.unmarshal(data, false)
+}
+
+// unmarshalStrict is UnmarshalJSON rejecting unknown fields (see decodeJSON)
+func (m *AuthorizationServerMetadata) unmarshalStrict(data []byte) error {
+	return m.unmarshal(data, true)
+}
+
+func (m *AuthorizationServerMetadata) unmarshal(data []byte, strict bool) error {
+	aux := struct {
+		*authorizationServerMetadataFields
+		PKCEMethodsSupported []string `json:"pkce_methods_supported,omitempty"`
+	}{
+		authorizationServerMetadataFields: (*authorizationServerMetadataFields)(m),
+	}
+
+	if strict {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&aux); err != nil {
+			return err
+		}
+	} else if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	switch {
+	case len(m.CodeChallengeMethodsSupported) > 0:
+		m.pkceMethodsField = "code_challenge_methods_supported"
+	case len(aux.PKCEMethodsSupported) > 0:
+		m.CodeChallengeMethodsSupported = aux.PKCEMethodsSupported
+		m.pkceMethodsField = "pkce_methods_supported"
+	default:
+		m.pkceMethodsField = ""
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// TestAuthorizationServerMetadata_PKCEMethodFields verifies PKCE methods are read from
+// code_challenge_methods_supported or pkce_methods_supported, preferring the former
+func TestAuthorizationServerMetadata_PKCEMethodFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		json          string
+		expected      []string
+		expectedField string
+	}{
+		{
+			name:          "RFC 8414 field",
+			json:          `{"issuer":"https://auth.example.com","code_challenge_methods_supported":["S256"]}`,
+			expected:      []string{"S256"},
+			expectedField: "code_challenge_methods_supported",
+		},
+		{
+			name:          "non-standard field",
+			json:          `{"issuer":"https://auth.example.com","pkce_methods_supported":["S256","plain"]}`,
+			expected:      []string{"S256", "plain"},
+			expectedField: "pkce_methods_supported",
+		},
+		{
+			name:          "both prefer RFC 8414",
+			json:          `{"code_challenge_methods_supported":["S256"],"pkce_methods_supported":["plain"]}`,
+			expected:      []string{"S256"},
+			expectedField: "code_challenge_methods_supported",
+		},
+		{
+			name: "neither",
+			json: `{"issuer":"https://auth.example.com"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var metadata AuthorizationServerMetadata
+			if err := json.Unmarshal([]byte(tt.json), &metadata); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if !slices.Equal(metadata.CodeChallengeMethodsSupported, tt.expected) {
+				t.Errorf("Expected methods %v, got %v", tt.expected, metadata.CodeChallengeMethodsSupported)
+			}
+			if metadata.pkceMethodsField != tt.expectedField {
+				t.Errorf("Expected field %q, got %q", tt.expectedField, metadata.pkceMethodsField)
+			}
+		})
+	}
+}
+
+// TestDiscovery_PKCEMethodsSupportedField verifies discovery detects S256 from pkce_methods_supported,
+// logs the field used and still accepts it in strict mode
+func TestDiscovery_PKCEMethodsSupportedField(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-authorization-server":
+			fmt.Fprintf(w, `{"issuer":%q,"authorization_endpoint":%q,"token_endpoint":%q,"pkce_methods_supported":["S256"]}`,
+				server.URL, server.URL+"/authorize", server.URL+"/token")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	for _, opts := range [][]DiscoveryOption{nil, {WithStrictJSON()}} {
+		logger := &testLogger{}
+		discovery, err := DiscoverOAuthRequirements(WithLogger(context.Background(), logger), server.URL+"/mcp", opts...)
+		if err != nil {
+			t.Fatalf("Discovery failed: %v", err)
+		}
+		if !discovery.SupportsPKCE {
+			t.Error("Expected SupportsPKCE=true from pkce_methods_supported")
+		}
+		if !logger.containsDebug("pkce_methods_supported") {
+			t.Error("Expected debug log naming the PKCE methods field")
+		}
+	}
+}
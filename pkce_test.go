@@ -0,0 +1,65 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestGeneratePKCE verifies verifier length and S256 challenge derivation
+func TestGeneratePKCE(t *testing.T) {
+	verifier, challenge, err := GeneratePKCE()
+	if err != nil {
+		t.Fatalf("GeneratePKCE failed: %v", err)
+	}
+
+	if len(verifier) != 43 {
+		t.Errorf("Expected 43-character verifier, got %d", len(verifier))
+	}
+	if challenge != pkceChallenge(verifier) {
+		t.Error("Challenge does not match S256 of verifier")
+	}
+
+	// RFC 7636 Appendix B test vector
+	if got := pkceChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("Unexpected challenge for RFC 7636 test vector: %s", got)
+	}
+}
+
+// TestGeneratePKCEWithLength verifies the RFC 7636 verifier length bounds
+func TestGeneratePKCEWithLength(t *testing.T) {
+	tests := []struct {
+		byteLength     int
+		expectedLength int
+		expectError    bool
+	}{
+		{byteLength: 32, expectedLength: 43},
+		{byteLength: 64, expectedLength: 86},
+		{byteLength: 96, expectedLength: 128},
+		{byteLength: 31, expectError: true},
+		{byteLength: 97, expectError: true},
+		{byteLength: 0, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d bytes", tt.byteLength), func(t *testing.T) {
+			verifier, challenge, err := GeneratePKCEWithLength(tt.byteLength)
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidPKCELength) {
+					t.Errorf("Expected ErrInvalidPKCELength, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GeneratePKCEWithLength failed: %v", err)
+			}
+
+			if len(verifier) != tt.expectedLength {
+				t.Errorf("Expected %d-character verifier, got %d", tt.expectedLength, len(verifier))
+			}
+			if challenge != pkceChallenge(verifier) {
+				t.Error("Challenge does not match S256 of verifier")
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package oauth
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// problemJSONContentType is the RFC 7807 problem details media type
+const problemJSONContentType = "application/problem+json"
+
+// maxProblemBodySize caps how much of a probe response body is read for problem details
+const maxProblemBodySize = 64 * 1024
+
+// problemDetails is an RFC 7807 problem details object with the OAuth hints some
+// servers embed as extension members
+//
+// RFC 7807 COMPLIANCE - Problem Details for HTTP APIs:
+// - Section 3.1: type, title, status, detail, instance members
+// - Section 3.2: extension members (resource_metadata, authorization_server(s), scope)
+type problemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+
+	// OAuth hint extension members (non-standard)
+	ResourceMetadata     string   `json:"resource_metadata"`
+	AuthorizationServer  string   `json:"authorization_server"`
+	AuthorizationServers []string `json:"authorization_servers"`
+	Scope                string   `json:"scope"`
+}
+
+// parseAuthProblem extracts OAuth hints from a 400/401/403 problem+json probe response
+//
+// Returns nil if the response is not a problem details document or does not reference
+// OAuth (via hint members or an auth-related type, title or detail)
+func parseAuthProblem(resp *http.Response) *problemDetails {
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden:
+	default:
+		return nil
+	}
+	if resp.Body == nil {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != problemJSONContentType {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProblemBodySize))
+	if err != nil {
+		return nil
+	}
+	var problem problemDetails
+	if err := json.Unmarshal(body, &problem); err != nil {
+		return nil
+	}
+
+	if !problem.hasOAuthHints() && !problem.mentionsAuth() {
+		return nil
+	}
+	return &problem
+}
+
+// hasOAuthHints reports whether the problem carries any OAuth extension members
+func (p *problemDetails) hasOAuthHints() bool {
+	return p.ResourceMetadata != "" || p.AuthorizationServer != "" || len(p.AuthorizationServers) > 0 || p.Scope != ""
+}
+
+// mentionsAuth reports whether the problem's type, title or detail refer to OAuth or authorization
+func (p *problemDetails) mentionsAuth() bool {
+	text := strings.ToLower(p.Type + " " + p.Title + " " + p.Detail)
+	for _, keyword := range []string{"oauth", "unauthorized", "authorization", "authentication", "access token"} {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizationServer returns the authorization server hint (singular form first)
+func (p *problemDetails) authorizationServer() string {
+	if p.AuthorizationServer != "" {
+		return p.AuthorizationServer
+	}
+	if len(p.AuthorizationServers) > 0 {
+		return p.AuthorizationServers[0]
+	}
+	return ""
+}
+
+// challenge converts the hints into a synthetic Bearer challenge so they flow through
+// the same resource_metadata and scope handling as a WWW-Authenticate header
+func (p *problemDetails) challenge() WWWAuthenticateChallenge {
+	parameters := map[string]string{}
+	if p.ResourceMetadata != "" {
+		parameters["resource_metadata"] = p.ResourceMetadata
+	}
+	if p.Scope != "" {
+		parameters["scope"] = p.Scope
+	}
+	return WWWAuthenticateChallenge{Scheme: "Bearer", Parameters: parameters}
+}
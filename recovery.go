@@ -0,0 +1,100 @@
+package oauth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// AuthRecoveryAction is the recommended reaction to an authenticated request being rejected
+type AuthRecoveryAction int
+
+const (
+	// AuthRecoveryNone means the response is not an OAuth failure the client can recover from
+	AuthRecoveryNone AuthRecoveryAction = iota
+
+	// AuthRecoveryRefresh means the token is invalid or expired: refresh it, or re-authorize
+	// if the refresh fails
+	AuthRecoveryRefresh
+
+	// AuthRecoveryReauthorize means the token lacks scope: run the authorization flow again
+	// requesting AuthFailure.RequiredScopes
+	AuthRecoveryReauthorize
+
+	// AuthRecoveryRediscover means the server no longer accepts the token without saying why
+	// (e.g. authorization server or resource metadata changed): run discovery again
+	AuthRecoveryRediscover
+)
+
+func (a AuthRecoveryAction) String() string {
+	switch a {
+	case AuthRecoveryRefresh:
+		return "refresh"
+	case AuthRecoveryReauthorize:
+		return "reauthorize"
+	case AuthRecoveryRediscover:
+		return "rediscover"
+	default:
+		return "none"
+	}
+}
+
+// AuthFailure describes why an authenticated request was rejected
+type AuthFailure struct {
+	Action              AuthRecoveryAction // Recommended reaction
+	Error               string             // RFC 6750 error code (invalid_token, insufficient_scope, ...)
+	ErrorDescription    string             // Human-readable error description
+	RequiredScopes      []string           // Scopes the server asked for (insufficient_scope)
+	ResourceMetadataURL string             // RFC 9728 resource_metadata advertised in the challenge
+}
+
+// AnalyzeAuthFailure inspects the response to an authenticated request and recommends how to recover
+//
+// RFC 6750 COMPLIANCE:
+// - Section 3.1: invalid_token (401) -> refresh or re-authenticate
+// - Section 3.1: insufficient_scope (403, some servers use 401) -> re-authorize with the scope parameter
+// - Section 3.1: a challenge without error code means the token was not usable at all -> re-discover
+//
+// Returns an AuthFailure with AuthRecoveryNone for responses other than 401/403
+func AnalyzeAuthFailure(resp *http.Response) *AuthFailure {
+	failure := &AuthFailure{Action: AuthRecoveryNone}
+	if resp == nil || (resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden) {
+		return failure
+	}
+
+	var challenges []WWWAuthenticateChallenge
+	if header := resp.Header.Get("WWW-Authenticate"); header != "" {
+		challenges, _ = ParseWWWAuthenticate(header)
+	}
+	for i := range challenges {
+		if !strings.EqualFold(challenges[i].Scheme, "Bearer") && !strings.EqualFold(challenges[i].Scheme, "DPoP") {
+			continue
+		}
+		failure.Error = challenges[i].MustGetParameter("error", "")
+		failure.ErrorDescription = challenges[i].MustGetParameter("error_description", "")
+		break
+	}
+	failure.RequiredScopes = FindRequiredScopes(challenges)
+	failure.ResourceMetadataURL = FindResourceMetadataURL(challenges)
+
+	switch failure.Error {
+	case "invalid_token":
+		failure.Action = AuthRecoveryRefresh
+	case "insufficient_scope":
+		failure.Action = AuthRecoveryReauthorize
+	case "":
+		// A 403 without error code is an authorization decision, not a token problem
+		if resp.StatusCode == http.StatusUnauthorized {
+			failure.Action = AuthRecoveryRediscover
+		}
+	default:
+		// invalid_request and unknown codes indicate a client bug rather than stale state
+	}
+
+	return failure
+}
+
+// ShouldRediscover reports whether the response to an authenticated request means
+// OAuth discovery must be run again (see AnalyzeAuthFailure)
+func ShouldRediscover(resp *http.Response) bool {
+	return AnalyzeAuthFailure(resp).Action == AuthRecoveryRediscover
+}
@@ -0,0 +1,78 @@
+package oauth
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestAnalyzeAuthFailure verifies the mapping from WWW-Authenticate errors to recovery actions
+func TestAnalyzeAuthFailure(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		wwwAuthenticate string
+		expectAction    AuthRecoveryAction
+		expectScopes    int
+	}{
+		{
+			name:            "invalid_token",
+			status:          http.StatusUnauthorized,
+			wwwAuthenticate: `Bearer error="invalid_token", error_description="The access token expired"`,
+			expectAction:    AuthRecoveryRefresh,
+		},
+		{
+			name:            "insufficient_scope",
+			status:          http.StatusForbidden,
+			wwwAuthenticate: `Bearer error="insufficient_scope", scope="files.read files.write"`,
+			expectAction:    AuthRecoveryReauthorize,
+			expectScopes:    2,
+		},
+		{
+			name:            "no error code with new metadata",
+			status:          http.StatusUnauthorized,
+			wwwAuthenticate: `Bearer resource_metadata="https://api.example.com/.well-known/oauth-protected-resource"`,
+			expectAction:    AuthRecoveryRediscover,
+		},
+		{
+			name:         "401 without header",
+			status:       http.StatusUnauthorized,
+			expectAction: AuthRecoveryRediscover,
+		},
+		{
+			name:            "invalid_request",
+			status:          http.StatusBadRequest,
+			wwwAuthenticate: `Bearer error="invalid_request"`,
+			expectAction:    AuthRecoveryNone,
+		},
+		{
+			name:         "403 without error",
+			status:       http.StatusForbidden,
+			expectAction: AuthRecoveryNone,
+		},
+		{
+			name:         "success",
+			status:       http.StatusOK,
+			expectAction: AuthRecoveryNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.wwwAuthenticate != "" {
+				resp.Header.Set("WWW-Authenticate", tt.wwwAuthenticate)
+			}
+
+			failure := AnalyzeAuthFailure(resp)
+			if failure.Action != tt.expectAction {
+				t.Errorf("Expected action %s, got %s", tt.expectAction, failure.Action)
+			}
+			if len(failure.RequiredScopes) != tt.expectScopes {
+				t.Errorf("Expected %d required scopes, got %v", tt.expectScopes, failure.RequiredScopes)
+			}
+			if got := ShouldRediscover(resp); got != (tt.expectAction == AuthRecoveryRediscover) {
+				t.Errorf("ShouldRediscover: expected %v, got %v", tt.expectAction == AuthRecoveryRediscover, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package oauth
+
+import (
+	"context"
+	"sync"
+)
+
+// refreshFlights deduplicates concurrent RefreshAccessToken calls for the same refresh token
+var refreshFlights = &refreshGroup{}
+
+// refreshCall is an in-flight refresh shared by all callers with the same key
+type refreshCall struct {
+	done  chan struct{}
+	token *TokenResponse
+	err   error
+}
+
+// refreshGroup is a minimal single-flight group for token refreshes
+//
+// Refresh tokens are often single-use (rotation, OAuth 2.1 Section 4.3.1): if concurrent
+// requests each redeemed the same refresh token, all but one would fail with invalid_grant
+// and some servers revoke the whole grant on reuse.
+type refreshGroup struct {
+	mu    sync.Mutex
+	calls map[string]*refreshCall
+}
+
+// do runs refresh once for all concurrent callers with the same key
+//
+// The refresh runs on a context detached from the first caller's cancellation (keeping its
+// values, e.g. the logger) and bounded by tokenRequestTimeout, so one caller giving up
+// cannot fail the others, who would then redeem the refresh token again. Every caller,
+// including the first, returns early with ctx.Err() if its own context ends first. Each
+// caller gets its own copy of the token, so callers may modify the result.
+func (g *refreshGroup) do(ctx context.Context, key string, refresh func(context.Context) (*TokenResponse, error)) (*TokenResponse, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*refreshCall)
+	}
+	call, ok := g.calls[key]
+	if !ok {
+		call = &refreshCall{done: make(chan struct{})}
+		g.calls[key] = call
+		go g.run(ctx, key, call, refresh)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return copyToken(call.token), call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run performs the shared refresh and publishes its result to every caller of call
+func (g *refreshGroup) run(ctx context.Context, key string, call *refreshCall, refresh func(context.Context) (*TokenResponse, error)) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenRequestTimeout)
+	defer cancel()
+
+	call.token, call.err = refresh(ctx)
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(call.done)
+}
+
+// copyToken returns a shallow copy of token (nil stays nil)
+func copyToken(token *TokenResponse) *TokenResponse {
+	if token == nil {
+		return nil
+	}
+	copied := *token
+	return &copied
+}
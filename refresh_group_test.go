@@ -0,0 +1,193 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForRefreshCallers waits until the token endpoint was hit and every caller has started,
+// then gives the started callers a moment to join the in-flight refresh
+func waitForRefreshCallers(t *testing.T, calls *atomic.Int32, started *sync.WaitGroup) {
+	t.Helper()
+
+	started.Wait()
+	deadline := time.Now().Add(5 * time.Second)
+	for calls.Load() < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the token endpoint call")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+}
+
+// TestRefreshAccessToken_SingleFlight verifies concurrent refreshes with the same refresh
+// token result in exactly one token endpoint call
+func TestRefreshAccessToken_SingleFlight(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		<-release
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: "new-access", TokenType: "Bearer", RefreshToken: "rotated"})
+	}))
+	defer server.Close()
+
+	discovery := &Discovery{TokenEndpoint: server.URL}
+	creds := &ClientCredentials{ClientID: "client-123"}
+
+	const goroutines = 20
+	var wg, started sync.WaitGroup
+	tokens := make(chan *TokenResponse, goroutines)
+	for range goroutines {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			token, err := RefreshAccessToken(context.Background(), discovery, creds, "single-use-refresh")
+			if err != nil {
+				t.Errorf("RefreshAccessToken failed: %v", err)
+				return
+			}
+			tokens <- token
+		}()
+	}
+
+	waitForRefreshCallers(t, &calls, &started)
+	close(release)
+	wg.Wait()
+	close(tokens)
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected exactly 1 token endpoint call, got %d", calls.Load())
+	}
+
+	seen := make(map[*TokenResponse]bool)
+	for token := range tokens {
+		if token.AccessToken != "new-access" || token.RefreshToken != "rotated" {
+			t.Errorf("Unexpected token %+v", token)
+		}
+		if seen[token] {
+			t.Error("Expected each caller to receive its own copy of the token")
+		}
+		seen[token] = true
+	}
+	if len(seen) != goroutines {
+		t.Errorf("Expected %d results, got %d", goroutines, len(seen))
+	}
+
+	// A later refresh is a new flight
+	if _, err := RefreshAccessToken(context.Background(), discovery, creds, "single-use-refresh"); err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected a new call after the flight completed, got %d", calls.Load())
+	}
+}
+
+// TestRefreshAccessToken_SingleFlightOptions verifies refreshes with different token-shaping
+// options do not share a token request
+func TestRefreshAccessToken_SingleFlightOptions(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_ = r.ParseForm()
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: "access", TokenType: "Bearer", Scope: r.PostForm.Get("scope")})
+	}))
+	defer server.Close()
+
+	discovery := &Discovery{TokenEndpoint: server.URL}
+	creds := &ClientCredentials{ClientID: "client-123"}
+
+	scopes := []string{"read", "write"}
+	results := make(chan string, len(scopes))
+	for _, scope := range scopes {
+		go func() {
+			token, err := RefreshAccessToken(context.Background(), discovery, creds, "shared-refresh", WithTokenScopes(scope))
+			if err != nil {
+				t.Errorf("RefreshAccessToken failed: %v", err)
+				results <- ""
+				return
+			}
+			results <- scope + "=" + token.Scope
+		}()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	for range scopes {
+		if result := <-results; result != "read=read" && result != "write=write" {
+			t.Errorf("Expected each caller to get a token for its own scope, got %q", result)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 token endpoint calls, got %d", calls.Load())
+	}
+}
+
+// TestRefreshAccessToken_SingleFlightCallerCanceled verifies the first caller canceling does
+// not fail the other callers sharing its refresh
+func TestRefreshAccessToken_SingleFlightCallerCanceled(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		<-release
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: "new-access", TokenType: "Bearer"})
+	}))
+	defer server.Close()
+
+	discovery := &Discovery{TokenEndpoint: server.URL}
+	creds := &ClientCredentials{ClientID: "client-123"}
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := RefreshAccessToken(firstCtx, discovery, creds, "cancel-refresh")
+		firstErr <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for calls.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	var started sync.WaitGroup
+	started.Add(1)
+	secondToken := make(chan *TokenResponse, 1)
+	go func() {
+		started.Done()
+		token, err := RefreshAccessToken(context.Background(), discovery, creds, "cancel-refresh")
+		if err != nil {
+			t.Errorf("Second caller failed: %v", err)
+		}
+		secondToken <- token
+	}()
+	waitForRefreshCallers(t, &calls, &started)
+
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled for the first caller, got %v", err)
+	}
+	close(release)
+
+	if token := <-secondToken; token == nil || token.AccessToken != "new-access" {
+		t.Errorf("Expected the second caller to receive the shared token, got %+v", token)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected exactly 1 token endpoint call, got %d", calls.Load())
+	}
+}
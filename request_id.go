@@ -0,0 +1,57 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// DefaultRequestIDHeader is the header NewRequestIDTransport uses when no name is given
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// requestIDTransport adds a request ID header to each request (see NewRequestIDTransport)
+type requestIDTransport struct {
+	base       http.RoundTripper
+	headerName string
+	generator  func() string
+}
+
+// NewRequestIDTransport returns an http.RoundTripper that adds a generated request ID to every request
+//
+// Each request sent through base (nil = http.DefaultTransport) gets a fresh ID from
+// generator in the headerName header, so the calls can be matched with the authorization
+// server's access logs. headerName defaults to DefaultRequestIDHeader and generator to a
+// random UUIDv4. Requests that already carry the header keep their value.
+func NewRequestIDTransport(base http.RoundTripper, headerName string, generator func() string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if headerName == "" {
+		headerName = DefaultRequestIDHeader
+	}
+	if generator == nil {
+		generator = newUUIDv4
+	}
+	return &requestIDTransport{base: base, headerName: headerName, generator: generator}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get(t.headerName) != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the caller's request
+	withID := req.Clone(req.Context())
+	withID.Header.Set(t.headerName, t.generator())
+	return t.base.RoundTrip(withID)
+}
+
+// newUUIDv4 returns a random RFC 9562 version 4 UUID in its canonical string form
+func newUUIDv4() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 9562 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
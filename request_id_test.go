@@ -0,0 +1,66 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+// TestNewRequestIDTransport verifies a fresh ID is sent with every request, using the
+// default header and UUIDv4 generator unless configured
+func TestNewRequestIDTransport(t *testing.T) {
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Clone())
+	}))
+	defer server.Close()
+
+	send := func(transport http.RoundTripper, header http.Header) {
+		t.Helper()
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		resp, err := (&http.Client{Transport: transport}).Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if header == nil && len(req.Header) != 0 {
+			t.Errorf("Expected the caller's request to be left unmodified, got %v", req.Header)
+		}
+	}
+
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	transport := NewRequestIDTransport(nil, "", nil)
+	send(transport, nil)
+	send(transport, nil)
+	first, second := received[0].Get(DefaultRequestIDHeader), received[1].Get(DefaultRequestIDHeader)
+	if !uuidV4.MatchString(first) || !uuidV4.MatchString(second) {
+		t.Errorf("Expected UUIDv4 request IDs, got %q and %q", first, second)
+	}
+	if first == second {
+		t.Errorf("Expected a new ID per request, got %q twice", first)
+	}
+
+	// Caller-supplied IDs are preserved
+	send(transport, http.Header{DefaultRequestIDHeader: {"caller-id"}})
+	if got := received[2].Get(DefaultRequestIDHeader); got != "caller-id" {
+		t.Errorf("Expected caller's request ID to be kept, got %q", got)
+	}
+
+	// Custom header and generator
+	custom := NewRequestIDTransport(http.DefaultTransport, "X-Correlation-Id", func() string { return "fixed" })
+	send(custom, nil)
+	if got := received[3].Get("X-Correlation-Id"); got != "fixed" {
+		t.Errorf("Expected custom header with generated ID, got %q", got)
+	}
+	if got := received[3].Get(DefaultRequestIDHeader); got != "" {
+		t.Errorf("Expected no default header with a custom name, got %q", got)
+	}
+}
@@ -0,0 +1,39 @@
+package oauth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CanonicalizeResource returns the canonical form of an RFC 8707 resource indicator
+//
+// RFC 8707 COMPLIANCE:
+// - Section 2: The resource MUST be an absolute URI and MUST NOT include a fragment
+//
+// Canonicalization lowercases the scheme and host, drops the scheme's default port
+// (:443 for https, :80 for http) and strips any fragment, so the audience requested
+// matches the identifier the resource server advertises. Path and query are kept as-is.
+func CanonicalizeResource(resource string) (string, error) {
+	parsed, err := url.Parse(resource)
+	if err != nil {
+		return "", fmt.Errorf("invalid resource %q: %w", resource, err)
+	}
+	if !parsed.IsAbs() || parsed.Host == "" {
+		return "", fmt.Errorf("resource %q must be an absolute URI", resource)
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6 literal
+	}
+	if port := parsed.Port(); port != "" && !isDefaultPort(parsed.Scheme, port) {
+		host += ":" + port
+	}
+	parsed.Host = host
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+
+	return parsed.String(), nil
+}
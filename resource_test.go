@@ -0,0 +1,43 @@
+package oauth
+
+import "testing"
+
+// TestCanonicalizeResource verifies case, default port and fragment normalization
+func TestCanonicalizeResource(t *testing.T) {
+	tests := []struct {
+		name        string
+		resource    string
+		expected    string
+		expectError bool
+	}{
+		{"already canonical", "https://api.example.com/mcp", "https://api.example.com/mcp", false},
+		{"uppercase scheme and host", "HTTPS://API.Example.COM/MCP", "https://api.example.com/MCP", false},
+		{"default https port", "https://api.example.com:443/mcp", "https://api.example.com/mcp", false},
+		{"default http port", "http://localhost:80/mcp", "http://localhost/mcp", false},
+		{"non-default port kept", "https://api.example.com:8443/mcp", "https://api.example.com:8443/mcp", false},
+		{"fragment stripped", "https://api.example.com/mcp#section", "https://api.example.com/mcp", false},
+		{"query kept", "https://api.example.com/mcp?tenant=a", "https://api.example.com/mcp?tenant=a", false},
+		{"IPv6 default port", "https://[::1]:443/mcp", "https://[::1]/mcp", false},
+		{"IPv6 custom port", "https://[::1]:8443/mcp", "https://[::1]:8443/mcp", false},
+		{"relative", "/mcp", "", true},
+		{"invalid", "https://api.example.com/%zz", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalizeResource(tt.resource)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("Expected error for %q, got %q", tt.resource, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CanonicalizeResource(%q) failed: %v", tt.resource, err)
+			}
+			if got != tt.expected {
+				t.Errorf("CanonicalizeResource(%q): expected %q, got %q", tt.resource, tt.expected, got)
+			}
+		})
+	}
+}
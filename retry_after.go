@@ -0,0 +1,54 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseRetryAfter parses a Retry-After header value into the delay from now
+//
+// RFC 7231 Section 7.1.3: the value is either delay-seconds (a non-negative integer) or an
+// HTTP-date (IMF-fixdate, or the obsolete RFC 850 and asctime formats). Dates in the past
+// yield 0. Returns false for empty or malformed values.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if value[0] >= '0' && value[0] <= '9' {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || seconds > int64(maxRetryAfter/time.Second) {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(date.Sub(now), 0), true
+}
+
+// maxRetryAfter bounds delay-seconds so the conversion to time.Duration cannot overflow
+const maxRetryAfter = 100 * 365 * 24 * time.Hour
+
+// retryAfterFromError returns the delay requested by the server response behind err
+//
+// That is TokenError.RetryAfter, or the Retry-After header of the response carried by a
+// MetadataFetchError or DCRError. Returns false when err carries no Retry-After.
+func retryAfterFromError(err error, now time.Time) (time.Duration, bool) {
+	var tokenErr *TokenError
+	if errors.As(err, &tokenErr) {
+		return tokenErr.RetryAfter, tokenErr.RetryAfter > 0
+	}
+	var respErr responseError
+	if errors.As(err, &respErr) && respErr.Response() != nil {
+		return ParseRetryAfter(respErr.Response().Header.Get("Retry-After"), now)
+	}
+	return 0, false
+}
@@ -0,0 +1,65 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestParseRetryAfter verifies delay-seconds and HTTP-date values
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"delay seconds", "120", 2 * time.Minute, true},
+		{"zero", "0", 0, true},
+		{"surrounding whitespace", " 5 ", 5 * time.Second, true},
+		{"IMF-fixdate", "Wed, 01 Jan 2025 12:01:30 GMT", 90 * time.Second, true},
+		{"RFC 850 date", "Wednesday, 01-Jan-25 12:00:10 GMT", 10 * time.Second, true},
+		{"asctime date", "Wed Jan  1 12:00:20 2025", 20 * time.Second, true},
+		{"date in the past", "Wed, 01 Jan 2025 11:00:00 GMT", 0, true},
+		{"empty", "", 0, false},
+		{"negative", "-5", 0, false},
+		{"fraction", "1.5", 0, false},
+		{"overflow", "99999999999999999999", 0, false},
+		{"garbage", "soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseRetryAfter(tt.value, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestTokenError_RetryAfter verifies the token endpoint's Retry-After is exposed on TokenError
+func TestTokenError_RetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":"slow_down"}`))
+	}))
+	defer server.Close()
+
+	discovery := &Discovery{TokenEndpoint: server.URL}
+	_, err := RefreshAccessToken(context.Background(), discovery, &ClientCredentials{ClientID: "client-123"}, "refresh-a")
+
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) {
+		t.Fatalf("Expected *TokenError, got %v", err)
+	}
+	if tokenErr.RetryAfter != 30*time.Second {
+		t.Errorf("Expected RetryAfter=30s, got %v", tokenErr.RetryAfter)
+	}
+}
@@ -0,0 +1,125 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ErrRevocationNotSupported is returned by RevokeToken when discovery found no revocation endpoint
+var ErrRevocationNotSupported = errors.New("authorization server does not advertise a revocation endpoint")
+
+// Token type hints for RevokeToken (RFC 7009 Section 2.1)
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+// RevokeToken asks the authorization server to invalidate an access or refresh token
+//
+// RFC 7009 COMPLIANCE:
+// - Section 2.1: POST token and optional token_type_hint to the revocation endpoint
+// - Section 2.2: 200 means the token is invalid now (also returned for unknown tokens)
+// - Section 2.2.1: error responses use the RFC 6749 Section 5.2 format (returned as *TokenError)
+//
+// tokenTypeHint may be empty, TokenTypeHintAccessToken or TokenTypeHintRefreshToken.
+// Returns ErrRevocationNotSupported if discovery is nil or found no revocation_endpoint.
+func RevokeToken(ctx context.Context, discovery *Discovery, creds *ClientCredentials, token, tokenTypeHint string) error {
+	if token == "" {
+		return fmt.Errorf("token is required")
+	}
+	if creds == nil || creds.ClientID == "" {
+		return fmt.Errorf("client_id is required")
+	}
+	if discovery == nil || discovery.RevocationEndpoint == "" {
+		return ErrRevocationNotSupported
+	}
+
+	form := url.Values{}
+	form.Set("token", token)
+	setIfNotEmpty(form, "token_type_hint", tokenTypeHint)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.RevocationEndpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create revocation request: %w", err)
+	}
+	// RFC 7009 Section 2.1: the client authenticates as it does at the token endpoint
+	if err := applyClientAuth(req, form, creds, resolveTokenEndpointAuthMethod(creds, "")); err != nil {
+		return err
+	}
+	setFormBody(req, form)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: tokenRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send revocation request to %s: %w", discovery.RevocationEndpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err == nil {
+		var tokenErr TokenError
+		if err := json.Unmarshal(body, &tokenErr); err == nil && tokenErr.Code != "" {
+			tokenErr.StatusCode = resp.StatusCode
+			return &tokenErr
+		}
+	}
+	return fmt.Errorf("revocation endpoint %s returned status %d", discovery.RevocationEndpoint, resp.StatusCode)
+}
+
+// Logout revokes the tokens and deletes the client registration, for a clean disconnect
+//
+// Best-effort: every step is attempted even if an earlier one fails, and the failures are
+// returned joined (errors.Join). The steps are:
+// - Revoke the refresh token first (RFC 7009 Section 2.1: this may also invalidate its access tokens)
+// - Revoke the access token
+// - Delete the registration (RFC 7592) if creds has a registration access token
+//
+// Token revocation is skipped (and logged) when the server has no revocation endpoint.
+// tokens may be nil to only delete the registration; discovery may be nil when only the
+// registration is known.
+func Logout(ctx context.Context, discovery *Discovery, creds *ClientCredentials, tokens *TokenResponse) error {
+	logger := loggerFromContext(ctx)
+
+	var errs []error
+	if tokens != nil {
+		revocations := []struct {
+			token string
+			hint  string
+		}{
+			{tokens.RefreshToken, TokenTypeHintRefreshToken},
+			{tokens.AccessToken, TokenTypeHintAccessToken},
+		}
+		for _, revocation := range revocations {
+			if revocation.token == "" {
+				continue
+			}
+			err := RevokeToken(ctx, discovery, creds, revocation.token, revocation.hint)
+			if errors.Is(err, ErrRevocationNotSupported) {
+				logger.Infof("authorization server has no revocation endpoint, skipping token revocation")
+				break
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("revoking %s: %w", revocation.hint, err))
+			}
+		}
+	}
+
+	if creds != nil && creds.RegistrationAccessToken != "" {
+		if err := DeleteClientRegistration(ctx, creds); err != nil {
+			errs = append(errs, fmt.Errorf("deleting client registration: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,154 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newMockLogoutServer serves a revocation endpoint and an RFC 7592 client configuration
+// endpoint; deleteStatus is the status returned for DELETE. Revoked tokens are recorded
+// as "hint:token".
+func newMockLogoutServer(t *testing.T, deleteStatus int) (*httptest.Server, *[]string, *bool) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var revoked []string
+	deleted := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/revoke" && r.Method == http.MethodPost:
+			if r.FormValue("client_id") != "client-123" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+				return
+			}
+			revoked = append(revoked, r.FormValue("token_type_hint")+":"+r.FormValue("token"))
+		case r.URL.Path == "/register/client-123" && r.Method == http.MethodDelete:
+			if r.Header.Get("Authorization") != "Bearer reg-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			deleted = deleteStatus == http.StatusNoContent
+			w.WriteHeader(deleteStatus)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &revoked, &deleted
+}
+
+// TestRevokeToken verifies the RFC 7009 request and error handling
+func TestRevokeToken(t *testing.T) {
+	server, revoked, _ := newMockLogoutServer(t, http.StatusNoContent)
+	discovery := &Discovery{RevocationEndpoint: server.URL + "/revoke"}
+
+	err := RevokeToken(context.Background(), discovery, &ClientCredentials{ClientID: "client-123"}, "tok", TokenTypeHintAccessToken)
+	if err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if !slices.Equal(*revoked, []string{"access_token:tok"}) {
+		t.Errorf("Expected access token revocation, got %v", *revoked)
+	}
+
+	// RFC 6749 Section 5.2 error responses are returned as *TokenError
+	err = RevokeToken(context.Background(), discovery, &ClientCredentials{ClientID: "other"}, "tok", "")
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) || tokenErr.Code != "invalid_client" {
+		t.Errorf("Expected invalid_client TokenError, got %v", err)
+	}
+
+	err = RevokeToken(context.Background(), &Discovery{}, &ClientCredentials{ClientID: "client-123"}, "tok", "")
+	if !errors.Is(err, ErrRevocationNotSupported) {
+		t.Errorf("Expected ErrRevocationNotSupported, got %v", err)
+	}
+}
+
+// TestLogout verifies full success and best-effort behavior on partial failure
+func TestLogout(t *testing.T) {
+	tests := []struct {
+		name          string
+		deleteStatus  int
+		expectDeleted bool
+		expectedError string
+	}{
+		{
+			name:          "full success",
+			deleteStatus:  http.StatusNoContent,
+			expectDeleted: true,
+		},
+		{
+			name:          "revocation works, deletion fails",
+			deleteStatus:  http.StatusInternalServerError,
+			expectedError: "deleting client registration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, revoked, deleted := newMockLogoutServer(t, tt.deleteStatus)
+			discovery := &Discovery{RevocationEndpoint: server.URL + "/revoke"}
+			creds := &ClientCredentials{
+				ClientID:                "client-123",
+				RegistrationClientURI:   server.URL + "/register/client-123",
+				RegistrationAccessToken: "reg-token",
+			}
+			tokens := &TokenResponse{AccessToken: "access", RefreshToken: "refresh"}
+
+			err := Logout(context.Background(), discovery, creds, tokens)
+			if tt.expectedError == "" && err != nil {
+				t.Fatalf("Logout failed: %v", err)
+			}
+			if tt.expectedError != "" && (err == nil || !strings.Contains(err.Error(), tt.expectedError)) {
+				t.Fatalf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+
+			// Both tokens are revoked regardless of the deletion outcome, refresh token first
+			expected := []string{"refresh_token:refresh", "access_token:access"}
+			if !slices.Equal(*revoked, expected) {
+				t.Errorf("Expected revocations %v, got %v", expected, *revoked)
+			}
+			if *deleted != tt.expectDeleted {
+				t.Errorf("Expected deleted=%v, got %v", tt.expectDeleted, *deleted)
+			}
+		})
+	}
+}
+
+// TestLogout_NilDiscovery verifies a logout without discovery skips revocation and still
+// deletes the registration
+func TestLogout_NilDiscovery(t *testing.T) {
+	server, revoked, deleted := newMockLogoutServer(t, http.StatusNoContent)
+	creds := &ClientCredentials{
+		ClientID:                "client-123",
+		RegistrationClientURI:   server.URL + "/register/client-123",
+		RegistrationAccessToken: "reg-token",
+	}
+
+	if err := Logout(context.Background(), nil, creds, nil); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if err := Logout(context.Background(), nil, creds, &TokenResponse{AccessToken: "access"}); err != nil {
+		t.Fatalf("Logout with tokens failed: %v", err)
+	}
+	if len(*revoked) != 0 {
+		t.Errorf("Expected no revocations, got %v", *revoked)
+	}
+	if !*deleted {
+		t.Error("Expected the registration to be deleted")
+	}
+
+	if err := RevokeToken(context.Background(), nil, creds, "access", ""); !errors.Is(err, ErrRevocationNotSupported) {
+		t.Errorf("Expected ErrRevocationNotSupported for nil discovery, got %v", err)
+	}
+}
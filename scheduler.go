@@ -0,0 +1,276 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Defaults for RefreshScheduler
+const (
+	DefaultRefreshLeadTime = time.Minute     // Refresh this long before the token expires
+	DefaultRefreshMinRetry = 5 * time.Second // First retry delay after a failed refresh
+	DefaultRefreshMaxRetry = 5 * time.Minute // Upper bound for the doubling retry delay
+)
+
+// RefreshScheduler proactively refreshes many tokens before they expire
+//
+// Each tracked token is refreshed at its expiry minus the lead time. On success the new
+// token is reported to the refresh callback and rescheduled from its expires_in; a token
+// without expires_in is no longer scheduled. Failed refreshes are reported to the error
+// handler and retried according to the BackoffStrategy (by default DefaultRefreshMinRetry
+// doubling up to DefaultRefreshMaxRetry, without limit) until they succeed, the strategy
+// gives up or the entry is removed. A retry is never scheduled earlier than the token
+// endpoint's Retry-After (TokenError.RetryAfter).
+//
+// Entries can be added and removed while Run is active. RefreshScheduler is safe for concurrent use.
+type RefreshScheduler struct {
+	clock     Clock
+	leadTime  time.Duration
+	backoff   BackoffStrategy
+	onRefresh func(key string, token *TokenResponse)
+	onError   func(key string, err error)
+
+	mu      sync.Mutex
+	entries map[string]*scheduledToken
+	wake    chan struct{} // Signals Run to recompute the next deadline
+}
+
+// scheduledToken is a token tracked by a RefreshScheduler
+type scheduledToken struct {
+	discovery *Discovery
+	creds     *ClientCredentials
+	token     *TokenResponse
+	next      time.Time // When to refresh (zero = not scheduled)
+	failures  int       // Consecutive failed refreshes
+	inFlight  bool
+}
+
+// RefreshSchedulerOption configures a RefreshScheduler
+type RefreshSchedulerOption func(*RefreshScheduler)
+
+// WithRefreshLeadTime sets how long before expiry tokens are refreshed (default DefaultRefreshLeadTime)
+func WithRefreshLeadTime(lead time.Duration) RefreshSchedulerOption {
+	return func(s *RefreshScheduler) {
+		s.leadTime = lead
+	}
+}
+
+// WithRefreshRetryBackoff sets the first and maximum delay between retries of a failed refresh
+// Shorthand for WithRefreshBackoff(ExponentialBackoff(minDelay, maxDelay, 0))
+func WithRefreshRetryBackoff(minDelay, maxDelay time.Duration) RefreshSchedulerOption {
+	return WithRefreshBackoff(ExponentialBackoff(minDelay, maxDelay, 0))
+}
+
+// WithRefreshBackoff sets the strategy for retrying failed refreshes
+// When the strategy gives up, the token stays tracked but is no longer refreshed until re-added
+func WithRefreshBackoff(strategy BackoffStrategy) RefreshSchedulerOption {
+	return func(s *RefreshScheduler) {
+		s.backoff = strategy
+	}
+}
+
+// WithScheduledRefreshCallback calls fn with the new token after every successful refresh,
+// so callers can persist rotated refresh tokens
+func WithScheduledRefreshCallback(fn func(key string, token *TokenResponse)) RefreshSchedulerOption {
+	return func(s *RefreshScheduler) {
+		s.onRefresh = fn
+	}
+}
+
+// WithScheduledRefreshErrorHandler calls fn after every failed refresh (before the retry is scheduled)
+func WithScheduledRefreshErrorHandler(fn func(key string, err error)) RefreshSchedulerOption {
+	return func(s *RefreshScheduler) {
+		s.onError = fn
+	}
+}
+
+// WithSchedulerClock sets the clock used for scheduling (defaults to the real clock)
+func WithSchedulerClock(clock Clock) RefreshSchedulerOption {
+	return func(s *RefreshScheduler) {
+		s.clock = clock
+	}
+}
+
+// NewRefreshScheduler creates an empty RefreshScheduler; call Run to start refreshing
+func NewRefreshScheduler(opts ...RefreshSchedulerOption) *RefreshScheduler {
+	s := &RefreshScheduler{
+		clock:    realClock{},
+		leadTime: DefaultRefreshLeadTime,
+		backoff:  ExponentialBackoff(DefaultRefreshMinRetry, DefaultRefreshMaxRetry, 0),
+		entries:  make(map[string]*scheduledToken),
+		wake:     make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add tracks a token under key, replacing any entry with the same key
+//
+// expiresAt is when the access token expires (typically the issue time plus ExpiresIn);
+// the token is refreshed at expiresAt minus the lead time, or immediately if that has passed.
+// token must carry a refresh token.
+func (s *RefreshScheduler) Add(key string, discovery *Discovery, creds *ClientCredentials, token *TokenResponse, expiresAt time.Time) error {
+	if token == nil || token.RefreshToken == "" {
+		return fmt.Errorf("token for %q has no refresh token", key)
+	}
+
+	s.mu.Lock()
+	s.entries[key] = &scheduledToken{
+		discovery: discovery,
+		creds:     creds,
+		token:     token,
+		next:      expiresAt.Add(-s.leadTime),
+	}
+	s.mu.Unlock()
+
+	s.signal()
+	return nil
+}
+
+// Remove stops tracking the token under key
+// A refresh already in flight completes, but its result is discarded
+func (s *RefreshScheduler) Remove(key string) {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+
+	s.signal()
+}
+
+// Token returns the current token for key, which changes after each refresh
+func (s *RefreshScheduler) Token(key string) (*TokenResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return entry.token, true
+}
+
+// Run refreshes tokens as they come due until ctx is canceled
+// It waits for in-flight refreshes to finish and returns ctx.Err(). Run must not be called concurrently.
+func (s *RefreshScheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		now := s.clock.Now()
+		var due []string
+		var earliest time.Time
+
+		s.mu.Lock()
+		for key, entry := range s.entries {
+			if entry.inFlight || entry.next.IsZero() {
+				continue
+			}
+			if !entry.next.After(now) {
+				entry.inFlight = true
+				due = append(due, key)
+				continue
+			}
+			if earliest.IsZero() || entry.next.Before(earliest) {
+				earliest = entry.next
+			}
+		}
+		s.mu.Unlock()
+
+		for _, key := range due {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.refresh(ctx, key)
+			}()
+		}
+
+		var timer <-chan time.Time
+		if !earliest.IsZero() {
+			timer = s.clock.After(earliest.Sub(now))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.wake:
+		case <-timer:
+		}
+	}
+}
+
+// refresh refreshes the entry under key and schedules its next refresh or retry
+func (s *RefreshScheduler) refresh(ctx context.Context, key string) {
+	s.mu.Lock()
+	entry, ok := s.entries[key]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	refreshed, err := RefreshAccessToken(ctx, entry.discovery, entry.creds, entry.token.RefreshToken)
+	if err == nil && refreshed.RefreshToken == "" {
+		// RFC 6749 Section 6: the refresh token is only replaced if the server issued a new one
+		refreshed.RefreshToken = entry.token.RefreshToken
+	}
+
+	s.mu.Lock()
+	// The entry was removed or replaced while the refresh was in flight
+	if s.entries[key] != entry {
+		s.mu.Unlock()
+		return
+	}
+	now := s.clock.Now()
+	entry.inFlight = false
+	var retryIn time.Duration
+	retry := false
+	failures := 0
+	if err != nil {
+		entry.failures++
+		failures = entry.failures
+		retryIn, retry = s.backoff.NextDelay(failures, err)
+		// Never retry sooner than the token endpoint asked for (Retry-After), also with custom strategies
+		if retryAfter, ok := retryAfterFromError(err, now); retry && ok && retryAfter > retryIn {
+			retryIn = retryAfter
+		}
+		entry.next = time.Time{}
+		if retry {
+			entry.next = now.Add(retryIn)
+		}
+	} else {
+		entry.failures = 0
+		entry.token = refreshed
+		entry.next = time.Time{}
+		if refreshed.ExpiresIn > 0 {
+			entry.next = now.Add(time.Duration(refreshed.ExpiresIn)*time.Second - s.leadTime)
+		}
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		if ctx.Err() == nil {
+			if retry {
+				loggerFromContext(ctx).Warnf("scheduled token refresh for %s failed, retrying in %s: %v", key, retryIn, err)
+			} else {
+				loggerFromContext(ctx).Warnf("scheduled token refresh for %s failed, giving up after %d attempts: %v", key, failures, err)
+			}
+		}
+		if s.onError != nil {
+			s.onError(key, err)
+		}
+	} else if s.onRefresh != nil {
+		s.onRefresh(key, refreshed)
+	}
+	s.signal()
+}
+
+// signal wakes Run so it picks up changed entries
+func (s *RefreshScheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,243 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newSchedulerTokenServer starts a token endpoint that fails the first failures requests
+// and then issues numbered access tokens valid for one hour
+func newSchedulerTokenServer(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := calls.Add(1)
+		if n <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: fmt.Sprint("access-", n),
+			TokenType:   "Bearer",
+			ExpiresIn:   3600,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// expectNoRefresh fails if a value arrives on ch within a short grace period
+func expectNoRefresh[T any](t *testing.T, ch <-chan T) {
+	t.Helper()
+	select {
+	case v := <-ch:
+		t.Fatalf("Unexpected refresh result %v", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// awaitRefresh returns the next value on ch or fails after a timeout
+func awaitRefresh[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for refresh")
+	}
+	var zero T
+	return zero
+}
+
+// TestRefreshScheduler_RefreshesBeforeExpiry verifies refreshes fire at expiry minus the
+// lead time and are rescheduled from the new token's expires_in
+func TestRefreshScheduler_RefreshesBeforeExpiry(t *testing.T) {
+	server, calls := newSchedulerTokenServer(t, 0)
+	clock := newFakeClock()
+	refreshed := make(chan *TokenResponse, 1)
+
+	scheduler := NewRefreshScheduler(
+		WithSchedulerClock(clock),
+		WithRefreshLeadTime(time.Minute),
+		WithScheduledRefreshCallback(func(_ string, token *TokenResponse) { refreshed <- token }),
+	)
+	err := scheduler.Add("server-a", &Discovery{TokenEndpoint: server.URL}, &ClientCredentials{ClientID: "client-123"},
+		&TokenResponse{AccessToken: "initial", RefreshToken: "refresh-a"}, clock.Now().Add(10*time.Minute))
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Run(ctx) }()
+
+	clock.Advance(8 * time.Minute)
+	expectNoRefresh(t, refreshed)
+
+	clock.Advance(time.Minute)
+	token := awaitRefresh(t, refreshed)
+	if token.AccessToken != "access-1" || token.RefreshToken != "refresh-a" {
+		t.Errorf("Unexpected refreshed token %+v", token)
+	}
+	if current, _ := scheduler.Token("server-a"); current.AccessToken != "access-1" {
+		t.Errorf("Expected Token to return the refreshed token, got %+v", current)
+	}
+
+	// Rescheduled 59 minutes later (expires_in 3600 minus the lead time)
+	clock.Advance(58 * time.Minute)
+	expectNoRefresh(t, refreshed)
+	clock.Advance(time.Minute)
+	if token := awaitRefresh(t, refreshed); token.AccessToken != "access-2" {
+		t.Errorf("Expected second refresh, got %+v", token)
+	}
+
+	cancel()
+	if err := awaitRefresh(t, done); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from Run, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 token requests, got %d", calls.Load())
+	}
+}
+
+// TestRefreshScheduler_RetriesWithBackoff verifies failed refreshes are reported and retried
+// after a doubling delay
+func TestRefreshScheduler_RetriesWithBackoff(t *testing.T) {
+	server, calls := newSchedulerTokenServer(t, 2)
+	clock := newFakeClock()
+	refreshed := make(chan *TokenResponse, 1)
+	failed := make(chan error, 1)
+
+	scheduler := NewRefreshScheduler(
+		WithSchedulerClock(clock),
+		WithRefreshRetryBackoff(10*time.Second, time.Minute),
+		WithScheduledRefreshCallback(func(_ string, token *TokenResponse) { refreshed <- token }),
+		WithScheduledRefreshErrorHandler(func(_ string, err error) { failed <- err }),
+	)
+	// Already inside the lead time, so the first refresh is immediate
+	err := scheduler.Add("server-a", &Discovery{TokenEndpoint: server.URL}, &ClientCredentials{ClientID: "client-123"},
+		&TokenResponse{AccessToken: "initial", RefreshToken: "refresh-a"}, clock.Now())
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Run(ctx) }()
+
+	awaitRefresh(t, failed)
+
+	clock.Advance(9 * time.Second)
+	expectNoRefresh(t, failed)
+	clock.Advance(time.Second)
+	awaitRefresh(t, failed)
+
+	// Second retry waits twice as long
+	clock.Advance(19 * time.Second)
+	expectNoRefresh(t, refreshed)
+	clock.Advance(time.Second)
+	if token := awaitRefresh(t, refreshed); token.AccessToken != "access-3" {
+		t.Errorf("Expected token from third attempt, got %+v", token)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 token requests, got %d", calls.Load())
+	}
+}
+
+// TestRefreshScheduler_RetryAfter verifies a retry waits for the token endpoint's Retry-After
+// when it is longer than the backoff delay
+func TestRefreshScheduler_RetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"temporarily_unavailable"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: "access-2", TokenType: "Bearer", ExpiresIn: 3600})
+	}))
+	t.Cleanup(server.Close)
+
+	clock := newFakeClock()
+	refreshed := make(chan *TokenResponse, 1)
+	failed := make(chan error, 1)
+	scheduler := NewRefreshScheduler(
+		WithSchedulerClock(clock),
+		WithRefreshRetryBackoff(10*time.Second, time.Minute),
+		WithScheduledRefreshCallback(func(_ string, token *TokenResponse) { refreshed <- token }),
+		WithScheduledRefreshErrorHandler(func(_ string, err error) { failed <- err }),
+	)
+	err := scheduler.Add("server-a", &Discovery{TokenEndpoint: server.URL}, &ClientCredentials{ClientID: "client-123"},
+		&TokenResponse{AccessToken: "initial", RefreshToken: "refresh-a"}, clock.Now())
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Run(ctx) }()
+
+	awaitRefresh(t, failed)
+
+	clock.Advance(29 * time.Second)
+	expectNoRefresh(t, refreshed)
+	clock.Advance(time.Second)
+	if token := awaitRefresh(t, refreshed); token.AccessToken != "access-2" {
+		t.Errorf("Expected token from the retry, got %+v", token)
+	}
+}
+
+// TestRefreshScheduler_Add verifies tokens without a refresh token are rejected
+func TestRefreshScheduler_Add(t *testing.T) {
+	scheduler := NewRefreshScheduler()
+	if err := scheduler.Add("server-a", &Discovery{}, &ClientCredentials{}, &TokenResponse{AccessToken: "access"}, time.Now()); err == nil {
+		t.Error("Expected error for token without refresh token")
+	}
+	if _, ok := scheduler.Token("server-a"); ok {
+		t.Error("Expected rejected token not to be tracked")
+	}
+}
+
+// TestRefreshScheduler_BackoffGivesUp verifies a strategy that stops retrying leaves the token unscheduled
+func TestRefreshScheduler_BackoffGivesUp(t *testing.T) {
+	server, calls := newSchedulerTokenServer(t, 100)
+	clock := newFakeClock()
+	failed := make(chan error, 1)
+
+	scheduler := NewRefreshScheduler(
+		WithSchedulerClock(clock),
+		WithRefreshBackoff(ConstantBackoff(time.Second, 2)),
+		WithScheduledRefreshErrorHandler(func(_ string, err error) { failed <- err }),
+	)
+	err := scheduler.Add("server-a", &Discovery{TokenEndpoint: server.URL}, &ClientCredentials{ClientID: "client-123"},
+		&TokenResponse{AccessToken: "initial", RefreshToken: "refresh-a"}, clock.Now())
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Run(ctx) }()
+
+	awaitRefresh(t, failed)
+	clock.Advance(time.Second)
+	awaitRefresh(t, failed)
+
+	clock.Advance(time.Hour)
+	expectNoRefresh(t, failed)
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 token requests before giving up, got %d", calls.Load())
+	}
+	if _, ok := scheduler.Token("server-a"); !ok {
+		t.Error("Expected the token to stay tracked after giving up")
+	}
+}
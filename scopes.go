@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrUnsupportedScope is returned when a requested scope is not in the supported scope list
+var ErrUnsupportedScope = errors.New("unsupported scope")
+
+// ParseScopes splits a scope string into individual scopes
+//
+// RFC 6749 Section 3.3 defines scope as a space-delimited list, but some authorization
+// servers use commas or "+" (e.g. a form-encoded space left undecoded). All three are
+// accepted as delimiters; scopes are trimmed, empty entries dropped and duplicates removed,
+// keeping the order of first appearance.
+func ParseScopes(raw string) []string {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == '+' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+
+	var scopes []string
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		scope := strings.TrimSpace(field)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// ValidateScopes checks that every requested scope appears in the supported list
+//
+// Returns an error wrapping ErrUnsupportedScope that lists the unsupported scopes in request order.
+// An empty supported list means nothing is advertised, so every request passes.
+func ValidateScopes(requested, supported []string) error {
+	if len(supported) == 0 {
+		return nil
+	}
+
+	var unsupported []string
+	for _, scope := range requested {
+		if !slices.Contains(supported, scope) && !slices.Contains(unsupported, scope) {
+			unsupported = append(unsupported, scope)
+		}
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnsupportedScope, strings.Join(unsupported, " "))
+	}
+	return nil
+}
+
+// ScopeMatchesPrefix reports whether scope belongs to the resource named by prefix
+//
+// Multi-resource authorization servers often namespace scopes as "<resource>:<action>"
+// (e.g. "files:read"). The prefix may be given with or without the trailing ":"; matching
+// stops at the separator so "files" matches "files:read" and "files" but not "filesystem:read".
+// An empty prefix matches nothing.
+func ScopeMatchesPrefix(scope, prefix string) bool {
+	resource := strings.TrimSuffix(prefix, ":")
+	if resource == "" {
+		return false
+	}
+	return scope == resource || strings.HasPrefix(scope, resource+":")
+}
+
+// FilterScopesByPrefix returns the scopes that match prefix (see ScopeMatchesPrefix), in order
+func FilterScopesByPrefix(scopes []string, prefix string) []string {
+	var filtered []string
+	for _, scope := range scopes {
+		if ScopeMatchesPrefix(scope, prefix) {
+			filtered = append(filtered, scope)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,105 @@
+package oauth
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// TestParseScopes verifies space, comma and plus delimiters, trimming and deduplication
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected []string
+	}{
+		{"space", "read write", []string{"read", "write"}},
+		{"comma", "read,write", []string{"read", "write"}},
+		{"comma with spaces", "read, write ,admin", []string{"read", "write", "admin"}},
+		{"plus", "read+write", []string{"read", "write"}},
+		{"mixed", "read+write, admin  openid", []string{"read", "write", "admin", "openid"}},
+		{"duplicates", "read write read,write", []string{"read", "write"}},
+		{"empty entries", " ,read,,+ ", []string{"read"}},
+		{"URI scopes", "https://graph.example.com/.default offline_access", []string{"https://graph.example.com/.default", "offline_access"}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseScopes(tt.raw); !slices.Equal(got, tt.expected) {
+				t.Errorf("ParseScopes(%q): expected %v, got %v", tt.raw, tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestValidateScopes verifies requested scopes are checked against the supported list
+func TestValidateScopes(t *testing.T) {
+	tests := []struct {
+		name        string
+		requested   []string
+		supported   []string
+		expectError string
+	}{
+		{"subset", []string{"read"}, []string{"read", "write"}, ""},
+		{"equal", []string{"read", "write"}, []string{"read", "write"}, ""},
+		{"nothing requested", nil, []string{"read"}, ""},
+		{"nothing advertised", []string{"admin"}, nil, ""},
+		{"unsupported", []string{"read", "admin", "delete", "admin"}, []string{"read", "write"}, "unsupported scope: admin delete"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateScopes(tt.requested, tt.supported)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnsupportedScope) {
+				t.Fatalf("Expected ErrUnsupportedScope, got %v", err)
+			}
+			if err.Error() != tt.expectError {
+				t.Errorf("Expected error %q, got %q", tt.expectError, err.Error())
+			}
+		})
+	}
+}
+
+// TestScopeMatchesPrefix verifies prefix matching stops at the ":" separator
+func TestScopeMatchesPrefix(t *testing.T) {
+	tests := []struct {
+		scope    string
+		prefix   string
+		expected bool
+	}{
+		{"files:read", "files", true},
+		{"files:read", "files:", true},
+		{"files", "files", true},
+		{"files:admin:write", "files", true},
+		{"files:admin:write", "files:admin", true},
+		{"filesystem:read", "files", false},
+		{"mail:read", "files", false},
+		{"files:read", "", false},
+		{"read", ":", false},
+	}
+
+	for _, tt := range tests {
+		if got := ScopeMatchesPrefix(tt.scope, tt.prefix); got != tt.expected {
+			t.Errorf("ScopeMatchesPrefix(%q, %q): expected %v, got %v", tt.scope, tt.prefix, tt.expected, got)
+		}
+	}
+}
+
+// TestFilterScopesByPrefix verifies only matching scopes are returned, in order
+func TestFilterScopesByPrefix(t *testing.T) {
+	scopes := []string{"files:read", "mail:send", "files:write", "openid", "filesystem:read"}
+
+	if got := FilterScopesByPrefix(scopes, "files"); !slices.Equal(got, []string{"files:read", "files:write"}) {
+		t.Errorf("Expected files scopes, got %v", got)
+	}
+	if got := FilterScopesByPrefix(scopes, "calendar"); got != nil {
+		t.Errorf("Expected no scopes, got %v", got)
+	}
+}
@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// ServerSelectionCriteria lists the capabilities SelectAuthorizationServer prefers
+type ServerSelectionCriteria struct {
+	PreferPKCE bool // Prefer servers supporting PKCE with S256 (RFC 7636)
+	PreferDPoP bool // Prefer servers advertising dpop_signing_alg_values_supported (RFC 9449)
+	PreferPAR  bool // Prefer servers with a pushed_authorization_request_endpoint (RFC 9126)
+}
+
+// score returns how many of the preferred capabilities metadata supports
+func (c ServerSelectionCriteria) score(metadata *AuthorizationServerMetadata) int {
+	score := 0
+	if c.PreferPKCE && slices.Contains(metadata.CodeChallengeMethodsSupported, "S256") {
+		score++
+	}
+	if c.PreferDPoP && len(metadata.DPoPSigningAlgValuesSupported) > 0 {
+		score++
+	}
+	if c.PreferPAR && metadata.PushedAuthorizationRequestEndpoint != "" {
+		score++
+	}
+	return score
+}
+
+// SelectAuthorizationServer picks the best of several authorization servers, such as the
+// authorization_servers listed in protected resource metadata
+//
+// RFC 9728 COMPLIANCE:
+// - Section 2: authorization_servers may list several issuers; the client chooses which to use
+//
+// RFC 8414 metadata of every server is fetched in parallel. The server supporting the most
+// preferred capabilities wins; ties go to the server listed first. Servers whose metadata
+// cannot be fetched are skipped, and an error joining their failures is returned only if
+// none succeeds.
+func SelectAuthorizationServer(ctx context.Context, servers []string, criteria ServerSelectionCriteria) (string, *AuthorizationServerMetadata, error) {
+	if len(servers) == 0 {
+		return "", nil, fmt.Errorf("no authorization servers to select from")
+	}
+
+	client := &http.Client{Timeout: discoveryTimeout}
+	metadata := make([]*AuthorizationServerMetadata, len(servers))
+	errs := make([]error, len(servers))
+
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metadata[i], errs[i] = fetchAuthorizationServerMetadata(ctx, client, server, false)
+		}()
+	}
+	wg.Wait()
+
+	logger := loggerFromContext(ctx)
+	best, bestScore := -1, -1
+	for i, server := range servers {
+		if errs[i] != nil {
+			logger.Warnf("skipping authorization server %s: %v", server, errs[i])
+			errs[i] = fmt.Errorf("%s: %w", server, errs[i])
+			continue
+		}
+		if score := criteria.score(metadata[i]); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return "", nil, fmt.Errorf("no authorization server metadata could be fetched: %w", errors.Join(errs...))
+	}
+
+	logger.Infof("selected authorization server %s (%d preferred capabilities)", servers[best], bestScore)
+	return servers[best], metadata[best], nil
+}
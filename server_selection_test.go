@@ -0,0 +1,82 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newSelectionAuthServer starts an authorization server whose metadata is completed by configure
+func newSelectionAuthServer(t *testing.T, configure func(*AuthorizationServerMetadata)) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/oauth-authorization-server" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		metadata := AuthorizationServerMetadata{
+			Issuer:                server.URL,
+			AuthorizationEndpoint: server.URL + "/authorize",
+			TokenEndpoint:         server.URL + "/token",
+		}
+		configure(&metadata)
+		_ = json.NewEncoder(w).Encode(metadata)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestSelectAuthorizationServer verifies the server matching the most preferred capabilities
+// is chosen, ties keep the listed order and unreachable servers are skipped
+func TestSelectAuthorizationServer(t *testing.T) {
+	plain := newSelectionAuthServer(t, func(*AuthorizationServerMetadata) {})
+	pkce := newSelectionAuthServer(t, func(m *AuthorizationServerMetadata) {
+		m.CodeChallengeMethodsSupported = []string{"S256"}
+	})
+	full := newSelectionAuthServer(t, func(m *AuthorizationServerMetadata) {
+		m.CodeChallengeMethodsSupported = []string{"S256"}
+		m.DPoPSigningAlgValuesSupported = []string{"ES256"}
+		m.PushedAuthorizationRequestEndpoint = m.Issuer + "/par"
+	})
+	broken := httptest.NewServer(http.NotFoundHandler())
+	defer broken.Close()
+
+	tests := []struct {
+		name     string
+		servers  []string
+		criteria ServerSelectionCriteria
+		expected string
+	}{
+		{"most capabilities wins", []string{plain.URL, pkce.URL, full.URL}, ServerSelectionCriteria{PreferPKCE: true, PreferDPoP: true, PreferPAR: true}, full.URL},
+		{"single criterion", []string{plain.URL, pkce.URL}, ServerSelectionCriteria{PreferPKCE: true}, pkce.URL},
+		{"tie keeps listed order", []string{pkce.URL, full.URL}, ServerSelectionCriteria{PreferPKCE: true}, pkce.URL},
+		{"no criteria picks first", []string{plain.URL, full.URL}, ServerSelectionCriteria{}, plain.URL},
+		{"unreachable server skipped", []string{broken.URL, plain.URL}, ServerSelectionCriteria{PreferPAR: true}, plain.URL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, metadata, err := SelectAuthorizationServer(context.Background(), tt.servers, tt.criteria)
+			if err != nil {
+				t.Fatalf("SelectAuthorizationServer failed: %v", err)
+			}
+			if selected != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, selected)
+			}
+			if metadata == nil || metadata.Issuer != tt.expected {
+				t.Errorf("Expected metadata of %s, got %+v", tt.expected, metadata)
+			}
+		})
+	}
+
+	if _, _, err := SelectAuthorizationServer(context.Background(), []string{broken.URL}, ServerSelectionCriteria{}); err == nil {
+		t.Error("Expected error when no server metadata can be fetched")
+	}
+	if _, _, err := SelectAuthorizationServer(context.Background(), nil, ServerSelectionCriteria{}); err == nil {
+		t.Error("Expected error for empty server list")
+	}
+}
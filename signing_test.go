@@ -0,0 +1,103 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestHMACSigningTransport verifies the signature header covers the timestamp and body
+func TestHMACSigningTransport(t *testing.T) {
+	secret := []byte("shared-secret")
+	clock := newFakeClock()
+	timestamp := fmt.Sprint(clock.Now().Unix())
+
+	var received atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received.Store(r.Header.Get("X-Request-Signature") + "|" + string(body))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: HMACSigningTransport(secret, "X-Request-Signature", WithSigningClock(clock))}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"with body", "grant_type=client_credentials"},
+		{"without body", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, body)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			expected := fmt.Sprintf("t=%s,v1=%s|%s", timestamp, SignHMAC(secret, timestamp, []byte(tt.body)), tt.body)
+			if got := received.Load(); got != expected {
+				t.Errorf("Expected %q, got %q", expected, got)
+			}
+			if req.Header.Get("X-Request-Signature") != "" {
+				t.Error("Signing must not modify the caller's request")
+			}
+		})
+	}
+}
+
+// TestHMACSigningTransport_DefaultHeader verifies DefaultSignatureHeader is used when no name is given
+func TestHMACSigningTransport_DefaultHeader(t *testing.T) {
+	var header atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		header.Store(r.Header.Get(DefaultSignatureHeader))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: HMACSigningTransport([]byte("secret"), "")}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got, _ := header.Load().(string); !strings.HasPrefix(got, "t=") || !strings.Contains(got, ",v1=") {
+		t.Errorf("Expected signature header, got %q", got)
+	}
+}
+
+// TestSignHMAC verifies the signature depends on the secret, timestamp and body
+func TestSignHMAC(t *testing.T) {
+	base := SignHMAC([]byte("secret"), "1700000000", []byte("body"))
+	if len(base) != 64 {
+		t.Fatalf("Expected 64 hex characters, got %d", len(base))
+	}
+	if SignHMAC([]byte("other"), "1700000000", []byte("body")) == base {
+		t.Error("Expected signature to depend on the secret")
+	}
+	if SignHMAC([]byte("secret"), "1700000001", []byte("body")) == base {
+		t.Error("Expected signature to depend on the timestamp")
+	}
+	if SignHMAC([]byte("secret"), "1700000000", []byte("other")) == base {
+		t.Error("Expected signature to depend on the body")
+	}
+}
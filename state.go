@@ -0,0 +1,195 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultStateTTL is how long a pending authorization stays valid in a StateStore
+const DefaultStateTTL = 10 * time.Minute
+
+var (
+	// ErrStateNotFound is returned when a callback state is unknown (or was already used)
+	ErrStateNotFound = errors.New("unknown authorization state")
+
+	// ErrStateExpired is returned when a callback arrives after the pending authorization expired
+	ErrStateExpired = errors.New("authorization state expired")
+)
+
+// PendingAuthorization is the data bound to a state value between the authorization
+// request and the callback
+//
+// Binding the PKCE verifier to the state prevents authorization code injection:
+// a code delivered with a foreign state cannot be redeemed with our verifier (RFC 9700 Section 4.5)
+type PendingAuthorization struct {
+	CodeVerifier string    // PKCE code verifier for the token request
+	RedirectURI  string    // redirect_uri sent in the authorization request (must match at token time)
+	Resource     string    // RFC 8707 resource indicator sent in the authorization request
+	ExpiresAt    time.Time // When this pending authorization stops being valid
+}
+
+// StateStore maps state values to pending authorizations
+//
+// Implementations must be safe for concurrent use. Consume must be single-use:
+// a state can be redeemed at most once.
+type StateStore interface {
+	// Save stores a pending authorization under the given state
+	Save(state string, pending *PendingAuthorization) error
+
+	// Consume returns and removes the pending authorization for state
+	// Returns ErrStateNotFound or ErrStateExpired if it cannot be redeemed
+	Consume(state string) (*PendingAuthorization, error)
+}
+
+// MemoryStateStore is an in-memory StateStore with per-entry expiry
+type MemoryStateStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	clock   Clock
+	pending map[string]*PendingAuthorization
+}
+
+// StateStoreOption configures a MemoryStateStore
+type StateStoreOption func(*MemoryStateStore)
+
+// WithStateStoreClock sets the clock used for expiry (defaults to the real clock)
+func WithStateStoreClock(clock Clock) StateStoreOption {
+	return func(s *MemoryStateStore) {
+		s.clock = clock
+	}
+}
+
+// NewMemoryStateStore creates an in-memory state store
+// ttl is applied to entries saved without an ExpiresAt (0 = DefaultStateTTL)
+func NewMemoryStateStore(ttl time.Duration, opts ...StateStoreOption) *MemoryStateStore {
+	if ttl <= 0 {
+		ttl = DefaultStateTTL
+	}
+	store := &MemoryStateStore{
+		ttl:     ttl,
+		clock:   realClock{},
+		pending: make(map[string]*PendingAuthorization),
+	}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store
+}
+
+// Save stores a pending authorization under the given state
+func (s *MemoryStateStore) Save(state string, pending *PendingAuthorization) error {
+	if state == "" {
+		return fmt.Errorf("state must not be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+
+	// Drop expired entries so abandoned flows don't accumulate
+	for key, entry := range s.pending {
+		if now.After(entry.ExpiresAt) {
+			delete(s.pending, key)
+		}
+	}
+
+	stored := *pending
+	if stored.ExpiresAt.IsZero() {
+		stored.ExpiresAt = now.Add(s.ttl)
+	}
+	s.pending[state] = &stored
+	return nil
+}
+
+// Consume returns and removes the pending authorization for state
+func (s *MemoryStateStore) Consume(state string) (*PendingAuthorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending, ok := s.pending[state]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	delete(s.pending, state)
+
+	if s.clock.Now().After(pending.ExpiresAt) {
+		return nil, ErrStateExpired
+	}
+	return pending, nil
+}
+
+// GenerateState returns a random base64url-encoded nonce suitable for the state parameter
+// (32 bytes of entropy, RFC 6749 Section 10.12)
+func GenerateState() (string, error) {
+	state, err := randomURLSafeString(32)
+	if err != nil {
+		return "", fmt.Errorf("generating state: %w", err)
+	}
+	return state, nil
+}
+
+// StartAuthorization begins an interactive authorization flow
+//
+// Generates a state nonce and a PKCE verifier, binds them together in the store
+// and returns the authorization URL to send the user to.
+func StartAuthorization(store StateStore, discovery *Discovery, creds *ClientCredentials, redirectURI string) (string, error) {
+	if discovery == nil {
+		return "", fmt.Errorf("discovery is required")
+	}
+	state, err := GenerateState()
+	if err != nil {
+		return "", err
+	}
+
+	verifier, challenge, err := GeneratePKCE()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := NewAuthorizationRequest(redirectURI, state, discovery.Scopes).
+		WithPKCE(challenge).
+		Build(discovery, creds)
+	if err != nil {
+		return "", err
+	}
+
+	// Record the resource exactly as sent in the authorization request (RFC 8707 Section 2)
+	resource := discovery.ResourceURL
+	if resource != "" {
+		if resource, err = CanonicalizeResource(resource); err != nil {
+			return "", fmt.Errorf("invalid resource indicator: %w", err)
+		}
+	}
+
+	err = store.Save(state, &PendingAuthorization{
+		CodeVerifier: verifier,
+		RedirectURI:  redirectURI,
+		Resource:     resource,
+	})
+	if err != nil {
+		return "", fmt.Errorf("saving authorization state: %w", err)
+	}
+
+	return authURL, nil
+}
+
+// ResumeAuthorization completes the callback side of an interactive authorization flow
+//
+// Parses the callback URL and looks up the pending authorization bound to its state,
+// returning the authorization code together with the PKCE verifier needed to redeem it.
+func ResumeAuthorization(store StateStore, callbackURL string, opts ...CallbackOption) (*AuthorizationCallbackResult, *PendingAuthorization, error) {
+	result, err := ParseAuthorizationCallback(callbackURL, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pending, err := store.Consume(result.State)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, pending, nil
+}
@@ -0,0 +1,97 @@
+package oauth
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestMemoryStateStore_SaveConsume verifies store/retrieve and single-use semantics
+func TestMemoryStateStore_SaveConsume(t *testing.T) {
+	store := NewMemoryStateStore(time.Minute)
+
+	err := store.Save("state-1", &PendingAuthorization{
+		CodeVerifier: "verifier-1",
+		RedirectURI:  DefaultRedirectURI,
+		Resource:     "https://api.example.com",
+	})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	pending, err := store.Consume("state-1")
+	if err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if pending.CodeVerifier != "verifier-1" {
+		t.Errorf("Expected verifier-1, got %s", pending.CodeVerifier)
+	}
+	if pending.RedirectURI != DefaultRedirectURI {
+		t.Errorf("Expected RedirectURI=%s, got %s", DefaultRedirectURI, pending.RedirectURI)
+	}
+
+	// State is single-use
+	if _, err := store.Consume("state-1"); !errors.Is(err, ErrStateNotFound) {
+		t.Errorf("Expected ErrStateNotFound on second use, got %v", err)
+	}
+}
+
+// TestMemoryStateStore_Expired verifies that expired states are rejected
+func TestMemoryStateStore_Expired(t *testing.T) {
+	store := NewMemoryStateStore(time.Minute)
+
+	err := store.Save("state-1", &PendingAuthorization{
+		CodeVerifier: "verifier-1",
+		ExpiresAt:    time.Now().Add(-time.Second),
+	})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if _, err := store.Consume("state-1"); !errors.Is(err, ErrStateExpired) {
+		t.Errorf("Expected ErrStateExpired, got %v", err)
+	}
+}
+
+// TestStartResumeAuthorization verifies the state binds the PKCE verifier across the flow
+func TestStartResumeAuthorization(t *testing.T) {
+	store := NewMemoryStateStore(0)
+	discovery := &Discovery{
+		AuthorizationEndpoint: "https://auth.example.com/authorize",
+		ResourceURL:           "HTTPS://API.Example.com:443",
+	}
+	creds := &ClientCredentials{ClientID: "client-123"}
+
+	authURL, err := StartAuthorization(store, discovery, creds, DefaultRedirectURI)
+	if err != nil {
+		t.Fatalf("StartAuthorization failed: %v", err)
+	}
+
+	parsed, _ := url.Parse(authURL)
+	state := parsed.Query().Get("state")
+	challenge := parsed.Query().Get("code_challenge")
+	if state == "" || challenge == "" {
+		t.Fatalf("Expected state and code_challenge in %s", authURL)
+	}
+
+	result, pending, err := ResumeAuthorization(store, DefaultRedirectURI+"?code=abc&state="+url.QueryEscape(state))
+	if err != nil {
+		t.Fatalf("ResumeAuthorization failed: %v", err)
+	}
+	if result.Code != "abc" {
+		t.Errorf("Expected code=abc, got %s", result.Code)
+	}
+	if pkceChallenge(pending.CodeVerifier) != challenge {
+		t.Error("Stored verifier does not match the challenge sent in the authorization request")
+	}
+	// The canonical resource is stored, matching the one sent in the authorization request
+	if pending.Resource != "https://api.example.com" || pending.Resource != parsed.Query().Get("resource") {
+		t.Errorf("Expected Resource=https://api.example.com, got %s", pending.Resource)
+	}
+
+	// Unknown state is rejected
+	if _, _, err := ResumeAuthorization(store, DefaultRedirectURI+"?code=abc&state=forged"); !errors.Is(err, ErrStateNotFound) {
+		t.Errorf("Expected ErrStateNotFound for forged state, got %v", err)
+	}
+}
@@ -2,7 +2,9 @@ package oauth
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 )
 
 // testLogger captures log messages for test verification
@@ -32,3 +34,69 @@ func (l *testLogger) containsInfo(substr string) bool {
 	}
 	return false
 }
+
+func (l *testLogger) containsWarn(substr string) bool {
+	for _, msg := range l.warns {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *testLogger) containsDebug(substr string) bool {
+	for _, msg := range l.debugs {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestLogger is a Logger that records every line, for verifying log output in tests
+//
+// Lines are recorded as "INFO: ", "WARN: " or "DEBUG: " followed by the formatted message.
+// A TestLogger is safe for concurrent use.
+type TestLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// NewTestLogger returns a TestLogger and a function returning a copy of the lines recorded so far
+//
+//	logger, lines := oauth.NewTestLogger()
+//	discovery, err := oauth.DiscoverOAuthRequirements(oauth.WithLogger(ctx, logger), serverURL)
+//	for _, line := range lines() { ... }
+func NewTestLogger() (*TestLogger, func() []string) {
+	logger := &TestLogger{}
+	return logger, logger.Lines
+}
+
+// Infof records an informational message
+func (l *TestLogger) Infof(format string, args ...any) {
+	l.record("INFO", format, args)
+}
+
+// Warnf records a warning
+func (l *TestLogger) Warnf(format string, args ...any) {
+	l.record("WARN", format, args)
+}
+
+// Debugf records a debug message
+func (l *TestLogger) Debugf(format string, args ...any) {
+	l.record("DEBUG", format, args)
+}
+
+// Lines returns a copy of the lines recorded so far
+func (l *TestLogger) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.lines)
+}
+
+func (l *TestLogger) record(level, format string, args []any) {
+	line := level + ": " + fmt.Sprintf(format, args...)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, line)
+}
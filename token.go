@@ -0,0 +1,342 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// tokenRequestTimeout bounds a single token endpoint request
+const tokenRequestTimeout = 30 * time.Second
+
+// ExchangeAuthorizationCode redeems an authorization code at the token endpoint
+//
+// RFC 6749 COMPLIANCE:
+// - Section 4.1.3: Access Token Request (grant_type=authorization_code, code, redirect_uri, client_id)
+//
+// RFC 7636 COMPLIANCE:
+// - Section 4.5: code_verifier proves possession of the PKCE challenge
+//
+// RFC 8707 COMPLIANCE:
+// - Section 2.2: resource is sent so the token is audience-bound to the MCP server
+//
+// Returns *TokenError when the server answers with an RFC 6749 Section 5.2 error response
+//
+// OPTIONS: See TokenOption (e.g. WithTokenScopes) to customize the request
+func ExchangeAuthorizationCode(ctx context.Context, discovery *Discovery, creds *ClientCredentials, code, codeVerifier, redirectURI string, opts ...TokenOption) (*TokenResponse, error) {
+	if discovery == nil {
+		return nil, fmt.Errorf("discovery is required")
+	}
+	if code == "" {
+		return nil, fmt.Errorf("authorization code is required")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	setIfNotEmpty(form, "redirect_uri", redirectURI)
+	setIfNotEmpty(form, "code_verifier", codeVerifier)
+	if err := setResourceIndicator(form, discovery); err != nil {
+		return nil, err
+	}
+
+	return requestToken(ctx, discovery, creds, form, newTokenConfig(opts))
+}
+
+// RefreshAccessToken obtains a new access token using a refresh token
+//
+// RFC 6749 COMPLIANCE:
+// - Section 6: Refreshing an Access Token (grant_type=refresh_token, refresh_token)
+//
+// RFC 8707 COMPLIANCE:
+// - Section 2.2: resource is re-sent (as in ExchangeAuthorizationCode) so the new token keeps its audience
+//
+// The server may rotate the refresh token; callers must store TokenResponse.RefreshToken
+// when it is non-empty. Returns *TokenError for RFC 6749 Section 5.2 error responses.
+//
+// SINGLE-FLIGHT: concurrent calls with the same refresh token (and client, token endpoint,
+// resource and token-shaping options: scopes, client authentication method, DPoP key)
+// share one network request and all receive its result, so a burst of requests noticing
+// an expired token cannot redeem a rotating refresh token more than once. The shared
+// request is not canceled when one caller's ctx ends (it is bounded by its own timeout);
+// each caller stops waiting when its own ctx ends.
+//
+// OPTIONS: See TokenOption (e.g. WithTokenScopes to narrow the scope)
+func RefreshAccessToken(ctx context.Context, discovery *Discovery, creds *ClientCredentials, refreshToken string, opts ...TokenOption) (*TokenResponse, error) {
+	if discovery == nil {
+		return nil, fmt.Errorf("discovery is required")
+	}
+	if refreshToken == "" {
+		return nil, fmt.Errorf("refresh token is required")
+	}
+
+	config := newTokenConfig(opts)
+	return refreshFlights.do(ctx, refreshFlightKey(discovery, creds, refreshToken, config), func(ctx context.Context) (*TokenResponse, error) {
+		form := url.Values{}
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", refreshToken)
+		if err := setResourceIndicator(form, discovery); err != nil {
+			return nil, err
+		}
+
+		return requestToken(ctx, discovery, creds, form, config)
+	})
+}
+
+// refreshFlightKey identifies refreshes that may share one token request: everything that
+// shapes the issued token (endpoint, client, resource, scopes, auth method, DPoP key binding)
+// is part of the key, so a caller never receives a token minted for different parameters
+func refreshFlightKey(discovery *Discovery, creds *ClientCredentials, refreshToken string, config *tokenConfig) string {
+	parts := []string{
+		discovery.TokenEndpoint, discovery.ResourceURL, refreshToken,
+		strings.Join(config.scopes, config.scopeSeparator), config.authMethod,
+	}
+	if creds != nil {
+		parts = append(parts, creds.TokenEndpoint, creds.ClientID)
+	}
+	if config.dpop != nil {
+		parts = append(parts, config.dpop.jwk["x"], config.dpop.jwk["y"])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// setResourceIndicator sets the canonical RFC 8707 resource parameter from discovery.ResourceURL, if any
+func setResourceIndicator(form url.Values, discovery *Discovery) error {
+	if discovery.ResourceURL == "" {
+		return nil
+	}
+	resource, err := CanonicalizeResource(discovery.ResourceURL)
+	if err != nil {
+		return fmt.Errorf("invalid resource indicator: %w", err)
+	}
+	form.Set("resource", resource)
+	return nil
+}
+
+// requestToken posts a token request and parses the success or error response
+func requestToken(ctx context.Context, discovery *Discovery, creds *ClientCredentials, form url.Values, config *tokenConfig) (*TokenResponse, error) {
+	if creds == nil || creds.ClientID == "" {
+		return nil, fmt.Errorf("client_id is required")
+	}
+
+	// Credentials record the token endpoint at registration time; fall back to discovery
+	tokenEndpoint := creds.TokenEndpoint
+	if tokenEndpoint == "" {
+		tokenEndpoint = discovery.TokenEndpoint
+	}
+	if tokenEndpoint == "" {
+		return nil, fmt.Errorf("no token endpoint in discovery or credentials")
+	}
+
+	setIfNotEmpty(form, "scope", strings.Join(config.scopes, config.scopeSeparator))
+
+	return withSpan(ctx, SpanToken, "token", tokenEndpoint, func(ctx context.Context) (*TokenResponse, error) {
+		return exchangeAtTokenEndpoint(ctx, tokenEndpoint, creds, form, config)
+	})
+}
+
+// exchangeAtTokenEndpoint sends the token request (retrying once for a DPoP nonce) and parses the response
+func exchangeAtTokenEndpoint(ctx context.Context, tokenEndpoint string, creds *ClientCredentials, form url.Values, config *tokenConfig) (*TokenResponse, error) {
+	resp, body, err := sendTokenRequest(ctx, tokenEndpoint, creds, form, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 9449 Section 8: the server requires a nonce in the DPoP proof; the nonce it sent
+	// has been stored by sendTokenRequest, so retry once with it
+	if config.dpop != nil && resp.StatusCode == http.StatusBadRequest &&
+		resp.Header.Get(dpopNonceHeader) != "" && tokenErrorCode(body) == "use_dpop_nonce" {
+		loggerFromContext(ctx).Infof("token endpoint %s requires a DPoP nonce, retrying", tokenEndpoint)
+		resp, body, err = sendTokenRequest(ctx, tokenEndpoint, creds, form, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// RFC 6749 Section 5.2: structured error response
+		var tokenErr TokenError
+		if err := json.Unmarshal(body, &tokenErr); err == nil && tokenErr.Code != "" {
+			tokenErr.StatusCode = resp.StatusCode
+			tokenErr.RetryAfter, _ = ParseRetryAfter(resp.Header.Get("Retry-After"), config.clock.Now())
+			return nil, &tokenErr
+		}
+		return nil, fmt.Errorf("token endpoint %s returned status %d", tokenEndpoint, resp.StatusCode)
+	}
+
+	var token TokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("parsing token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token response missing access_token")
+	}
+
+	token.IssuedAt = config.clock.Now()
+
+	if config.checkSkew {
+		if err := CheckClockSkew(token.AccessToken, token.IssuedAt, config.skew); err != nil {
+			loggerFromContext(ctx).Warnf("possible clock skew between this machine and %s: %v", tokenEndpoint, err)
+		}
+	}
+
+	return &token, nil
+}
+
+// sendTokenRequest posts form to the token endpoint with client authentication (and a DPoP
+// proof if configured) and returns the response with its body read and closed
+func sendTokenRequest(ctx context.Context, tokenEndpoint string, creds *ClientCredentials, form url.Values, config *tokenConfig) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenEndpoint, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	if err := applyClientAuth(req, form, creds, resolveTokenEndpointAuthMethod(creds, config.authMethod)); err != nil {
+		return nil, nil, err
+	}
+	setFormBody(req, form)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if config.dpop != nil {
+		proof, err := config.dpop.Proof(http.MethodPost, tokenEndpoint, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("DPoP", proof)
+	}
+
+	client := &http.Client{Timeout: tokenRequestTimeout}
+	if config.circuitBreaker != nil {
+		client.Transport = config.circuitBreaker.Transport(nil)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to send token request to %s: %w", tokenEndpoint, err)
+	}
+	defer resp.Body.Close()
+	setSpanStatus(ctx, resp.StatusCode)
+
+	if config.dpop != nil {
+		config.dpop.UpdateNonce(tokenEndpoint, resp.Header)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading token response: %w", err)
+	}
+	return resp, body, nil
+}
+
+// tokenErrorCode returns the RFC 6749 Section 5.2 error code of a token error body, or ""
+func tokenErrorCode(body []byte) string {
+	var tokenErr TokenError
+	if err := json.Unmarshal(body, &tokenErr); err != nil {
+		return ""
+	}
+	return tokenErr.Code
+}
+
+// setFormBody sets form as the application/x-www-form-urlencoded request body
+func setFormBody(req *http.Request, form url.Values) {
+	encoded := form.Encode()
+	req.Body = io.NopCloser(strings.NewReader(encoded))
+	req.ContentLength = int64(len(encoded))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(encoded)), nil
+	}
+}
+
+// Token types defined for the token_type response field
+const (
+	TokenTypeBearer = "Bearer" // RFC 6750
+	TokenTypeDPoP   = "DPoP"   // RFC 9449
+)
+
+// Bearer token presentation methods (RFC 6750 Section 2, advertised via RFC 9728 bearer_methods_supported)
+const (
+	BearerMethodHeader = "header" // Section 2.1: Authorization request header
+	BearerMethodBody   = "body"   // Section 2.2: access_token form-encoded body parameter
+	BearerMethodQuery  = "query"  // Section 2.3: access_token URI query parameter
+)
+
+// AuthorizationHeader returns the Authorization header value for using the access token
+//
+// RFC 6749 Section 7.1: token_type is case-insensitive and determines how the token is used.
+// Bearer tokens (RFC 6750 Section 2.1) yield "Bearer <token>"; a missing token_type is
+// treated as Bearer for compatibility. Returns "" for token types that need additional
+// proof, like DPoP (RFC 9449, which requires a DPoP proof header per request) or mac,
+// and when there is no access token.
+func (t *TokenResponse) AuthorizationHeader() string {
+	if t.AccessToken == "" {
+		return ""
+	}
+	if t.TokenType == "" || strings.EqualFold(t.TokenType, TokenTypeBearer) {
+		return TokenTypeBearer + " " + t.AccessToken
+	}
+	return ""
+}
+
+// ExpiresAt returns when the access token expires (IssuedAt plus ExpiresIn)
+// Returns the zero time when either is unknown
+func (t *TokenResponse) ExpiresAt() time.Time {
+	if t.IssuedAt.IsZero() || t.ExpiresIn <= 0 {
+		return time.Time{}
+	}
+	return t.IssuedAt.Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// IsExpired reports whether the access token has expired according to clock (nil = real clock)
+// Tokens without a known expiry (see ExpiresAt) are never reported as expired.
+func (t *TokenResponse) IsExpired(clock Clock) bool {
+	expiresAt := t.ExpiresAt()
+	if expiresAt.IsZero() {
+		return false
+	}
+	if clock == nil {
+		clock = realClock{}
+	}
+	return !clock.Now().Before(expiresAt)
+}
+
+// GrantedScopes returns the scopes granted by the authorization server
+//
+// RFC 6749 Section 3.3: The server may grant fewer scopes than requested (downscoping).
+// Returns nil when the response omitted scope, which means the granted scope is
+// identical to the requested scope.
+func (t *TokenResponse) GrantedScopes() []string {
+	if t.Scope == "" {
+		return nil
+	}
+	return strings.Fields(t.Scope)
+}
+
+// HasScope reports whether scope is listed in the granted scopes
+// (always false when the response omitted scope - see GrantedScopes)
+func (t *TokenResponse) HasScope(scope string) bool {
+	return slices.Contains(t.GrantedScopes(), scope)
+}
+
+// MissingScopes returns the requested scopes that were not granted
+//
+// Gateways use this to surface downscoping, e.g. "requested admin but only got read".
+// Returns nil when the response omitted scope (granted == requested per RFC 6749 Section 5.1).
+func (t *TokenResponse) MissingScopes(requested []string) []string {
+	if t.Scope == "" {
+		return nil
+	}
+
+	granted := t.GrantedScopes()
+	var missing []string
+	for _, scope := range requested {
+		if !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,108 @@
+package oauth
+
+import "time"
+
+// defaultScopeSeparator is the RFC 6749 Section 3.3 scope delimiter
+const defaultScopeSeparator = " "
+
+// TokenOption configures ExchangeAuthorizationCode and RefreshAccessToken
+type TokenOption func(*tokenConfig)
+
+// tokenConfig holds the settings applied by TokenOption values
+type tokenConfig struct {
+	scopes         []string        // scope sent in the token request (omitted when empty)
+	scopeSeparator string          // Delimiter used to join scopes
+	checkSkew      bool            // Warn when JWT access token timestamps disagree with clock
+	skew           time.Duration   // Tolerance for the clock skew check
+	clock          Clock           // Clock used for IssuedAt, the skew check and Retry-After
+	authMethod     string          // Client authentication override (empty = from credentials)
+	dpop           *DPoPProver     // Sends DPoP proofs when set (RFC 9449)
+	circuitBreaker *CircuitBreaker // Shared per-host circuit breaker (nil = disabled)
+}
+
+// newTokenConfig applies options over the defaults
+func newTokenConfig(opts []TokenOption) *tokenConfig {
+	config := &tokenConfig{
+		scopeSeparator: defaultScopeSeparator,
+		clock:          realClock{},
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.scopeSeparator == "" {
+		config.scopeSeparator = defaultScopeSeparator
+	}
+	if config.skew <= 0 {
+		config.skew = DefaultClockSkew
+	}
+	return config
+}
+
+// WithTokenScopes sends a scope parameter in the token request
+//
+// RFC 6749 Section 6: a refresh request may ask for a subset of the originally granted scopes
+func WithTokenScopes(scopes ...string) TokenOption {
+	return func(c *tokenConfig) {
+		c.scopes = scopes
+	}
+}
+
+// WithTokenScopeSeparator sets the delimiter used to join the scope parameter (default: space)
+//
+// INTEROP WORKAROUND: see WithScopeSeparator
+func WithTokenScopeSeparator(separator string) TokenOption {
+	return func(c *tokenConfig) {
+		c.scopeSeparator = separator
+	}
+}
+
+// WithClockSkewCheck logs a warning when a JWT access token's iat, nbf or exp claims
+// disagree with the local clock by more than skew (0 = DefaultClockSkew)
+//
+// Informational only (see CheckClockSkew): the token is still returned, and opaque
+// tokens are ignored. Useful to diagnose machines with a wrong system clock.
+func WithClockSkewCheck(skew time.Duration) TokenOption {
+	return func(c *tokenConfig) {
+		c.checkSkew = true
+		c.skew = skew
+	}
+}
+
+// WithTokenClock sets the clock used for TokenResponse.IssuedAt, the clock skew check and
+// Retry-After dates (defaults to the real clock)
+func WithTokenClock(clock Clock) TokenOption {
+	return func(c *tokenConfig) {
+		c.clock = clock
+	}
+}
+
+// WithTokenEndpointAuthMethod overrides how the client authenticates to the token endpoint
+//
+// Supported: TokenEndpointAuthNone, TokenEndpointAuthClientSecretPost and
+// TokenEndpointAuthClientSecretBasic. By default the method recorded in
+// ClientCredentials.TokenEndpointAuthMethod is used, falling back to client_secret_post
+// for public clients and client_secret_basic for confidential clients.
+func WithTokenEndpointAuthMethod(method string) TokenOption {
+	return func(c *tokenConfig) {
+		c.authMethod = method
+	}
+}
+
+// WithDPoP sends a DPoP proof with the token request so the issued token is key-bound (RFC 9449)
+//
+// If the server answers 400 use_dpop_nonce with a DPoP-Nonce header, the nonce is stored
+// on the prover and the request is retried once. Use a TokenResponse with token_type DPoP
+// through DPoPProver.AuthorizeRequest rather than TokenResponse.AuthorizationHeader.
+func WithDPoP(prover *DPoPProver) TokenOption {
+	return func(c *tokenConfig) {
+		c.dpop = prover
+	}
+}
+
+// WithTokenCircuitBreaker fails token requests fast with ErrCircuitOpen while the token
+// endpoint host's circuit is open (see CircuitBreaker)
+func WithTokenCircuitBreaker(breaker *CircuitBreaker) TokenOption {
+	return func(c *tokenConfig) {
+		c.circuitBreaker = breaker
+	}
+}
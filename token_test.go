@@ -0,0 +1,312 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// TestTokenResponse_GrantedScopes verifies downscoping detection
+func TestTokenResponse_GrantedScopes(t *testing.T) {
+	requested := []string{"read", "admin"}
+
+	tests := []struct {
+		name          string
+		scope         string
+		expectGranted int
+		expectAdmin   bool
+		expectMissing []string
+	}{
+		{
+			name:          "granted subset",
+			scope:         "read",
+			expectGranted: 1,
+			expectAdmin:   false,
+			expectMissing: []string{"admin"},
+		},
+		{
+			name:          "granted equal",
+			scope:         "admin read",
+			expectGranted: 2,
+			expectAdmin:   true,
+			expectMissing: nil,
+		},
+		{
+			name:          "scope omitted (identical to requested)",
+			scope:         "",
+			expectGranted: 0,
+			expectAdmin:   false,
+			expectMissing: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &TokenResponse{AccessToken: "at", TokenType: "Bearer", Scope: tt.scope}
+
+			if granted := token.GrantedScopes(); len(granted) != tt.expectGranted {
+				t.Errorf("Expected %d granted scopes, got %v", tt.expectGranted, granted)
+			}
+			if got := token.HasScope("admin"); got != tt.expectAdmin {
+				t.Errorf("HasScope(admin): expected %v, got %v", tt.expectAdmin, got)
+			}
+
+			missing := token.MissingScopes(requested)
+			if len(missing) != len(tt.expectMissing) {
+				t.Fatalf("Expected missing %v, got %v", tt.expectMissing, missing)
+			}
+			for i := range missing {
+				if missing[i] != tt.expectMissing[i] {
+					t.Errorf("Missing scope %d: expected %s, got %s", i, tt.expectMissing[i], missing[i])
+				}
+			}
+		})
+	}
+}
+
+// newMockTokenServer starts a token endpoint that records the last form it received
+// and answers with the given status and JSON body
+func newMockTokenServer(t *testing.T, status int, response any, form *url.Values) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+			t.Errorf("Expected form content type, got %s", ct)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse form: %v", err)
+		}
+		if form != nil {
+			*form = r.PostForm
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestExchangeAuthorizationCode verifies the authorization code grant request and response parsing
+func TestExchangeAuthorizationCode(t *testing.T) {
+	var form url.Values
+	server := newMockTokenServer(t, http.StatusOK, TokenResponse{
+		AccessToken:  "access-123",
+		TokenType:    "Bearer",
+		ExpiresIn:    3600,
+		RefreshToken: "refresh-123",
+	}, &form)
+
+	discovery := &Discovery{
+		TokenEndpoint: server.URL + "/token",
+		ResourceURL:   "https://api.example.com/mcp",
+	}
+	creds := &ClientCredentials{ClientID: "client-123", IsPublic: true}
+
+	token, err := ExchangeAuthorizationCode(context.Background(), discovery, creds, "code-abc", "verifier-xyz", DefaultRedirectURI)
+	if err != nil {
+		t.Fatalf("ExchangeAuthorizationCode failed: %v", err)
+	}
+
+	if token.AccessToken != "access-123" || token.RefreshToken != "refresh-123" || token.ExpiresIn != 3600 {
+		t.Errorf("Unexpected token response: %+v", token)
+	}
+
+	expected := map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          "code-abc",
+		"code_verifier": "verifier-xyz",
+		"redirect_uri":  DefaultRedirectURI,
+		"client_id":     "client-123",
+		"resource":      "https://api.example.com/mcp",
+	}
+	for key, value := range expected {
+		if got := form.Get(key); got != value {
+			t.Errorf("Expected %s=%s, got %s", key, value, got)
+		}
+	}
+}
+
+// TestRefreshAccessToken verifies the refresh token grant request
+func TestRefreshAccessToken(t *testing.T) {
+	var form url.Values
+	server := newMockTokenServer(t, http.StatusOK, TokenResponse{
+		AccessToken: "access-456",
+		TokenType:   "Bearer",
+	}, &form)
+
+	// Token endpoint recorded on the credentials takes precedence over discovery
+	discovery := &Discovery{TokenEndpoint: "https://unused.example.com/token"}
+	creds := &ClientCredentials{ClientID: "client-123", TokenEndpoint: server.URL + "/token"}
+
+	token, err := RefreshAccessToken(context.Background(), discovery, creds, "refresh-123")
+	if err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+
+	if token.AccessToken != "access-456" {
+		t.Errorf("Expected access-456, got %s", token.AccessToken)
+	}
+	if form.Get("grant_type") != "refresh_token" || form.Get("refresh_token") != "refresh-123" {
+		t.Errorf("Unexpected refresh form: %v", form)
+	}
+}
+
+// TestRefreshAccessToken_Resource verifies the refresh request re-sends the canonical
+// resource indicator, like the authorization code exchange
+func TestRefreshAccessToken_Resource(t *testing.T) {
+	var form url.Values
+	server := newMockTokenServer(t, http.StatusOK, TokenResponse{
+		AccessToken: "access-789",
+		TokenType:   "Bearer",
+	}, &form)
+
+	discovery := &Discovery{
+		TokenEndpoint: server.URL + "/token",
+		ResourceURL:   "HTTPS://API.example.com:443/mcp",
+	}
+	creds := &ClientCredentials{ClientID: "client-123", IsPublic: true}
+
+	if _, err := RefreshAccessToken(context.Background(), discovery, creds, "refresh-resource"); err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	if got := form.Get("resource"); got != "https://api.example.com/mcp" {
+		t.Errorf("Expected resource=https://api.example.com/mcp, got %q", got)
+	}
+
+	// Without a resource URL no resource parameter is sent
+	discovery.ResourceURL = ""
+	if _, err := RefreshAccessToken(context.Background(), discovery, creds, "refresh-no-resource"); err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	if form.Has("resource") {
+		t.Errorf("Expected no resource parameter, got %q", form.Get("resource"))
+	}
+}
+
+// TestTokenError verifies RFC 6749 Section 5.2 error responses are surfaced as *TokenError
+func TestTokenError(t *testing.T) {
+	server := newMockTokenServer(t, http.StatusBadRequest, map[string]string{
+		"error":             "invalid_grant",
+		"error_description": "The authorization code has expired",
+		"error_uri":         "https://auth.example.com/docs/errors#invalid_grant",
+	}, nil)
+
+	discovery := &Discovery{TokenEndpoint: server.URL + "/token"}
+	creds := &ClientCredentials{ClientID: "client-123"}
+
+	_, err := ExchangeAuthorizationCode(context.Background(), discovery, creds, "expired-code", "verifier", DefaultRedirectURI)
+
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) {
+		t.Fatalf("Expected *TokenError, got %T: %v", err, err)
+	}
+	if tokenErr.Code != "invalid_grant" {
+		t.Errorf("Expected Code=invalid_grant, got %s", tokenErr.Code)
+	}
+	if tokenErr.Description != "The authorization code has expired" {
+		t.Errorf("Unexpected Description: %s", tokenErr.Description)
+	}
+	if tokenErr.URI != "https://auth.example.com/docs/errors#invalid_grant" {
+		t.Errorf("Unexpected URI: %s", tokenErr.URI)
+	}
+	if tokenErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected StatusCode=400, got %d", tokenErr.StatusCode)
+	}
+
+	expected := "token request failed: invalid_grant: The authorization code has expired (see https://auth.example.com/docs/errors#invalid_grant)"
+	if tokenErr.Error() != expected {
+		t.Errorf("Expected error %q, got %q", expected, tokenErr.Error())
+	}
+}
+
+// TestTokenResponse_AuthorizationHeader verifies header formatting per token type
+func TestTokenResponse_AuthorizationHeader(t *testing.T) {
+	tests := []struct {
+		tokenType string
+		expected  string
+	}{
+		{"Bearer", "Bearer at-123"},
+		{"bearer", "Bearer at-123"},
+		{"", "Bearer at-123"},
+		{"DPoP", ""},
+		{"mac", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tokenType, func(t *testing.T) {
+			token := &TokenResponse{AccessToken: "at-123", TokenType: tt.tokenType}
+			if got := token.AuthorizationHeader(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if got := (&TokenResponse{TokenType: "Bearer"}).AuthorizationHeader(); got != "" {
+		t.Errorf("Expected empty header without access token, got %q", got)
+	}
+}
+
+// TestRefreshAccessToken_ScopeSeparator verifies the scope parameter of token requests
+// uses spaces by default and the configured separator otherwise
+func TestRefreshAccessToken_ScopeSeparator(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []TokenOption
+		expectedScope string
+	}{
+		{
+			name:          "no scope",
+			expectedScope: "",
+		},
+		{
+			name:          "space (default)",
+			opts:          []TokenOption{WithTokenScopes("read", "write")},
+			expectedScope: "read write",
+		},
+		{
+			name:          "comma",
+			opts:          []TokenOption{WithTokenScopes("read", "write"), WithTokenScopeSeparator(",")},
+			expectedScope: "read,write",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var form url.Values
+			server := newMockTokenServer(t, http.StatusOK, TokenResponse{AccessToken: "access", TokenType: "Bearer"}, &form)
+			discovery := &Discovery{TokenEndpoint: server.URL + "/token"}
+			creds := &ClientCredentials{ClientID: "client-123"}
+
+			if _, err := RefreshAccessToken(context.Background(), discovery, creds, "refresh-123", tt.opts...); err != nil {
+				t.Fatalf("RefreshAccessToken failed: %v", err)
+			}
+			if got := form.Get("scope"); got != tt.expectedScope {
+				t.Errorf("Expected scope %q, got %q", tt.expectedScope, got)
+			}
+			if tt.expectedScope == "" && form.Has("scope") {
+				t.Error("Expected scope to be omitted")
+			}
+		})
+	}
+}
+
+// TestTokenRequests_NilDiscovery verifies token requests without discovery fail instead of panicking
+func TestTokenRequests_NilDiscovery(t *testing.T) {
+	creds := &ClientCredentials{ClientID: "client-123", TokenEndpoint: "https://auth.example.com/token"}
+
+	if _, err := ExchangeAuthorizationCode(context.Background(), nil, creds, "code", "verifier", DefaultRedirectURI); err == nil {
+		t.Error("Expected error from ExchangeAuthorizationCode without discovery")
+	}
+	if _, err := RefreshAccessToken(context.Background(), nil, creds, "refresh"); err == nil {
+		t.Error("Expected error from RefreshAccessToken without discovery")
+	}
+}
@@ -0,0 +1,88 @@
+package oauth
+
+import "context"
+
+// Tracer starts spans for discovery, DCR and token operations
+//
+// It mirrors the shape of OpenTelemetry's trace.Tracer so an adapter is a few lines, without
+// this package depending on OpenTelemetry. Attach one with WithTracer; StartSpan returns the
+// context child spans should be started from. Implementations must be safe for concurrent use.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, Span)
+}
+
+// Span is an in-progress operation started by a Tracer
+type Span interface {
+	SetAttributes(attrs ...SpanAttribute) // Add attributes (e.g. the HTTP status once known)
+	End(err error)                        // Finish the span; err is nil on success
+}
+
+// SpanAttribute is a key/value pair attached to a span
+type SpanAttribute struct {
+	Key   string
+	Value any // string or int
+}
+
+// Span names used by this package
+const (
+	SpanDiscovery          = "oauth.discovery"                      // DiscoverOAuthRequirements as a whole
+	SpanProbe              = "oauth.discovery.probe"                // Unauthenticated MCP server request
+	SpanResourceMetadata   = "oauth.discovery.resource_metadata"    // RFC 9728 metadata fetch
+	SpanAuthServerMetadata = "oauth.discovery.auth_server_metadata" // RFC 8414 metadata fetch
+	SpanDCR                = "oauth.dcr"                            // RFC 7591 client registration
+	SpanToken              = "oauth.token"                          // Token endpoint request
+)
+
+// Span attribute keys used by this package
+const (
+	AttrStage      = "oauth.stage"               // Operation stage (e.g. "probe", "token")
+	AttrURL        = "url.full"                  // Request URL
+	AttrStatusCode = "http.response.status_code" // HTTP response status
+)
+
+// WithTracer attaches a tracer to the context
+func WithTracer(ctx context.Context, tracer Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey, tracer)
+}
+
+type (
+	tracerContextKey struct{}
+	spanContextKey   struct{}
+)
+
+var (
+	tracerKey = tracerContextKey{}
+	spanKey   = spanContextKey{}
+)
+
+// startSpan starts a span for stage on the context's tracer (a noop span if none is set)
+// The returned context carries the span for setSpanStatus
+func startSpan(ctx context.Context, name, stage, url string) (context.Context, Span) {
+	tracer, ok := ctx.Value(tracerKey).(Tracer)
+	if !ok {
+		return ctx, noopSpan{}
+	}
+	ctx, span := tracer.StartSpan(ctx, name, SpanAttribute{Key: AttrStage, Value: stage}, SpanAttribute{Key: AttrURL, Value: url})
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+// noopSpan does nothing (used when no tracer is provided)
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(_ ...SpanAttribute) {}
+func (noopSpan) End(_ error)                      {}
+
+// withSpan runs fn with a context carrying a new span and ends the span with fn's error
+func withSpan[T any](ctx context.Context, name, stage, url string, fn func(context.Context) (T, error)) (T, error) {
+	ctx, span := startSpan(ctx, name, stage, url)
+	result, err := fn(ctx)
+	span.End(err)
+	return result, err
+}
+
+// setSpanStatus records an HTTP response status on the span started by withSpan, if any
+func setSpanStatus(ctx context.Context, statusCode int) {
+	if span, ok := ctx.Value(spanKey).(Span); ok {
+		span.SetAttributes(SpanAttribute{Key: AttrStatusCode, Value: statusCode})
+	}
+}
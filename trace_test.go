@@ -0,0 +1,162 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// recordedSpan is a span captured by fakeTracer
+type recordedSpan struct {
+	name  string
+	attrs map[string]any
+	ended bool
+	err   error
+}
+
+// fakeTracer records every span it starts
+type fakeTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (f *fakeTracer) StartSpan(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, Span) {
+	span := &recordedSpan{name: name, attrs: make(map[string]any)}
+	for _, attr := range attrs {
+		span.attrs[attr.Key] = attr.Value
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.spans = append(f.spans, span)
+	return ctx, &fakeSpan{tracer: f, span: span}
+}
+
+// find returns the first span with the given name, or nil
+func (f *fakeTracer) find(name string) *recordedSpan {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, span := range f.spans {
+		if span.name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+type fakeSpan struct {
+	tracer *fakeTracer
+	span   *recordedSpan
+}
+
+func (s *fakeSpan) SetAttributes(attrs ...SpanAttribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for _, attr := range attrs {
+		s.span.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *fakeSpan) End(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.span.ended = true
+	s.span.err = err
+}
+
+// TestTracer_DiscoverySpans verifies a span is created and ended for every discovery stage
+func TestTracer_DiscoverySpans(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mcp":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/.well-known/oauth-protected-resource/mcp":
+			_ = json.NewEncoder(w).Encode(ProtectedResourceMetadata{Resource: server.URL + "/mcp", AuthorizationServer: server.URL})
+		case "/.well-known/oauth-authorization-server":
+			_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+				Issuer:                server.URL,
+				AuthorizationEndpoint: server.URL + "/authorize",
+				TokenEndpoint:         server.URL + "/token",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tracer := &fakeTracer{}
+	if _, err := DiscoverOAuthRequirements(WithTracer(context.Background(), tracer), server.URL+"/mcp"); err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		stage  string
+		url    string
+		status int // 0 = not recorded
+	}{
+		{SpanDiscovery, "discovery", server.URL + "/mcp", 0},
+		{SpanProbe, "probe", server.URL + "/mcp", http.StatusUnauthorized},
+		{SpanResourceMetadata, "resource_metadata", server.URL + "/.well-known/oauth-protected-resource/mcp", http.StatusOK},
+		{SpanAuthServerMetadata, "auth_server_metadata", server.URL, http.StatusOK},
+	}
+	for _, tt := range tests {
+		span := tracer.find(tt.name)
+		if span == nil {
+			t.Errorf("Expected span %q", tt.name)
+			continue
+		}
+		if !span.ended || span.err != nil {
+			t.Errorf("Span %q: expected ended without error, got ended=%v err=%v", tt.name, span.ended, span.err)
+		}
+		if span.attrs[AttrStage] != tt.stage || span.attrs[AttrURL] != tt.url {
+			t.Errorf("Span %q: unexpected attributes %v", tt.name, span.attrs)
+		}
+		if tt.status != 0 && span.attrs[AttrStatusCode] != tt.status {
+			t.Errorf("Span %q: expected status %d, got %v", tt.name, tt.status, span.attrs[AttrStatusCode])
+		}
+	}
+}
+
+// TestTracer_TokenAndDCRSpans verifies token and registration requests are traced, including failures
+func TestTracer_TokenAndDCRSpans(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/register":
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(DCRResponse{ClientID: "client-123"})
+		case "/token":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		}
+	}))
+	defer server.Close()
+
+	tracer := &fakeTracer{}
+	ctx := WithTracer(context.Background(), tracer)
+	discovery := &Discovery{RegistrationEndpoint: server.URL + "/register", TokenEndpoint: server.URL + "/token"}
+
+	creds, err := PerformDCR(ctx, discovery, "test-server", "")
+	if err != nil {
+		t.Fatalf("PerformDCR failed: %v", err)
+	}
+	if _, err := ExchangeAuthorizationCode(ctx, discovery, creds, "code", "verifier", DefaultRedirectURI); err == nil {
+		t.Fatal("Expected token error")
+	}
+
+	dcr := tracer.find(SpanDCR)
+	if dcr == nil || !dcr.ended || dcr.err != nil || dcr.attrs[AttrStatusCode] != http.StatusCreated {
+		t.Errorf("Unexpected DCR span %+v", dcr)
+	}
+	token := tracer.find(SpanToken)
+	if token == nil || !token.ended || token.err == nil || token.attrs[AttrStatusCode] != http.StatusBadRequest {
+		t.Errorf("Unexpected token span %+v", token)
+	}
+	if token != nil && token.attrs[AttrURL] != server.URL+"/token" {
+		t.Errorf("Expected token span URL %q, got %v", server.URL+"/token", token.attrs[AttrURL])
+	}
+}
@@ -0,0 +1,217 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Transport is an http.RoundTripper that attaches an OAuth access token to every request
+// and transparently refreshes it when the resource server rejects it
+//
+// RFC 6750 COMPLIANCE:
+// - Section 2.1: the token is sent as "Authorization: Bearer <token>"
+// - Section 3.1: a 401 with error="invalid_token" triggers a refresh (RFC 6749 Section 6)
+// and a single retry of the request
+//
+// Transport is safe for concurrent use: when several in-flight requests fail with the same
+// expired token, only one refresh is sent and the others wait for and reuse its result.
+// Requests with a body are only retried if the body can be replayed (http.Request.GetBody,
+// set automatically by http.NewRequest for common body types).
+//
+// When the resource only accepts the access_token query parameter (Discovery.BearerMethod
+// returns BearerMethodQuery) the token is sent there instead of the header. The body method
+// is not used, since RFC 6750 Section 2.2 limits it to form-encoded requests, so the header
+// is sent instead.
+type Transport struct {
+	base      http.RoundTripper
+	discovery *Discovery
+	creds     *ClientCredentials
+	onRefresh func(*TokenResponse)
+	tokenOpts []TokenOption
+
+	mu      sync.Mutex
+	token   *TokenResponse
+	pending *transportRefresh // Refresh in progress (nil = none)
+}
+
+// TransportOption configures a Transport
+type TransportOption func(*Transport)
+
+// WithTokenRefreshCallback calls fn with the new token after every successful refresh,
+// so callers can persist rotated refresh tokens
+func WithTokenRefreshCallback(fn func(*TokenResponse)) TransportOption {
+	return func(t *Transport) {
+		t.onRefresh = fn
+	}
+}
+
+// WithTransportTokenOptions passes options to the RefreshAccessToken calls made by the Transport
+func WithTransportTokenOptions(opts ...TokenOption) TransportOption {
+	return func(t *Transport) {
+		t.tokenOpts = opts
+	}
+}
+
+// NewTransport creates a Transport that sends requests through base (nil = http.DefaultTransport)
+// authenticated with token, refreshing it at the token endpoint from creds or discovery
+func NewTransport(base http.RoundTripper, discovery *Discovery, creds *ClientCredentials, token *TokenResponse, opts ...TransportOption) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &Transport{
+		base:      base,
+		discovery: discovery,
+		creds:     creds,
+		token:     token,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Token returns the current token (which changes after a refresh)
+func (t *Transport) Token() *TokenResponse {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.token
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := t.Token()
+	resp, err := t.base.RoundTrip(t.authorizedRequest(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if AnalyzeAuthFailure(resp).Action != AuthRecoveryRefresh {
+		return resp, nil
+	}
+
+	// The retry needs a fresh copy of the body
+	var body io.ReadCloser
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		if body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+
+	refreshed, err := t.refresh(req.Context(), token)
+	if err != nil {
+		loggerFromContext(req.Context()).Warnf("token refresh after invalid_token failed: %v", err)
+		if body != nil {
+			body.Close()
+		}
+		return resp, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	retry := t.authorizedRequest(req, refreshed)
+	if body != nil {
+		retry.Body = body
+	}
+	return t.base.RoundTrip(retry)
+}
+
+// refresh replaces stale with a refreshed token, unless another request already did
+//
+// t.mu only guards the token state, never the network call, so Token and RoundTrip are not
+// blocked by a slow token endpoint. Concurrent callers wait for the pending refresh instead
+// of starting their own; if it failed only because its caller's context ended, the next
+// caller takes over (and joins the still running request through RefreshAccessToken's
+// single-flight).
+func (t *Transport) refresh(ctx context.Context, stale *TokenResponse) (*TokenResponse, error) {
+	for {
+		t.mu.Lock()
+		// Another request refreshed while this one was in flight - reuse its token
+		if t.token != stale {
+			current := t.token
+			t.mu.Unlock()
+			return current, nil
+		}
+		if pending := t.pending; pending != nil {
+			t.mu.Unlock()
+			select {
+			case <-pending.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if pending.err != nil && pending.canceled && ctx.Err() == nil {
+				continue
+			}
+			return pending.token, pending.err
+		}
+		if stale == nil || stale.RefreshToken == "" {
+			t.mu.Unlock()
+			return nil, fmt.Errorf("no refresh token available")
+		}
+		pending := &transportRefresh{done: make(chan struct{})}
+		t.pending = pending
+		t.mu.Unlock()
+
+		pending.token, pending.err = RefreshAccessToken(ctx, t.discovery, t.creds, stale.RefreshToken, t.tokenOpts...)
+		pending.canceled = ctx.Err() != nil
+		// RFC 6749 Section 6: the refresh token is only replaced if the server issued a new one
+		if pending.err == nil && pending.token.RefreshToken == "" {
+			pending.token.RefreshToken = stale.RefreshToken
+		}
+
+		t.mu.Lock()
+		if pending.err == nil {
+			t.token = pending.token
+		}
+		t.pending = nil
+		t.mu.Unlock()
+		close(pending.done)
+
+		if pending.err != nil {
+			return nil, pending.err
+		}
+		if t.onRefresh != nil {
+			t.onRefresh(pending.token)
+		}
+		return pending.token, nil
+	}
+}
+
+// transportRefresh is a refresh in progress that concurrent requests wait for
+type transportRefresh struct {
+	done     chan struct{}
+	token    *TokenResponse
+	err      error
+	canceled bool // The refreshing caller's context ended (other callers may retry)
+}
+
+// authorizedRequest returns a copy of req carrying the token in the Authorization header,
+// or the query string for resources that only accept that (RoundTrippers must not modify
+// the caller's request)
+func (t *Transport) authorizedRequest(req *http.Request, token *TokenResponse) *http.Request {
+	authorized := req.Clone(req.Context())
+	if token == nil {
+		return authorized
+	}
+	header := token.AuthorizationHeader()
+	if header == "" {
+		return authorized
+	}
+
+	if t.discovery != nil && t.discovery.BearerMethod() == BearerMethodQuery {
+		// RFC 6750 Section 2.3: access_token parameter, and responses should not be cached
+		query := authorized.URL.Query()
+		query.Set("access_token", token.AccessToken)
+		authorized.URL.RawQuery = query.Encode()
+		authorized.Header.Set("Cache-Control", "no-store")
+		return authorized
+	}
+
+	authorized.Header.Set("Authorization", header)
+	return authorized
+}
@@ -0,0 +1,202 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newMockResourceServer starts an MCP server accepting only "Bearer <validToken>"
+// plus a token endpoint at /token that issues validToken; refreshes are counted
+func newMockResourceServer(t *testing.T, validToken string, refreshes *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			refreshes.Add(1)
+			_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: validToken, TokenType: "Bearer"})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+validToken {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestTransport_AttachesToken verifies the Authorization header is added without a refresh
+func TestTransport_AttachesToken(t *testing.T) {
+	var refreshes atomic.Int32
+	server := newMockResourceServer(t, "valid", &refreshes)
+
+	transport := NewTransport(nil, &Discovery{TokenEndpoint: server.URL + "/token"},
+		&ClientCredentials{ClientID: "client-123"}, &TokenResponse{AccessToken: "valid", RefreshToken: "refresh"})
+	client := &http.Client{Transport: transport}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+"/mcp", strings.NewReader("ping"))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Error("Expected the caller's request to be left unmodified")
+	}
+	if refreshes.Load() != 0 {
+		t.Errorf("Expected no refresh, got %d", refreshes.Load())
+	}
+}
+
+// TestTransport_SingleFlightRefresh verifies concurrent requests failing with the same
+// expired token share a single refresh and are retried with their bodies intact
+func TestTransport_SingleFlightRefresh(t *testing.T) {
+	var refreshes atomic.Int32
+	server := newMockResourceServer(t, "fresh", &refreshes)
+
+	var refreshedTokens []string
+	transport := NewTransport(nil, &Discovery{TokenEndpoint: server.URL + "/token"},
+		&ClientCredentials{ClientID: "client-123"}, &TokenResponse{AccessToken: "expired", RefreshToken: "refresh"},
+		WithTokenRefreshCallback(func(token *TokenResponse) {
+			refreshedTokens = append(refreshedTokens, token.AccessToken)
+		}))
+	client := &http.Client{Transport: transport}
+
+	const requests = 20
+	var wg sync.WaitGroup
+	errs := make(chan string, requests)
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+"/mcp", strings.NewReader("payload"))
+			if err != nil {
+				errs <- err.Error()
+				return
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				errs <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK || string(body) != "payload" {
+				errs <- "unexpected response: " + resp.Status + " " + string(body)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if refreshes.Load() != 1 {
+		t.Errorf("Expected exactly 1 refresh, got %d", refreshes.Load())
+	}
+	if token := transport.Token(); token.AccessToken != "fresh" || token.RefreshToken != "refresh" {
+		t.Errorf("Expected fresh token keeping the refresh token, got %+v", token)
+	}
+	if len(refreshedTokens) != 1 || refreshedTokens[0] != "fresh" {
+		t.Errorf("Expected one refresh callback, got %v", refreshedTokens)
+	}
+}
+
+// TestTransport_QueryBearerMethod verifies the token is sent as access_token when the resource only accepts query
+func TestTransport_QueryBearerMethod(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Expected no Authorization header, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("access_token") != "valid" || r.URL.Query().Get("page") != "2" {
+			t.Errorf("Expected access_token and original query, got %q", r.URL.RawQuery)
+		}
+		if r.Header.Get("Cache-Control") != "no-store" {
+			t.Errorf("Expected Cache-Control no-store, got %q", r.Header.Get("Cache-Control"))
+		}
+	}))
+	defer server.Close()
+
+	discovery := &Discovery{TokenEndpoint: server.URL + "/token", BearerMethods: []string{BearerMethodQuery}}
+	transport := NewTransport(nil, discovery, &ClientCredentials{ClientID: "client-123"}, &TokenResponse{AccessToken: "valid"})
+	client := &http.Client{Transport: transport}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/mcp?page=2", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if req.URL.Query().Get("access_token") != "" {
+		t.Error("Transport must not modify the caller's request URL")
+	}
+}
+
+// TestTransport_TokenNotBlockedByRefresh verifies Token returns while a refresh is waiting on the token endpoint
+func TestTransport_TokenNotBlockedByRefresh(t *testing.T) {
+	release := make(chan struct{})
+	refreshing := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			close(refreshing)
+			<-release
+			_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: "fresh", TokenType: "Bearer"})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	transport := NewTransport(nil, &Discovery{TokenEndpoint: server.URL + "/token"},
+		&ClientCredentials{ClientID: "client-123"}, &TokenResponse{AccessToken: "expired", RefreshToken: "refresh"})
+	client := &http.Client{Transport: transport}
+
+	go func() {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/mcp", nil)
+		if err != nil {
+			return
+		}
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-refreshing
+
+	tokens := make(chan *TokenResponse, 1)
+	go func() { tokens <- transport.Token() }()
+	select {
+	case token := <-tokens:
+		if token.AccessToken != "expired" {
+			t.Errorf("Expected the current token during the refresh, got %+v", token)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Token blocked while a refresh was in progress")
+	}
+}
@@ -1,5 +1,10 @@
 package oauth
 
+import (
+	"net/http"
+	"time"
+)
+
 // Discovery contains OAuth configuration discovered from MCP server
 //
 // MCP SPEC COMPLIANCE:
@@ -16,22 +21,53 @@ type Discovery struct {
 	ResourceServer      string   // Resource server identifier
 	AuthorizationServer string   // Authorization server URL
 	Scopes              []string // Required scopes for this resource
+	BearerMethods       []string // How the resource accepts bearer tokens (header, body, query; see BearerMethod)
 
 	// From RFC 8414 - Authorization Server Metadata
 	AuthorizationEndpoint string   // OAuth authorization endpoint
 	TokenEndpoint         string   // OAuth token endpoint
 	RegistrationEndpoint  string   // Dynamic Client Registration endpoint (RFC 7591)
+	RevocationEndpoint    string   // Token revocation endpoint (RFC 7009)
 	JWKSUri               string   // JSON Web Key Set URI
 	SupportsPKCE          bool     // Whether server supports PKCE (S256)
 	CodeChallengeMethod   []string // Supported PKCE methods
+	SupportsRAR           bool     // Whether server supports Rich Authorization Requests (RFC 9396)
+	SupportsIssParameter  bool     // Whether authorization responses carry iss (RFC 9207)
+	RequireRequestObject  bool     // Whether authorization requests must be signed request objects (RFC 9101)
 
 	// Additional OAuth metadata
-	Issuer                            string   // Authorization server issuer identifier
-	ScopesSupported                   []string // All scopes supported by authorization server
-	ResponseTypesSupported            []string // Supported OAuth response types
-	ResponseModesSupported            []string // Supported OAuth response modes
-	GrantTypesSupported               []string // Supported OAuth grant types
-	TokenEndpointAuthMethodsSupported []string // Supported client authentication methods
+	Issuer                             string   // Authorization server issuer identifier
+	ScopesSupported                    []string // All scopes supported by authorization server
+	ResponseTypesSupported             []string // Supported OAuth response types
+	ResponseModesSupported             []string // Supported OAuth response modes
+	GrantTypesSupported                []string // Supported OAuth grant types
+	TokenEndpointAuthMethodsSupported  []string // Supported client authentication methods
+	AuthorizationDetailsTypesSupported []string // Supported RFC 9396 authorization_details types
+	RequestObjectSigningAlgs           []string // Supported request object signing algorithms (RFC 9101)
+
+	// Raw headers of the MCP server's probe response (e.g. DPoP-Nonce, Retry-After)
+	// Values are preserved as received, including WWW-Authenticate; treat them as
+	// sensitive and avoid logging the whole map
+	ResponseHeaders http.Header
+
+	// How long the discovery stages took (zero for results not produced by discovery)
+	Timings Timings
+}
+
+// Timings records the duration and attempt count of each discovery stage, for identifying
+// slow MCP or authorization servers
+//
+// Stages that did not run (e.g. resource metadata with WithAuthorizationServerURL) are zero.
+// Durations are serialized as nanoseconds.
+type Timings struct {
+	Total              time.Duration `json:"total"`                // Whole DiscoverOAuthRequirements call
+	Probe              time.Duration `json:"probe"`                // MCP server probe, including the HEAD fallback
+	ResourceMetadata   time.Duration `json:"resource_metadata"`    // All protected resource metadata fetches
+	AuthServerMetadata time.Duration `json:"auth_server_metadata"` // Authorization server metadata fetch
+
+	ProbeAttempts              int `json:"probe_attempts"`                // 2 when a HEAD probe was retried with POST
+	ResourceMetadataAttempts   int `json:"resource_metadata_attempts"`    // Metadata URLs tried until one succeeded
+	AuthServerMetadataAttempts int `json:"auth_server_metadata_attempts"` // Authorization server metadata requests
 }
 
 // ProtectedResourceMetadata represents metadata from /.well-known/oauth-protected-resource
@@ -44,10 +80,12 @@ type Discovery struct {
 // COMPATIBILITY NOTE: Handles both authorization_server (singular) and authorization_servers (plural)
 // formats since different servers implement RFC 9728 differently
 type ProtectedResourceMetadata struct {
-	Resource             string   `json:"resource"`                        // REQUIRED: Protected resource identifier
-	AuthorizationServer  string   `json:"authorization_server,omitempty"`  // RFC 9728 standard (single server)
-	AuthorizationServers []string `json:"authorization_servers,omitempty"` // Some servers use plural (array)
-	Scopes               []string `json:"scopes,omitempty"`                // OPTIONAL: Required scopes
+	Resource               string   `json:"resource"`                           // REQUIRED: Protected resource identifier
+	AuthorizationServer    string   `json:"authorization_server,omitempty"`     // RFC 9728 standard (single server)
+	AuthorizationServers   []string `json:"authorization_servers,omitempty"`    // Some servers use plural (array)
+	Scopes                 []string `json:"scopes,omitempty"`                   // Non-standard: Required scopes (some servers)
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`         // RFC 9728 Section 2: OPTIONAL scopes_supported
+	BearerMethodsSupported []string `json:"bearer_methods_supported,omitempty"` // RFC 9728 Section 2: header, body, query
 }
 
 // AuthorizationServerMetadata represents metadata from /.well-known/oauth-authorization-server
@@ -61,17 +99,26 @@ type ProtectedResourceMetadata struct {
 // - MCP clients MUST use this metadata per Section 4.2
 // - Dynamic Client Registration endpoint support for Phase 2
 type AuthorizationServerMetadata struct {
-	Issuer                            string   `json:"issuer"`                                          // REQUIRED: Issuer identifier
-	AuthorizationEndpoint             string   `json:"authorization_endpoint"`                          // REQUIRED: Authorization endpoint
-	TokenEndpoint                     string   `json:"token_endpoint"`                                  // REQUIRED: Token endpoint
-	JWKSUri                           string   `json:"jwks_uri,omitempty"`                              // OPTIONAL: JSON Web Key Set
-	RegistrationEndpoint              string   `json:"registration_endpoint,omitempty"`                 // OPTIONAL: DCR endpoint (RFC 7591)
-	ScopesSupported                   []string `json:"scopes_supported,omitempty"`                      // OPTIONAL: Supported scopes
-	ResponseTypesSupported            []string `json:"response_types_supported,omitempty"`              // OPTIONAL: Response types
-	ResponseModesSupported            []string `json:"response_modes_supported,omitempty"`              // OPTIONAL: Response modes
-	GrantTypesSupported               []string `json:"grant_types_supported,omitempty"`                 // OPTIONAL: Grant types
-	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported,omitempty"` // OPTIONAL: Auth methods
-	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported,omitempty"`      // OPTIONAL: PKCE methods
+	Issuer                             string   `json:"issuer"`                                                // REQUIRED: Issuer identifier
+	AuthorizationEndpoint              string   `json:"authorization_endpoint"`                                // REQUIRED: Authorization endpoint
+	TokenEndpoint                      string   `json:"token_endpoint"`                                        // REQUIRED: Token endpoint
+	JWKSUri                            string   `json:"jwks_uri,omitempty"`                                    // OPTIONAL: JSON Web Key Set
+	RegistrationEndpoint               string   `json:"registration_endpoint,omitempty"`                       // OPTIONAL: DCR endpoint (RFC 7591)
+	RevocationEndpoint                 string   `json:"revocation_endpoint,omitempty"`                         // OPTIONAL: Token revocation endpoint (RFC 7009)
+	ScopesSupported                    []string `json:"scopes_supported,omitempty"`                            // OPTIONAL: Supported scopes
+	ResponseTypesSupported             []string `json:"response_types_supported,omitempty"`                    // OPTIONAL: Response types
+	ResponseModesSupported             []string `json:"response_modes_supported,omitempty"`                    // OPTIONAL: Response modes
+	GrantTypesSupported                []string `json:"grant_types_supported,omitempty"`                       // OPTIONAL: Grant types
+	TokenEndpointAuthMethodsSupported  []string `json:"token_endpoint_auth_methods_supported,omitempty"`       // OPTIONAL: Auth methods
+	CodeChallengeMethodsSupported      []string `json:"code_challenge_methods_supported,omitempty"`            // OPTIONAL: PKCE methods
+	AuthorizationDetailsTypesSupported []string `json:"authorization_details_types_supported,omitempty"`       // OPTIONAL: RAR types (RFC 9396)
+	AuthorizationResponseIssSupported  bool     `json:"authorization_response_iss_parameter_supported"`        // OPTIONAL: iss in authorization responses (RFC 9207)
+	DPoPSigningAlgValuesSupported      []string `json:"dpop_signing_alg_values_supported,omitempty"`           // OPTIONAL: DPoP proof algorithms (RFC 9449)
+	PushedAuthorizationRequestEndpoint string   `json:"pushed_authorization_request_endpoint,omitempty"`       // OPTIONAL: PAR endpoint (RFC 9126)
+	RequireSignedRequestObject         bool     `json:"require_signed_request_object,omitempty"`               // OPTIONAL: JAR required (RFC 9101 Section 10.5)
+	RequestObjectSigningAlgsSupported  []string `json:"request_object_signing_alg_values_supported,omitempty"` // OPTIONAL: JAR algorithms (RFC 9101)
+
+	pkceMethodsField string // JSON field CodeChallengeMethodsSupported was read from (see UnmarshalJSON)
 }
 
 // DCRRequest represents a Dynamic Client Registration request
@@ -92,12 +139,23 @@ type DCRRequest struct {
 	GrantTypes              []string `json:"grant_types"`                // OAuth grant types requested
 	ResponseTypes           []string `json:"response_types"`             // OAuth response types requested
 	Scope                   string   `json:"scope,omitempty"`            // Space-separated scopes
+	ApplicationType         string   `json:"application_type,omitempty"` // "web" or "native" (OIDC Dynamic Client Registration)
 
 	// Additional metadata for better client identification
 	ClientURI       string   `json:"client_uri,omitempty"`       // Client information URL
+	LogoURI         string   `json:"logo_uri,omitempty"`         // Client logo shown on consent screens
+	TOSURI          string   `json:"tos_uri,omitempty"`          // Terms of service URL
+	PolicyURI       string   `json:"policy_uri,omitempty"`       // Privacy policy URL
 	SoftwareID      string   `json:"software_id,omitempty"`      // Software identifier
 	SoftwareVersion string   `json:"software_version,omitempty"` // Software version
 	Contacts        []string `json:"contacts,omitempty"`         // Contact information
+
+	// RFC 7591 Section 2.3: signed JWT asserting client metadata values
+	SoftwareStatement string `json:"software_statement,omitempty"`
+
+	// Extension metadata serialized alongside the fields above (RFC 7591 Section 2 allows
+	// additional fields); names of the fields above take precedence
+	ExtraMetadata map[string]any `json:"-"`
 }
 
 // DCRResponse represents the response from a Dynamic Client Registration request
@@ -128,15 +186,28 @@ type DCRResponse struct {
 	RegistrationClientURI   string   `json:"registration_client_uri,omitempty"`
 }
 
-// ClientCredentials represents stored client credentials for a public client
-// For public clients, only the client_id is stored (no client_secret)
+// ClientCredentials represents stored client credentials
+// Public clients (the PerformDCR default) only have a client_id; confidential clients
+// registered with a secret-based token_endpoint_auth_method also carry a client_secret
+//
+// RFC 7592 COMPLIANCE - OAuth 2.0 Dynamic Client Registration Management Protocol:
+// - Section 3: registration_client_uri and registration_access_token manage the registration
 type ClientCredentials struct {
-	ClientID              string `json:"client_id"`
-	ServerURL             string `json:"server_url"` // The resource server URL
-	IsPublic              bool   `json:"is_public"`  // Always true for our implementation
-	AuthorizationEndpoint string `json:"authorization_endpoint,omitempty"`
-	TokenEndpoint         string `json:"token_endpoint,omitempty"`
-	// No ClientSecret field - public clients don't have secrets
+	ClientID                string `json:"client_id"`
+	ClientSecret            string `json:"client_secret,omitempty"` // Empty for public clients
+	ServerURL               string `json:"server_url"`              // The resource server URL
+	IsPublic                bool   `json:"is_public"`               // True when the client has no secret (token_endpoint_auth_method=none)
+	TokenEndpointAuthMethod string `json:"token_endpoint_auth_method,omitempty"`
+	AuthorizationEndpoint   string `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint           string `json:"token_endpoint,omitempty"`
+
+	// Redirect URIs registered for the client; use one of these in the authorization request
+	RedirectURIs []string `json:"redirect_uris,omitempty"`
+
+	// Registration management (RFC 7592)
+	RegistrationEndpoint    string `json:"registration_endpoint,omitempty"`     // Endpoint the client was registered at
+	RegistrationClientURI   string `json:"registration_client_uri,omitempty"`   // Client configuration endpoint
+	RegistrationAccessToken string `json:"registration_access_token,omitempty"` // Bearer token for the configuration endpoint
 }
 
 // WWWAuthenticateChallenge represents a parsed WWW-Authenticate challenge
@@ -148,6 +219,55 @@ type ClientCredentials struct {
 // MCP SPEC COMPLIANCE - MCP Authorization Specification:
 // - Section 4.1: WWW-Authenticate header parsing for resource_metadata discovery
 type WWWAuthenticateChallenge struct {
-	Scheme     string            // Authentication scheme (e.g., "Bearer")
-	Parameters map[string]string // Challenge parameters (realm, scope, resource_metadata, etc.)
+	Scheme            string            // Authentication scheme (e.g., "Bearer")
+	Parameters        map[string]string // Challenge parameters (realm, scope, resource_metadata, etc.)
+	OrderedParameters []AuthParam       // Same parameters in header order (duplicates kept), for debugging or re-serializing
+}
+
+// AuthParam is a single auth-param of a WWW-Authenticate challenge (RFC 7235 Section 2.1)
+type AuthParam struct {
+	Key   string // Parameter name as written in the header
+	Value string // Unquoted parameter value
+}
+
+// AuthorizationCallbackResult represents a successful authorization response
+//
+// RFC 6749 COMPLIANCE:
+// - Section 4.1.2: Authorization Response (code, state)
+//
+// RFC 9207 COMPLIANCE - OAuth 2.0 Authorization Server Issuer Identification:
+// - Section 2: iss parameter identifying the authorization server that issued the response
+type AuthorizationCallbackResult struct {
+	Code   string // Authorization code to exchange at the token endpoint
+	State  string // State value echoed back by the authorization server
+	Issuer string // RFC 9207 issuer identifier (empty if not sent)
+}
+
+// AuthorizationDetail represents one entry of the RFC 9396 authorization_details parameter
+//
+// RFC 9396 COMPLIANCE - OAuth 2.0 Rich Authorization Requests:
+// - Section 2: Each object has a REQUIRED "type" field
+// - Section 2: All other fields are defined by the type (locations, actions, amount, etc.)
+//
+// Fields holds the type-specific members; they are serialized alongside "type"
+type AuthorizationDetail struct {
+	Type   string         // REQUIRED: Authorization details type identifier
+	Fields map[string]any // Type-specific fields (e.g. "actions", "locations", "instructedAmount")
+}
+
+// TokenResponse represents a successful response from the token endpoint
+//
+// RFC 6749 COMPLIANCE - OAuth 2.0 Authorization Framework:
+// - Section 5.1: Successful Response (access_token, token_type, expires_in, refresh_token, scope)
+// - Section 3.3: scope is OPTIONAL if identical to the requested scope, REQUIRED otherwise
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`            // REQUIRED: The issued access token
+	TokenType    string `json:"token_type"`              // REQUIRED: Token type (e.g. "Bearer")
+	ExpiresIn    int64  `json:"expires_in,omitempty"`    // RECOMMENDED: Lifetime in seconds
+	RefreshToken string `json:"refresh_token,omitempty"` // OPTIONAL: Refresh token
+	Scope        string `json:"scope,omitempty"`         // OPTIONAL: Granted scopes (space-separated)
+
+	// When the token response was received (set by the token requests of this package, zero
+	// otherwise); ExpiresIn counts from here
+	IssuedAt time.Time `json:"-"`
 }
@@ -0,0 +1,70 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// WarmupAuthServerConnections opens n connections to the token endpoint ahead of the first token request
+//
+// n HEAD requests are sent to Discovery.TokenEndpoint concurrently so the TCP and TLS handshakes
+// happen before any real token request. Any HTTP response counts as success, since only the
+// connection matters (token endpoints typically answer HEAD with 405). The connections are
+// returned to the pool of http.DefaultTransport, which token requests use; note that it keeps
+// at most http.DefaultMaxIdleConnsPerHost idle connections per host, so larger n only helps
+// with a transport configured for more.
+//
+// Returns nil if at least one connection was established, otherwise the joined errors.
+func WarmupAuthServerConnections(ctx context.Context, d *Discovery, n int) error {
+	if d == nil || d.TokenEndpoint == "" {
+		return fmt.Errorf("no token endpoint in discovery")
+	}
+	if n < 1 {
+		return nil
+	}
+
+	client := &http.Client{Timeout: tokenRequestTimeout}
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = warmupConnection(ctx, client, d.TokenEndpoint)
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == n {
+		return fmt.Errorf("warming up connections to %s failed: %w", d.TokenEndpoint, errors.Join(errs...))
+	}
+	if failed > 0 {
+		loggerFromContext(ctx).Debugf("warmed up %d of %d connections to %s", n-failed, n, d.TokenEndpoint)
+	}
+	return nil
+}
+
+// warmupConnection sends one HEAD request and drains the response so the connection is reused
+func warmupConnection(ctx context.Context, client *http.Client, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
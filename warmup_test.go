@@ -0,0 +1,56 @@
+package oauth
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// TestWarmupAuthServerConnections verifies HEAD requests are sent and open connections to the token endpoint
+func TestWarmupAuthServerConnections(t *testing.T) {
+	var heads, conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	if err := WarmupAuthServerConnections(context.Background(), &Discovery{TokenEndpoint: server.URL + "/token"}, 2); err != nil {
+		t.Fatalf("Warmup failed: %v", err)
+	}
+	if heads.Load() != 2 {
+		t.Errorf("Expected 2 HEAD requests, got %d", heads.Load())
+	}
+	if conns.Load() < 1 {
+		t.Error("Expected connections to be opened")
+	}
+}
+
+// TestWarmupAuthServerConnections_Errors verifies missing endpoints and unreachable servers are reported
+func TestWarmupAuthServerConnections_Errors(t *testing.T) {
+	if err := WarmupAuthServerConnections(context.Background(), &Discovery{}, 2); err == nil {
+		t.Error("Expected error without token endpoint")
+	}
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL + "/token"
+	server.Close()
+	if err := WarmupAuthServerConnections(context.Background(), &Discovery{TokenEndpoint: endpoint}, 2); err == nil {
+		t.Error("Expected error when no connection can be established")
+	}
+
+	if err := WarmupAuthServerConnections(context.Background(), &Discovery{TokenEndpoint: endpoint}, 0); err != nil {
+		t.Errorf("Expected no-op for n=0, got %v", err)
+	}
+}
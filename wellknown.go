@@ -0,0 +1,63 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// DefaultWellKnownSuffixes are the metadata documents ProbeWellKnownURLs tries when none are given
+//
+// - oauth-authorization-server: RFC 8414 Section 3
+// - openid-configuration: OpenID Connect Discovery 1.0 Section 4 (RFC 8414 Section 5 compatibility)
+var DefaultWellKnownSuffixes = []string{"oauth-authorization-server", "openid-configuration"}
+
+// ProbeWellKnownURLs fetches authorization server metadata from several well-known suffixes at once
+//
+// Every <issuer>/.well-known/<suffix> URL is requested concurrently; the first valid metadata
+// document wins and the remaining requests are canceled. Returns the metadata together with
+// the suffix that served it, or an error joining every probe's failure.
+//
+// suffixes defaults to DefaultWellKnownSuffixes and client to a default http.Client.
+// The issuer is validated as in discovery (no query or fragment) and the returned metadata
+// has the required RFC 8414 fields.
+func ProbeWellKnownURLs(ctx context.Context, issuer string, suffixes []string, client *http.Client) (*AuthorizationServerMetadata, string, error) {
+	if err := validateIssuerURL(issuer); err != nil {
+		return nil, "", fmt.Errorf("invalid authorization server URL: %w", err)
+	}
+	if len(suffixes) == 0 {
+		suffixes = DefaultWellKnownSuffixes
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type probeResult struct {
+		suffix   string
+		metadata *AuthorizationServerMetadata
+		err      error
+	}
+	// Buffered so probes finishing after the winner don't block
+	results := make(chan probeResult, len(suffixes))
+	for _, suffix := range suffixes {
+		go func() {
+			metadata, err := fetchAuthorizationServerMetadataFrom(ctx, client, wellKnownURL(issuer, suffix), false)
+			results <- probeResult{suffix: suffix, metadata: metadata, err: err}
+		}()
+	}
+
+	var errs []error
+	for range suffixes {
+		result := <-results
+		if result.err == nil {
+			loggerFromContext(ctx).Debugf("authorization server metadata found at %s suffix %s", issuer, result.suffix)
+			return result.metadata, result.suffix, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", result.suffix, result.err))
+	}
+	return nil, "", fmt.Errorf("no well-known metadata found for %s: %w", issuer, errors.Join(errs...))
+}
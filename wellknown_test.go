@@ -0,0 +1,74 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestProbeWellKnownURLs verifies the first successful suffix wins and slower probes are canceled
+func TestProbeWellKnownURLs(t *testing.T) {
+	canceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			baseURL := "http://" + r.Host
+			_ = json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+				Issuer:                baseURL,
+				AuthorizationEndpoint: baseURL + "/authorize",
+				TokenEndpoint:         baseURL + "/token",
+			})
+		case "/.well-known/oauth-authorization-server":
+			// Hangs until the probe is canceled
+			<-r.Context().Done()
+			close(canceled)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	metadata, suffix, err := ProbeWellKnownURLs(context.Background(), server.URL, nil, nil)
+	if err != nil {
+		t.Fatalf("ProbeWellKnownURLs failed: %v", err)
+	}
+	if suffix != "openid-configuration" {
+		t.Errorf("Expected openid-configuration suffix, got %q", suffix)
+	}
+	if metadata.TokenEndpoint != server.URL+"/token" {
+		t.Errorf("Unexpected token endpoint %q", metadata.TokenEndpoint)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the slower probe to be canceled")
+	}
+}
+
+// TestProbeWellKnownURLs_AllFail verifies every probe failure is reported
+func TestProbeWellKnownURLs_AllFail(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, _, err := ProbeWellKnownURLs(context.Background(), server.URL, []string{"oauth-authorization-server", "custom-metadata"}, server.Client())
+	if err == nil {
+		t.Fatal("Expected error when no suffix serves metadata")
+	}
+	for _, suffix := range []string{"oauth-authorization-server", "custom-metadata"} {
+		if !strings.Contains(err.Error(), suffix) {
+			t.Errorf("Expected error to mention %q, got %v", suffix, err)
+		}
+	}
+}
+
+// TestProbeWellKnownURLs_InvalidIssuer verifies issuers with a query are rejected before probing
+func TestProbeWellKnownURLs_InvalidIssuer(t *testing.T) {
+	if _, _, err := ProbeWellKnownURLs(context.Background(), "https://auth.example.com?tenant=a", nil, nil); err == nil {
+		t.Error("Expected error for issuer with query component")
+	}
+}
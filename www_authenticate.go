@@ -1,11 +1,35 @@
 package oauth
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
 )
 
+// bareSchemeRegex matches a challenge consisting only of an auth scheme token (RFC 7235 Section 2.1)
+var bareSchemeRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9!#$%&'*+.^_` + "`" + `|~-]*$`)
+
+// paramStartRegex matches a header item that starts with an auth-param rather than a scheme
+var paramStartRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+\s*=`)
+
+// quotedPairRegex matches a backslash-escaped character in a quoted-string
+var quotedPairRegex = regexp.MustCompile(`\\(.)`)
+
+// paramRegex matches key=value auth-params with quoted (backslash escapes allowed) or unquoted values
+var paramRegex = regexp.MustCompile(`([a-zA-Z0-9_-]+)\s*=\s*(?:"((?:[^"\\]|\\.)*)"|([^,\s]+))`)
+
+// ErrEmptyHeader is returned by ParseWWWAuthenticate when the header carries no challenge at all
+var ErrEmptyHeader = errors.New("empty WWW-Authenticate header")
+
+// ErrMalformedHeader is returned by ParseWWWAuthenticate when the header is present but unparseable
+var ErrMalformedHeader = errors.New("malformed WWW-Authenticate header")
+
+// utf8BOM is the UTF-8 encoded byte order mark some servers prepend to header values
+const utf8BOM = "\uFEFF"
+
 // ParseWWWAuthenticate parses a WWW-Authenticate header value
 //
 // RFC 6750 COMPLIANCE - OAuth 2.0 Bearer Token Usage:
@@ -19,77 +43,102 @@ import (
 //
 // ROBUST PARSING:
 // - Handles quoted and unquoted parameter values
-// - Supports multiple authentication schemes in single header
+// - Supports multiple authentication schemes in single header, each keeping only its own params
+// - Commas inside quoted values do not split params or challenges
 // - Gracefully handles malformed headers (best-effort parsing)
+// - Ignores a leading UTF-8 BOM and surrounding whitespace
+// - Returns ErrEmptyHeader for a blank header and an error wrapping ErrMalformedHeader for unparseable input
 //
 // Example inputs:
 //
 //	Bearer realm="example.com", scope="read write", resource_metadata="https://example.com/.well-known/oauth-protected-resource"
 //	Bearer realm=example.com scope="read write"
 //	Basic realm="example.com", Bearer realm="api.example.com" scope="read"
+//	Bearer
 func ParseWWWAuthenticate(headerValue string) ([]WWWAuthenticateChallenge, error) {
+	// Some servers prepend a UTF-8 byte order mark or pad the value with whitespace
+	headerValue = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(headerValue), utf8BOM))
 	if headerValue == "" {
-		return nil, fmt.Errorf("empty WWW-Authenticate header")
+		return nil, ErrEmptyHeader
 	}
 
+	// RFC 7235 Section 4.1: challenges and their auth-params are all separated by commas,
+	// so split on commas outside quoted strings and decide per item whether it starts a new
+	// challenge ("Bearer realm=x"), is a bare scheme ("Bearer") or continues the current
+	// challenge ("scope=read"). Params never leak across scheme boundaries.
 	var challenges []WWWAuthenticateChallenge
-
-	// Use regex to find auth schemes and their parameters
-	// This handles multiple schemes in one header: Basic realm="...", Bearer realm="..." scope="..."
-	schemeRegex := regexp.MustCompile(`(?i)([a-z][a-z0-9\-_]*)\s+([^,]*(?:,\s*[^=\s]+\s*=\s*[^,]*)*)[,\s]*`)
-	matches := schemeRegex.FindAllStringSubmatch(headerValue, -1)
-
-	if len(matches) == 0 {
-		// Fallback: try to parse as single scheme if regex fails
-		return parseSingleScheme(headerValue)
-	}
-
-	for _, match := range matches {
-		if len(match) < 3 {
+	for _, item := range splitHeaderItems(headerValue) {
+		if paramStartRegex.MatchString(item) {
+			if len(challenges) == 0 {
+				// auth-param before any scheme - nothing to attach it to
+				continue
+			}
+			current := &challenges[len(challenges)-1]
+			parameters, ordered := parseAuthParameters(item)
+			maps.Copy(current.Parameters, parameters)
+			current.OrderedParameters = append(current.OrderedParameters, ordered...)
 			continue
 		}
-		scheme := match[1]
-		paramString := match[2]
 
-		parameters := parseAuthParameters(paramString)
+		scheme, paramString, _ := strings.Cut(item, " ")
+		// The scheme must be a token (RFC 7235 Section 2.1); rejects quoted or punctuation-only input
+		if !bareSchemeRegex.MatchString(scheme) {
+			continue
+		}
 
+		// Lenient: params of one challenge may also be separated by whitespace only
+		parameters, ordered := parseAuthParameters(strings.TrimSpace(paramString))
 		challenges = append(challenges, WWWAuthenticateChallenge{
-			Scheme:     scheme,
-			Parameters: parameters,
+			Scheme:            scheme,
+			Parameters:        parameters,
+			OrderedParameters: ordered,
 		})
 	}
 
 	if len(challenges) == 0 {
-		return nil, fmt.Errorf("no valid authentication challenges found in WWW-Authenticate header: %s", headerValue)
+		return nil, fmt.Errorf("%w: no valid authentication challenges found: %s", ErrMalformedHeader, headerValue)
 	}
 
 	return challenges, nil
 }
 
-// parseSingleScheme attempts to parse a header with a single authentication scheme
-func parseSingleScheme(headerValue string) ([]WWWAuthenticateChallenge, error) {
-	parts := strings.SplitN(strings.TrimSpace(headerValue), " ", 2)
-	if len(parts) < 1 {
-		return nil, fmt.Errorf("invalid WWW-Authenticate header format")
-	}
+// splitHeaderItems splits a header value at commas that are not inside a quoted string
+// Items are trimmed and empty items (e.g. from ", ,") are dropped
+func splitHeaderItems(headerValue string) []string {
+	var items []string
+	var current strings.Builder
+	inQuotes, escaped := false, false
 
-	scheme := parts[0]
-	var paramString string
-	if len(parts) > 1 {
-		paramString = parts[1]
+	flush := func() {
+		if item := strings.TrimSpace(current.String()); item != "" {
+			items = append(items, item)
+		}
+		current.Reset()
 	}
 
-	parameters := parseAuthParameters(paramString)
+	for _, r := range headerValue {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuotes && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == ',' && !inQuotes:
+			flush()
+			continue
+		case r == '\t' && !inQuotes:
+			r = ' '
+		}
+		current.WriteRune(r)
+	}
+	flush()
 
-	return []WWWAuthenticateChallenge{
-		{
-			Scheme:     scheme,
-			Parameters: parameters,
-		},
-	}, nil
+	return items
 }
 
 // parseAuthParameters parses authentication parameters from a parameter string
+// Returns them both as a lookup map (last value wins) and in header order
 //
 // Handles multiple formats:
 // - Quoted values: param="value"
@@ -101,17 +150,17 @@ func parseSingleScheme(headerValue string) ([]WWWAuthenticateChallenge, error) {
 //	realm="example.com", scope="read write"
 //	realm=example.com scope="read write"
 //	realm="example.com", resource_metadata="https://example.com/.well-known/oauth-protected-resource"
-func parseAuthParameters(paramString string) map[string]string {
+func parseAuthParameters(paramString string) (map[string]string, []AuthParam) {
 	parameters := make(map[string]string)
 
 	if paramString == "" {
-		return parameters
+		return parameters, nil
 	}
 
 	// Use regex to parse key=value pairs, handling quoted and unquoted values
-	paramRegex := regexp.MustCompile(`([a-zA-Z0-9_-]+)\s*=\s*(?:"([^"]*)"|([^,\s]+))`)
 	matches := paramRegex.FindAllStringSubmatch(paramString, -1)
 
+	var ordered []AuthParam
 	for _, match := range matches {
 		if len(match) < 4 {
 			continue
@@ -124,15 +173,40 @@ func parseAuthParameters(paramString string) map[string]string {
 		// Use quoted value if present, otherwise unquoted
 		var value string
 		if quotedValue != "" {
-			value = quotedValue
+			// RFC 9110 Section 5.6.4: a backslash escapes the next character in a quoted-string
+			value = quotedPairRegex.ReplaceAllString(quotedValue, "$1")
 		} else {
 			value = unquotedValue
 		}
 
 		parameters[key] = value
+		ordered = append(ordered, AuthParam{Key: key, Value: value})
+	}
+
+	return parameters, ordered
+}
+
+// GetParameter returns the value of an auth-param, matching the name case-insensitively
+//
+// RFC 7235 Section 2.1: auth-param names are case-insensitive
+func (c *WWWAuthenticateChallenge) GetParameter(name string) (string, bool) {
+	if value, exists := c.Parameters[name]; exists {
+		return value, true
+	}
+	for key, value := range c.Parameters {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
 	}
+	return "", false
+}
 
-	return parameters
+// MustGetParameter returns the value of an auth-param, or defaultValue if it is absent
+func (c *WWWAuthenticateChallenge) MustGetParameter(name, defaultValue string) string {
+	if value, exists := c.GetParameter(name); exists {
+		return value
+	}
+	return defaultValue
 }
 
 // FindResourceMetadataURL searches for the resource_metadata URL in WWW-Authenticate challenges
@@ -142,52 +216,49 @@ func parseAuthParameters(paramString string) map[string]string {
 // - Returns the first resource_metadata URL found across all challenges
 func FindResourceMetadataURL(challenges []WWWAuthenticateChallenge) string {
 	for _, challenge := range challenges {
-		if challenge.Parameters == nil {
-			continue
-		}
-		if resourceMetadataURL, exists := challenge.Parameters["resource_metadata"]; exists && resourceMetadataURL != "" {
+		if resourceMetadataURL, exists := challenge.GetParameter("resource_metadata"); exists && resourceMetadataURL != "" {
 			return resourceMetadataURL
 		}
 	}
 	return ""
 }
 
+// FindAllResourceMetadataURLs returns every distinct resource_metadata URL in WWW-Authenticate challenges
+//
+// RFC 9728 COMPLIANCE:
+// - Section 5.1: Each challenge (protection space) may carry its own resource_metadata parameter
+// - URLs are returned in header order with duplicates removed
+func FindAllResourceMetadataURLs(challenges []WWWAuthenticateChallenge) []string {
+	var urls []string
+	for _, challenge := range challenges {
+		if resourceMetadataURL, exists := challenge.GetParameter("resource_metadata"); exists && resourceMetadataURL != "" {
+			if !slices.Contains(urls, resourceMetadataURL) {
+				urls = append(urls, resourceMetadataURL)
+			}
+		}
+	}
+	return urls
+}
+
 // FindRequiredScopes extracts required OAuth scopes from WWW-Authenticate challenges
 //
 // RFC 6750 COMPLIANCE:
 // - Section 3: Defines scope parameter in Bearer challenges
-// - Returns space-separated scopes as a slice
+// - Returns space-separated scopes as a slice (comma and "+" delimiters are tolerated, see ParseScopes)
 //
-// Searches all Bearer challenges and combines scopes into a unique set
+// Searches all Bearer challenges and combines scopes into a unique set, in header order
 func FindRequiredScopes(challenges []WWWAuthenticateChallenge) []string {
-	scopesSet := make(map[string]bool)
-
+	var scopeParams []string
 	for _, challenge := range challenges {
 		// Only process Bearer challenges for OAuth scopes
 		if !strings.EqualFold(challenge.Scheme, "Bearer") {
 			continue
 		}
 
-		if challenge.Parameters == nil {
-			continue
+		if scopeParam, exists := challenge.GetParameter("scope"); exists && scopeParam != "" {
+			scopeParams = append(scopeParams, scopeParam)
 		}
-
-		if scopeParam, exists := challenge.Parameters["scope"]; exists && scopeParam != "" {
-			// Split space-separated scopes per OAuth 2.0 spec (RFC 6749 Section 3.3)
-			scopes := strings.Fields(scopeParam)
-			for _, scope := range scopes {
-				if scope != "" {
-					scopesSet[scope] = true
-				}
-			}
-		}
-	}
-
-	// Convert set to slice
-	var scopes []string
-	for scope := range scopesSet {
-		scopes = append(scopes, scope)
 	}
 
-	return scopes
+	return ParseScopes(strings.Join(scopeParams, " "))
 }
@@ -1,6 +1,9 @@
 package oauth
 
 import (
+	"errors"
+	"maps"
+	"slices"
 	"testing"
 )
 
@@ -63,6 +66,93 @@ func TestParseWWWAuthenticate_Valid(t *testing.T) {
 	}
 }
 
+// TestParseWWWAuthenticate_PerSchemeParameters verifies each challenge keeps exactly its own
+// parameters when several schemes share one header
+func TestParseWWWAuthenticate_PerSchemeParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		expect []WWWAuthenticateChallenge
+	}{
+		{
+			name:   "whitespace-separated params after second scheme",
+			header: `Basic realm="web", Bearer realm="api" scope="read"`,
+			expect: []WWWAuthenticateChallenge{
+				{Scheme: "Basic", Parameters: map[string]string{"realm": "web"}},
+				{Scheme: "Bearer", Parameters: map[string]string{"realm": "api", "scope": "read"}},
+			},
+		},
+		{
+			name:   "comma-separated params after second scheme",
+			header: `Basic realm="web", Bearer realm="api", scope="read"`,
+			expect: []WWWAuthenticateChallenge{
+				{Scheme: "Basic", Parameters: map[string]string{"realm": "web"}},
+				{Scheme: "Bearer", Parameters: map[string]string{"realm": "api", "scope": "read"}},
+			},
+		},
+		{
+			name:   "Bearer first with several params",
+			header: `Bearer realm="api", scope="a b", error="invalid_token", Basic realm="x"`,
+			expect: []WWWAuthenticateChallenge{
+				{Scheme: "Bearer", Parameters: map[string]string{"realm": "api", "scope": "a b", "error": "invalid_token"}},
+				{Scheme: "Basic", Parameters: map[string]string{"realm": "x"}},
+			},
+		},
+		{
+			name:   "comma inside quoted value",
+			header: `Basic realm="a, Bearer b", Bearer scope="read"`,
+			expect: []WWWAuthenticateChallenge{
+				{Scheme: "Basic", Parameters: map[string]string{"realm": "a, Bearer b"}},
+				{Scheme: "Bearer", Parameters: map[string]string{"scope": "read"}},
+			},
+		},
+		{
+			name:   "escaped quote inside quoted value",
+			header: `Bearer realm="say \"hi\", then", scope="read"`,
+			expect: []WWWAuthenticateChallenge{
+				{Scheme: "Bearer", Parameters: map[string]string{"realm": `say "hi", then`, "scope": "read"}},
+			},
+		},
+		{
+			name:   "bare scheme before parameterized scheme",
+			header: `Negotiate, Bearer realm="api"`,
+			expect: []WWWAuthenticateChallenge{
+				{Scheme: "Negotiate", Parameters: map[string]string{}},
+				{Scheme: "Bearer", Parameters: map[string]string{"realm": "api"}},
+			},
+		},
+		{
+			name:   "bare scheme after parameterized scheme",
+			header: `Basic realm="web", Bearer`,
+			expect: []WWWAuthenticateChallenge{
+				{Scheme: "Basic", Parameters: map[string]string{"realm": "web"}},
+				{Scheme: "Bearer", Parameters: map[string]string{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenges, err := ParseWWWAuthenticate(tt.header)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if len(challenges) != len(tt.expect) {
+				t.Fatalf("Expected %d challenges, got %d: %+v", len(tt.expect), len(challenges), challenges)
+			}
+			for i, want := range tt.expect {
+				got := challenges[i]
+				if got.Scheme != want.Scheme {
+					t.Errorf("Challenge %d: expected scheme %q, got %q", i, want.Scheme, got.Scheme)
+				}
+				if !maps.Equal(got.Parameters, want.Parameters) {
+					t.Errorf("Challenge %d (%s): expected parameters %v, got %v", i, want.Scheme, want.Parameters, got.Parameters)
+				}
+			}
+		})
+	}
+}
+
 // TestParseWWWAuthenticate_Malformed verifies error handling for invalid headers
 func TestParseWWWAuthenticate_Malformed(t *testing.T) {
 	// Empty header should return error
@@ -70,6 +160,71 @@ func TestParseWWWAuthenticate_Malformed(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for empty header")
 	}
+
+	tests := []struct {
+		name     string
+		header   string
+		expected error
+	}{
+		{"empty", "", ErrEmptyHeader},
+		{"whitespace only", "  \t ", ErrEmptyHeader},
+		{"quoted garbage", `"Bearer" realm="x"`, ErrMalformedHeader},
+		{"punctuation", "=== ,,,", ErrMalformedHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWWWAuthenticate(tt.header)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v for %q, got %v", tt.expected, tt.header, err)
+			}
+			other := ErrMalformedHeader
+			if errors.Is(tt.expected, ErrMalformedHeader) {
+				other = ErrEmptyHeader
+			}
+			if errors.Is(err, other) {
+				t.Errorf("Expected %q not to match %v", tt.header, other)
+			}
+		})
+	}
+}
+
+// TestParseWWWAuthenticate_BOMAndWhitespace verifies a leading BOM and surrounding whitespace are ignored
+func TestParseWWWAuthenticate_BOMAndWhitespace(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"leading BOM", "\uFEFFBearer realm=\"example\", scope=\"read write\""},
+		{"leading and trailing spaces", "   Bearer realm=\"example\", scope=\"read write\"  \t"},
+		{"BOM after whitespace", " \uFEFF Bearer realm=\"example\", scope=\"read write\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenges, err := ParseWWWAuthenticate(tt.header)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if len(challenges) != 1 {
+				t.Fatalf("Expected 1 challenge, got %d", len(challenges))
+			}
+			if challenges[0].Scheme != "Bearer" {
+				t.Errorf("Expected scheme Bearer, got %q", challenges[0].Scheme)
+			}
+			if got := challenges[0].Parameters["scope"]; got != "read write" {
+				t.Errorf("Expected scope %q, got %q", "read write", got)
+			}
+		})
+	}
+
+	if _, err := ParseWWWAuthenticate("\uFEFF  "); err == nil {
+		t.Error("Expected error for header containing only a BOM and whitespace")
+	}
+	challenges, err := ParseWWWAuthenticate("\uFEFFBearer")
+	if err != nil || len(challenges) != 1 || challenges[0].Scheme != "Bearer" {
+		t.Errorf("Expected bare Bearer challenge after BOM, got %v, %v", challenges, err)
+	}
 }
 
 // TestFindResourceMetadataURL verifies extraction of resource_metadata URL
@@ -151,6 +306,24 @@ func TestFindRequiredScopes(t *testing.T) {
 			},
 			expectScopes: []string{"read", "write", "admin"},
 		},
+		{
+			name: "Comma-delimited scopes across challenges",
+			challenges: []WWWAuthenticateChallenge{
+				{
+					Scheme: "Bearer",
+					Parameters: map[string]string{
+						"scope": "read,write",
+					},
+				},
+				{
+					Scheme: "Bearer",
+					Parameters: map[string]string{
+						"scope": "write admin",
+					},
+				},
+			},
+			expectScopes: []string{"read", "write", "admin"},
+		},
 		{
 			name: "No scopes",
 			challenges: []WWWAuthenticateChallenge{
@@ -172,3 +345,153 @@ func TestFindRequiredScopes(t *testing.T) {
 		})
 	}
 }
+
+// TestParseWWWAuthenticate_BareBearer verifies a bare "Bearer" challenge (no parameters) is accepted
+func TestParseWWWAuthenticate_BareBearer(t *testing.T) {
+	for _, header := range []string{"Bearer", "  Bearer  "} {
+		challenges, err := ParseWWWAuthenticate(header)
+		if err != nil {
+			t.Fatalf("Parse failed for %q: %v", header, err)
+		}
+		if len(challenges) != 1 {
+			t.Fatalf("Expected 1 challenge for %q, got %d", header, len(challenges))
+		}
+		if challenges[0].Scheme != "Bearer" {
+			t.Errorf("Expected scheme Bearer, got %q", challenges[0].Scheme)
+		}
+		if challenges[0].Parameters == nil || len(challenges[0].Parameters) != 0 {
+			t.Errorf("Expected empty non-nil Parameters, got %#v", challenges[0].Parameters)
+		}
+	}
+}
+
+// TestFindAllResourceMetadataURLs verifies extraction of distinct resource_metadata URLs in order
+func TestFindAllResourceMetadataURLs(t *testing.T) {
+	challenges, err := ParseWWWAuthenticate(`Bearer realm="a", resource_metadata="https://a.example.com/rm", Bearer realm="b", resource_metadata="https://b.example.com/rm", Bearer realm="c", resource_metadata="https://a.example.com/rm"`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	urls := FindAllResourceMetadataURLs(challenges)
+	expected := []string{"https://a.example.com/rm", "https://b.example.com/rm"}
+	if len(urls) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, urls)
+	}
+	for i := range expected {
+		if urls[i] != expected[i] {
+			t.Errorf("URL %d: expected %s, got %s", i, expected[i], urls[i])
+		}
+	}
+
+	if urls := FindAllResourceMetadataURLs(nil); len(urls) != 0 {
+		t.Errorf("Expected no URLs for nil challenges, got %v", urls)
+	}
+}
+
+// TestWWWAuthenticateChallenge_GetParameter verifies case-insensitive parameter lookup
+func TestWWWAuthenticateChallenge_GetParameter(t *testing.T) {
+	challenges, err := ParseWWWAuthenticate(`Bearer Realm="example", Resource_Metadata="https://example.com/rm"`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	challenge := challenges[0]
+
+	if value, ok := challenge.GetParameter("realm"); !ok || value != "example" {
+		t.Errorf("Expected realm=example, got %q (found=%v)", value, ok)
+	}
+	if _, ok := challenge.GetParameter("scope"); ok {
+		t.Error("Expected scope to be absent")
+	}
+	if value := challenge.MustGetParameter("scope", "default"); value != "default" {
+		t.Errorf("Expected default value, got %q", value)
+	}
+
+	// Find* helpers match parameter names case-insensitively too
+	if url := FindResourceMetadataURL(challenges); url != "https://example.com/rm" {
+		t.Errorf("Expected resource metadata URL from mixed-case parameter, got %q", url)
+	}
+
+	// Challenges without parameters are handled
+	empty := WWWAuthenticateChallenge{Scheme: "Bearer"}
+	if _, ok := empty.GetParameter("realm"); ok {
+		t.Error("Expected no parameters on empty challenge")
+	}
+}
+
+// FuzzParseWWWAuthenticate verifies the parser never panics on untrusted input and
+// always returns either challenges with a scheme or an error
+func FuzzParseWWWAuthenticate(f *testing.F) {
+	seeds := []string{
+		// Valid headers from the table tests
+		`Bearer realm="example.com", scope="read write", resource_metadata="https://example.com/.well-known/oauth-protected-resource"`,
+		`Bearer realm=example.com scope="read write"`,
+		`Basic realm="example.com", Bearer realm="api.example.com" scope="read"`,
+		`Bearer`,
+		`Bearer error="invalid_token", error_description="The access token expired"`,
+		// Malformed input
+		`Bearer realm="unterminated`,
+		`Bearer realm="a",,, scope="b",`,
+		`Bearer realm=\`,
+		`Bearer realm="a\"b"`,
+		`"Bearer"`,
+		`=`,
+		`, , ,`,
+		" \t ",
+		"Bearer realm=\"\x00\"",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, header string) {
+		challenges, err := ParseWWWAuthenticate(header)
+		if err != nil {
+			if challenges != nil {
+				t.Errorf("Expected nil challenges with error, got %v", challenges)
+			}
+			return
+		}
+
+		if len(challenges) == 0 {
+			t.Fatalf("Expected challenges or error for %q", header)
+		}
+		for _, challenge := range challenges {
+			if challenge.Scheme == "" {
+				t.Errorf("Empty scheme in result for %q", header)
+			}
+			if challenge.Parameters == nil {
+				t.Errorf("Nil parameters in result for %q", header)
+			}
+		}
+	})
+}
+
+// TestParseWWWAuthenticate_OrderedParameters verifies parameter order from the header is preserved
+func TestParseWWWAuthenticate_OrderedParameters(t *testing.T) {
+	header := `Bearer scope="read write", resource_metadata="https://example.com/rm", realm=example, error="invalid_token", Basic realm="basic"`
+	challenges, err := ParseWWWAuthenticate(header)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(challenges) != 2 {
+		t.Fatalf("Expected 2 challenges, got %d", len(challenges))
+	}
+
+	expected := []AuthParam{
+		{Key: "scope", Value: "read write"},
+		{Key: "resource_metadata", Value: "https://example.com/rm"},
+		{Key: "realm", Value: "example"},
+		{Key: "error", Value: "invalid_token"},
+	}
+	if !slices.Equal(challenges[0].OrderedParameters, expected) {
+		t.Errorf("Expected ordered parameters %v, got %v", expected, challenges[0].OrderedParameters)
+	}
+	if len(challenges[0].Parameters) != len(expected) {
+		t.Errorf("Expected map with %d parameters, got %v", len(expected), challenges[0].Parameters)
+	}
+
+	expectedBasic := []AuthParam{{Key: "realm", Value: "basic"}}
+	if !slices.Equal(challenges[1].OrderedParameters, expectedBasic) {
+		t.Errorf("Expected ordered parameters %v, got %v", expectedBasic, challenges[1].OrderedParameters)
+	}
+}